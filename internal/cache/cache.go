@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// Canal do Postgres usado para propagar invalidações entre instâncias
+const notifyChannel = "cache_invalidation"
+
+var (
+	Itens      = NewLRU("itens", 10000, 5*time.Minute)
+	Categorias = NewLRU("categorias", 1000, 5*time.Minute)
+)
+
+// Init - Recria os caches com o tamanho e TTL configurados.
+// Deve ser chamado na inicialização, antes de atender requisições.
+func Init(size int, ttl time.Duration) {
+	Itens = NewLRU("itens", size, ttl)
+	Categorias = NewLRU("categorias", size/10+1, ttl)
+}
+
+func byName(name string) *LRU {
+	switch name {
+	case "itens":
+		return Itens
+	case "categorias":
+		return Categorias
+	}
+	return nil
+}
+
+// Key - Chave de uma entrada do cache por ID
+func Key(id uint) string {
+	return fmt.Sprintf("id:%d", id)
+}
+
+// Invalidate - Remove a entrada localmente e notifica as demais instâncias
+// via NOTIFY. Como o NOTIFY é transacional, quando db estiver dentro de uma
+// transação o aviso só é entregue após o commit.
+func Invalidate(db *gorm.DB, c *LRU, id uint) error {
+	c.Delete(Key(id))
+	return notify(db, c.name+":"+Key(id))
+}
+
+// FlushAll - Esvazia todos os caches e notifica as demais instâncias
+func FlushAll(db *gorm.DB) error {
+	flushLocal()
+	return notify(db, "*")
+}
+
+func flushLocal() {
+	Itens.Flush()
+	Categorias.Flush()
+}
+
+func notify(db *gorm.DB, payload string) error {
+	return db.Exec("SELECT pg_notify(?, ?)", notifyChannel, payload).Error
+}
+
+// observadores - Recebem cada invalidação vinda do canal, inclusive as
+// publicadas por esta instância, quando a alteração já foi confirmada
+var (
+	observadoresMu sync.Mutex
+	observadores   []func(payload string)
+)
+
+// Observar - Registra fn para receber as invalidações ("itens:id:5"); "*"
+// indica que qualquer entrada pode ter mudado (FlushAll ou perda da escuta)
+func Observar(fn func(payload string)) {
+	observadoresMu.Lock()
+	defer observadoresMu.Unlock()
+	observadores = append(observadores, fn)
+}
+
+func observar(payload string) {
+	observadoresMu.Lock()
+	fns := observadores
+	observadoresMu.Unlock()
+	for _, fn := range fns {
+		fn(payload)
+	}
+}
+
+// apply - Aplica localmente uma invalidação recebida ("itens:id:5" ou "*")
+func apply(payload string) {
+	defer observar(payload)
+	if payload == "*" {
+		flushLocal()
+		return
+	}
+	name, key, ok := strings.Cut(payload, ":")
+	if !ok {
+		return
+	}
+	if c := byName(name); c != nil {
+		c.Delete(key)
+	}
+}
+
+// Listen - Escuta as invalidações publicadas pelas outras instâncias.
+// Se a conexão cair, os caches são esvaziados (avisos podem ter sido
+// perdidos) e a escuta é retomada.
+func Listen(dsn string) {
+	go func() {
+		for {
+			err := listen(context.Background(), dsn)
+			log.Printf("Escuta de invalidação do cache interrompida: %v", err)
+			flushLocal()
+			observar("*")
+			time.Sleep(5 * time.Second)
+		}
+	}()
+}
+
+func listen(ctx context.Context, dsn string) error {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+
+	if _, err := conn.Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+		return err
+	}
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		apply(n.Payload)
+	}
+}
@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"container/list"
+	"expvar"
+	"sync"
+	"time"
+)
+
+var stats = expvar.NewMap("cache")
+
+// LRU - Cache em memória limitado por tamanho, com expiração por TTL
+type LRU struct {
+	name  string
+	size  int
+	ttl   time.Duration
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+	// Contador de invalidações (Delete e Flush), usado por SetIfCurrent
+	generation uint64
+}
+
+type entry struct {
+	key       string
+	value     any
+	expiresAt time.Time
+}
+
+// NewLRU - Cria um cache com no máximo size entradas válidas por ttl.
+// As métricas de acertos e falhas são publicadas em /debug/vars como
+// cache.<name>_hits e cache.<name>_misses.
+func NewLRU(name string, size int, ttl time.Duration) *LRU {
+	return &LRU{
+		name:  name,
+		size:  size,
+		ttl:   ttl,
+		ll:    list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// Get - Busca uma entrada, descartando-a se estiver expirada
+func (c *LRU) Get(key string) (any, bool) {
+	value, ok := c.Peek(key)
+	c.Record(ok)
+	return value, ok
+}
+
+// Peek - Como Get, mas sem contabilizar acerto ou falha; para consultas
+// compostas de mais de uma entrada, que registram o resultado com Record
+func (c *LRU) Peek(key string) (any, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry)
+		if time.Now().Before(e.expiresAt) {
+			c.ll.MoveToFront(el)
+			return e.value, true
+		}
+		c.removeElement(el)
+	}
+	return nil, false
+}
+
+// Record - Contabiliza um acerto ou uma falha
+func (c *LRU) Record(hit bool) {
+	if hit {
+		stats.Add(c.name+"_hits", 1)
+	} else {
+		stats.Add(c.name+"_misses", 1)
+	}
+}
+
+// Generation - Marca a ser obtida antes de ler do banco o valor que será
+// gravado com SetIfCurrent
+func (c *LRU) Generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// SetIfCurrent - Grava a entrada apenas se nenhuma invalidação ocorreu desde
+// a marca: uma leitura concorrente a uma alteração pode ter obtido o valor
+// antigo, e gravá-lo depois da invalidação o manteria até o TTL
+func (c *LRU) SetIfCurrent(key string, value any, generation uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation == generation {
+		c.set(key, value)
+	}
+}
+
+// Set - Grava uma entrada, removendo a menos usada se o cache estiver cheio
+func (c *LRU) Set(key string, value any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, value)
+}
+
+func (c *LRU) set(key string, value any) {
+	if c.size <= 0 {
+		return
+	}
+	expiresAt := time.Now().Add(c.ttl)
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry)
+		e.value = value
+		e.expiresAt = expiresAt
+		c.ll.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&entry{key: key, value: value, expiresAt: expiresAt})
+	for c.ll.Len() > c.size {
+		c.removeElement(c.ll.Back())
+		stats.Add(c.name+"_evictions", 1)
+	}
+}
+
+// Delete - Remove uma entrada
+func (c *LRU) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+}
+
+// Flush - Remove todas as entradas
+func (c *LRU) Flush() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+}
+
+// Len - Quantidade de entradas no cache (incluindo as expiradas ainda não removidas)
+func (c *LRU) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+func (c *LRU) removeElement(el *list.Element) {
+	c.ll.Remove(el)
+	delete(c.items, el.Value.(*entry).key)
+}
@@ -0,0 +1,14 @@
+package config
+
+// AdminConfig - Acesso às rotas administrativas (/admin)
+type AdminConfig struct {
+	// Token exigido no header Authorization: Bearer; vazio desabilita as rotas
+	Token string
+}
+
+// LoadAdminConfig - Carrega a configuração das rotas administrativas a partir das variáveis de ambiente
+func LoadAdminConfig() AdminConfig {
+	return AdminConfig{
+		Token: getEnv("ADMIN_TOKEN", ""),
+	}
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"context"
+	"strings"
+)
+
+type apiKeyKey struct{}
+
+// LoadApiKeys - API keys aceitas, listadas em API_KEYS (separadas por ",")
+func LoadApiKeys() map[string]bool {
+	keys := make(map[string]bool)
+	for _, key := range strings.Split(getEnv("API_KEYS", ""), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys[key] = true
+		}
+	}
+	return keys
+}
+
+// WithApiKey - Anexa ao contexto a API key validada da requisição
+func WithApiKey(ctx context.Context, apiKey string) context.Context {
+	return context.WithValue(ctx, apiKeyKey{}, apiKey)
+}
+
+// ApiKey - API key validada da requisição; vazia quando o header não foi
+// enviado ou traz uma chave desconhecida
+func ApiKey(ctx context.Context) string {
+	apiKey, _ := ctx.Value(apiKeyKey{}).(string)
+	return apiKey
+}
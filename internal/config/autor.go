@@ -0,0 +1,16 @@
+package config
+
+import "context"
+
+type autorKey struct{}
+
+// WithAutor - Anexa ao contexto o autor da requisição (registrado em históricos)
+func WithAutor(ctx context.Context, autor string) context.Context {
+	return context.WithValue(ctx, autorKey{}, autor)
+}
+
+// Autor - Autor da requisição; vazio para rotinas internas
+func Autor(ctx context.Context) string {
+	autor, _ := ctx.Value(autorKey{}).(string)
+	return autor
+}
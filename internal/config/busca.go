@@ -0,0 +1,38 @@
+package config
+
+import (
+	"time"
+
+	"myapi/internal/decimal"
+)
+
+// BuscaConfig - Parâmetros da busca de itens
+type BuscaConfig struct {
+	// Largura padrão das faixas de preço nas facetas
+	FaixaPreco decimal.Decimal
+	// Quantos valores mais frequentes de cada atributo entram nas facetas
+	ValoresPorAtributo int
+	// Sugestões devolvidas sem ?limite= e o máximo aceito nele
+	SugestoesPadrao int
+	SugestoesMaximo int
+	// Caracteres digitados a partir dos quais há sugestões
+	PrefixoMinimo int
+	// Período de vendas que define a popularidade do item
+	PopularidadeDias int
+	// Intervalo da reconstrução completa do índice de sugestões, que também
+	// recalcula a popularidade dos itens sem alterações
+	ReconstrucaoInterval time.Duration
+}
+
+// LoadBuscaConfig - Carrega a configuração da busca a partir das variáveis de ambiente
+func LoadBuscaConfig() BuscaConfig {
+	return BuscaConfig{
+		FaixaPreco:           getEnvDecimal("BUSCA_FAIXA_PRECO", "100"),
+		ValoresPorAtributo:   getEnvInt("BUSCA_VALORES_POR_ATRIBUTO", 20),
+		SugestoesPadrao:      getEnvInt("BUSCA_SUGESTOES", 10),
+		SugestoesMaximo:      getEnvInt("BUSCA_SUGESTOES_MAXIMO", 50),
+		PrefixoMinimo:        getEnvInt("BUSCA_PREFIXO_MINIMO", 2),
+		PopularidadeDias:     getEnvInt("BUSCA_POPULARIDADE_DIAS", 90),
+		ReconstrucaoInterval: getEnvDuration("BUSCA_RECONSTRUCAO_INTERVAL", time.Hour),
+	}
+}
@@ -0,0 +1,17 @@
+package config
+
+import "time"
+
+// CacheConfig - Parâmetros do cache em memória
+type CacheConfig struct {
+	Size int
+	TTL  time.Duration
+}
+
+// LoadCacheConfig - Carrega a configuração do cache a partir das variáveis de ambiente
+func LoadCacheConfig() CacheConfig {
+	return CacheConfig{
+		Size: getEnvInt("CACHE_SIZE", 10000),
+		TTL:  getEnvDuration("CACHE_TTL", 5*time.Minute),
+	}
+}
@@ -1,7 +1,12 @@
 package config
 
 import (
+	"database/sql"
+	"expvar"
+	"fmt"
 	"log"
+	"math/rand"
+	"time"
 
 	"myapi/internal/models"
 
@@ -11,19 +16,312 @@ import (
 
 var DB *gorm.DB
 
+// DatabaseConfig - Parâmetros de conexão, retry e pool do banco
+type DatabaseConfig struct {
+	Host     string
+	Port     string
+	User     string
+	Password string
+	Name     string
+
+	// Retry na inicialização: backoff exponencial com jitter até ConnectTimeout
+	ConnectTimeout time.Duration
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// Pool de conexões
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+
+	// Intervalo de coleta das estatísticas do pool
+	PoolStatsInterval time.Duration
+}
+
+// LoadDatabaseConfig - Carrega a configuração do banco a partir das variáveis de ambiente
+func LoadDatabaseConfig() DatabaseConfig {
+	return DatabaseConfig{
+		// Usando host "db" por padrão pois o docker-compose cria essa rede
+		Host:     getEnv("POSTGRES_HOST", "db"),
+		Port:     getEnv("POSTGRES_PORT", "5432"),
+		User:     getEnv("POSTGRES_USER", "postgres"),
+		Password: getEnv("POSTGRES_PASSWORD", "postgres"),
+		Name:     getEnv("POSTGRES_DB", "postgres"),
+
+		ConnectTimeout: getEnvDuration("DB_CONNECT_TIMEOUT", 60*time.Second),
+		InitialBackoff: getEnvDuration("DB_CONNECT_INITIAL_BACKOFF", 500*time.Millisecond),
+		MaxBackoff:     getEnvDuration("DB_CONNECT_MAX_BACKOFF", 10*time.Second),
+
+		MaxOpenConns:    getEnvInt("DB_MAX_OPEN_CONNS", 25),
+		MaxIdleConns:    getEnvInt("DB_MAX_IDLE_CONNS", 10),
+		ConnMaxLifetime: getEnvDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+		ConnMaxIdleTime: getEnvDuration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+
+		PoolStatsInterval: getEnvDuration("DB_POOL_STATS_INTERVAL", 15*time.Second),
+	}
+}
+
+// DSN - Monta a string de conexão do Postgres
+func (c DatabaseConfig) DSN() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=UTC",
+		c.Host, c.User, c.Password, c.Name, c.Port)
+}
+
 func ConnectDatabase() {
-	// Conexão com o Postgres (usando host "db" pois o docker-compose cria essa rede)
-	dsn := "host=db user=postgres password=postgres dbname=postgres port=5432 sslmode=disable TimeZone=UTC"
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	cfg := LoadDatabaseConfig()
+
+	db, err := openWithRetry(cfg.DSN(), cfg)
 	if err != nil {
 		log.Fatalf("Erro ao conectar com o BD: %v", err)
 	}
 	DB = db
 
+	if err := configurePool(DB, cfg); err != nil {
+		log.Fatalf("Erro ao configurar o pool de conexões: %v", err)
+	}
+
 	if err := DB.AutoMigrate(&models.Iten{}); err != nil {
 		log.Fatalf("Erro ao migrar tabela Iten: %v", err)
 	}
-	if err := DB.AutoMigrate(&models.Categoria{}); err != nil {
+	if err := DB.AutoMigrate(&models.HistoricoPreco{}); err != nil {
+		log.Fatalf("Erro ao migrar tabela de histórico de preços: %v", err)
+	}
+	if err := DB.AutoMigrate(&models.TabelaPreco{}, &models.PrecoTabela{}, &models.RegraPreco{}); err != nil {
+		log.Fatalf("Erro ao migrar tabelas de precificação: %v", err)
+	}
+	if err := DB.AutoMigrate(&models.Cotacao{}); err != nil {
+		log.Fatalf("Erro ao migrar tabela de cotações: %v", err)
+	}
+	if err := DB.AutoMigrate(&models.AliquotaIcms{}); err != nil {
+		log.Fatalf("Erro ao migrar tabela de alíquotas de ICMS: %v", err)
+	}
+	if err := DB.AutoMigrate(&models.Categoria{}, &models.CategoriaCaminho{}); err != nil {
 		log.Fatalf("Erro ao migrar tabela Categoria: %v", err)
 	}
+	if err := DB.AutoMigrate(&models.IdempotencyKey{}); err != nil {
+		log.Fatalf("Erro ao migrar tabela IdempotencyKey: %v", err)
+	}
+	if err := DB.AutoMigrate(&models.RateLimitBucket{}, &models.ApiKeyQuota{}); err != nil {
+		log.Fatalf("Erro ao migrar tabelas de rate limiting: %v", err)
+	}
+	if err := DB.AutoMigrate(&models.Deposito{}, &models.EstoqueDeposito{}, &models.Movimentacao{}); err != nil {
+		log.Fatalf("Erro ao migrar tabelas de estoque: %v", err)
+	}
+	if err := DB.AutoMigrate(&models.Localizacao{}, &models.EstoqueLocalizacao{}, &models.RegraArmazenagem{}); err != nil {
+		log.Fatalf("Erro ao migrar tabelas de localização: %v", err)
+	}
+	if err := DB.AutoMigrate(&models.Lote{}, &models.EstoqueLote{}, &models.MovimentacaoLote{}); err != nil {
+		log.Fatalf("Erro ao migrar tabelas de lotes: %v", err)
+	}
+	if err := DB.AutoMigrate(&models.NumeroSerie{}, &models.MovimentacaoSerie{}); err != nil {
+		log.Fatalf("Erro ao migrar tabelas de números de série: %v", err)
+	}
+	if err := DB.AutoMigrate(&models.ComponenteKit{}); err != nil {
+		log.Fatalf("Erro ao migrar tabela de componentes de kit: %v", err)
+	}
+	if err := DB.AutoMigrate(&models.CamadaCusto{}); err != nil {
+		log.Fatalf("Erro ao migrar tabela de camadas de custo: %v", err)
+	}
+	if err := DB.AutoMigrate(&models.Fornecedor{}, &models.PedidoCompra{}, &models.PedidoCompraItem{}, &models.RecebimentoCompra{}); err != nil {
+		log.Fatalf("Erro ao migrar tabelas de compras: %v", err)
+	}
+	if err := DB.AutoMigrate(&models.ImportacaoNfe{}, &models.ImportacaoNfeLinha{}, &models.ImportacaoNfeLote{}, &models.ItemFornecedor{}); err != nil {
+		log.Fatalf("Erro ao migrar tabelas de importação de NF-e: %v", err)
+	}
+	if err := DB.AutoMigrate(&models.Cliente{}, &models.PedidoVenda{}, &models.PedidoVendaItem{}); err != nil {
+		log.Fatalf("Erro ao migrar tabelas de vendas: %v", err)
+	}
+	if err := DB.AutoMigrate(&models.ParametroReposicao{}, &models.Notificacao{}); err != nil {
+		log.Fatalf("Erro ao migrar tabelas de reposição: %v", err)
+	}
+	if err := migrateEstoquePorDeposito(DB); err != nil {
+		log.Fatalf("Erro ao migrar saldos para o depósito padrão: %v", err)
+	}
+	if err := migrateSaldosIniciais(DB); err != nil {
+		log.Fatalf("Erro ao criar as camadas de custo iniciais: %v", err)
+	}
+	if err := migrateMoedas(DB); err != nil {
+		log.Fatalf("Erro ao definir a moeda dos preços existentes: %v", err)
+	}
+	if err := migrateHistoricoPrecos(DB); err != nil {
+		log.Fatalf("Erro ao registrar os preços iniciais: %v", err)
+	}
+	if err := migrateCategoriaCaminhos(DB); err != nil {
+		log.Fatalf("Erro ao montar a árvore de categorias: %v", err)
+	}
+}
+
+// migrateEstoquePorDeposito - Move a quantidade dos itens que ainda não têm
+// saldo por depósito para o depósito padrão. Produtos com variantes ficam de
+// fora: a quantidade deles é a soma das variantes.
+func migrateEstoquePorDeposito(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		deposito := models.Deposito{Codigo: LoadEstoqueConfig().DepositoPadrao}
+		if err := tx.Where(models.Deposito{Codigo: deposito.Codigo}).
+			Attrs(models.Deposito{Nome: "Depósito padrão"}).FirstOrCreate(&deposito).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`
+			INSERT INTO estoque_depositos (item_id, deposito_id, quantidade)
+			SELECT i.id, ?, i.quantidade FROM itens i
+			WHERE i.quantidade <> 0 AND i.dimensoes = '[]'::jsonb
+			  AND NOT EXISTS (SELECT 1 FROM estoque_depositos e WHERE e.item_id = i.id)`,
+			deposito.Id).Error
+	})
+}
+
+// migrateSaldosIniciais - Prepara o estoque que existia antes do custeio:
+// registra uma movimentação de saldo inicial para a diferença entre o saldo
+// e as movimentações (para que os relatórios por data fechem com o estoque)
+// e cria uma camada de custo zero para as saídas FIFO terem o que consumir
+func migrateSaldosIniciais(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`
+			INSERT INTO movimentacoes (item_id, deposito_id, tipo, quantidade, valor, referencia, criado_em)
+			SELECT e.item_id, e.deposito_id, ?, e.quantidade - COALESCE(m.quantidade, 0), 0, 'SALDO-INICIAL', NOW()
+			FROM estoque_depositos e
+			LEFT JOIN (SELECT item_id, deposito_id, SUM(quantidade) AS quantidade FROM movimentacoes GROUP BY item_id, deposito_id) m
+				ON m.item_id = e.item_id AND m.deposito_id = e.deposito_id
+			WHERE e.quantidade <> COALESCE(m.quantidade, 0)`, models.MovimentacaoAjuste).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`
+			INSERT INTO camadas_custo (item_id, deposito_id, quantidade, restante, valor, valor_restante, criado_em)
+			SELECT e.item_id, e.deposito_id, e.quantidade - COALESCE(c.restante, 0), e.quantidade - COALESCE(c.restante, 0), 0, 0, NOW()
+			FROM estoque_depositos e
+			LEFT JOIN (SELECT item_id, deposito_id, SUM(restante) AS restante FROM camadas_custo GROUP BY item_id, deposito_id) c
+				ON c.item_id = e.item_id AND c.deposito_id = e.deposito_id
+			WHERE e.quantidade > COALESCE(c.restante, 0)`).Error
+	})
+}
+
+// migrateMoedas - Marca na moeda padrão os preços gravados antes de os
+// preços terem moeda
+func migrateMoedas(db *gorm.DB) error {
+	moeda := LoadDinheiroConfig().MoedaPadrao
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, tabela := range []string{"itens", "historico_precos", "pedidos_venda_itens"} {
+			if err := tx.Exec("UPDATE "+tabela+" SET moeda = ? WHERE moeda IS NULL OR moeda = ''", moeda).Error; err != nil {
+				return err
+			}
+		}
+		// Preços de tabela e de regras de item estavam na moeda do item; os
+		// das regras de categoria e gerais, na moeda padrão
+		if err := tx.Exec(`
+			UPDATE precos_tabela p SET moeda = i.moeda FROM itens i
+			WHERE i.id = p.item_id AND (p.moeda IS NULL OR p.moeda = '')`).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(`
+			UPDATE regras_preco r SET moeda = i.moeda FROM itens i
+			WHERE i.id = r.item_id AND r.preco IS NOT NULL AND (r.moeda IS NULL OR r.moeda = '')`).Error; err != nil {
+			return err
+		}
+		return tx.Exec("UPDATE regras_preco SET moeda = ? WHERE preco IS NOT NULL AND (moeda IS NULL OR moeda = '')", moeda).Error
+	})
+}
+
+// migrateHistoricoPrecos - Abre o histórico dos itens que ainda não têm um
+// com o preço atual; consultas anteriores a esse registro não têm preço
+func migrateHistoricoPrecos(db *gorm.DB) error {
+	return db.Exec(`
+		INSERT INTO historico_precos (item_id, preco, moeda, vigente_de, autor, aplicado, criado_em)
+		SELECT i.id, i.preco, i.moeda, NOW(), '', true, NOW() FROM itens i
+		WHERE NOT EXISTS (SELECT 1 FROM historico_precos h WHERE h.item_id = i.id)`).Error
+}
+
+// migrateCategoriaCaminhos - Completa a tabela de caminhos a partir do
+// parent_id das categorias (as existentes antes da hierarquia viram raízes)
+func migrateCategoriaCaminhos(db *gorm.DB) error {
+	return db.Exec(`
+		INSERT INTO categorias_caminhos (ancestral_id, descendente_id, profundidade)
+		WITH RECURSIVE caminhos AS (
+			SELECT id AS ancestral_id, id AS descendente_id, 0 AS profundidade FROM categorias
+			UNION ALL
+			SELECT c.parent_id, a.descendente_id, a.profundidade + 1
+			FROM caminhos a JOIN categorias c ON c.id = a.ancestral_id
+			WHERE c.parent_id IS NOT NULL AND a.profundidade < 100
+		)
+		SELECT ancestral_id, descendente_id, profundidade FROM caminhos
+		ON CONFLICT DO NOTHING`).Error
+}
+
+// openWithRetry - Abre a conexão tentando novamente com backoff exponencial e jitter
+// até que o prazo cfg.ConnectTimeout seja atingido
+func openWithRetry(dsn string, cfg DatabaseConfig) (*gorm.DB, error) {
+	deadline := time.Now().Add(cfg.ConnectTimeout)
+	// Um backoff nulo não cresceria e faria as tentativas em sequência
+	backoff := max(cfg.InitialBackoff, time.Millisecond)
+
+	for attempt := 1; ; attempt++ {
+		db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+		if err == nil {
+			if attempt > 1 {
+				log.Printf("Conectado ao BD após %d tentativas", attempt)
+			}
+			return db, nil
+		}
+
+		// Jitter: espera um valor aleatório entre backoff/2 e backoff
+		wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		if time.Now().Add(wait).After(deadline) {
+			return nil, fmt.Errorf("desistindo após %d tentativas: %w", attempt, err)
+		}
+		log.Printf("Falha ao conectar com o BD (tentativa %d): %v; nova tentativa em %s", attempt, err, wait.Round(time.Millisecond))
+		time.Sleep(wait)
+
+		// O teto nunca fica abaixo do backoff inicial (nem de 1ms): com
+		// DB_CONNECT_MAX_BACKOFF=0 as tentativas não viram um laço sem espera
+		backoff = min(backoff*2, max(cfg.MaxBackoff, cfg.InitialBackoff, time.Millisecond))
+	}
+}
+
+// configurePool - Aplica os limites do pool e inicia o monitoramento de saturação
+func configurePool(db *gorm.DB, cfg DatabaseConfig) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	publishPoolStats("db_pool", sqlDB)
+	if cfg.PoolStatsInterval > 0 {
+		go monitorPool("primário", sqlDB, cfg.PoolStatsInterval)
+	}
+	return nil
+}
+
+// publishPoolStats - Expõe as estatísticas do pool em /debug/vars
+func publishPoolStats(name string, sqlDB *sql.DB) {
+	if expvar.Get(name) != nil {
+		return
+	}
+	expvar.Publish(name, expvar.Func(func() any {
+		return sqlDB.Stats()
+	}))
+}
+
+// monitorPool - Loga um aviso sempre que requisições precisaram esperar por uma
+// conexão livre no intervalo, indicando pool saturado
+func monitorPool(name string, sqlDB *sql.DB, interval time.Duration) {
+	var lastWaitCount int64
+	var lastWaitDuration time.Duration
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		stats := sqlDB.Stats()
+		waits := stats.WaitCount - lastWaitCount
+		if waits > 0 {
+			log.Printf("Pool %s saturado: %d esperas (%s) nos últimos %s; em uso=%d/%d ociosas=%d",
+				name, waits, (stats.WaitDuration - lastWaitDuration).Round(time.Millisecond), interval,
+				stats.InUse, stats.MaxOpenConnections, stats.Idle)
+		}
+		lastWaitCount = stats.WaitCount
+		lastWaitDuration = stats.WaitDuration
+	}
 }
@@ -0,0 +1,24 @@
+package config
+
+// DinheiroConfig - Parâmetros dos valores monetários
+type DinheiroConfig struct {
+	// Moeda (ISO 4217) dos preços e custos gravados sem moeda explícita
+	MoedaPadrao string
+	// Formato dos valores no JSON de resposta: "numero" (12.5, padrão durante
+	// a transição dos clientes) ou "texto" ("12.50", sem perda de precisão)
+	JSON string
+	// Arredondamento dos preços por moeda, ex: "JPY=0,USD=2,EUR=2:par"
+	// (casas decimais e modo "cima", "par" ou "truncar"); as demais usam 2
+	// casas, meio para cima
+	Arredondamento string
+}
+
+// LoadDinheiroConfig - Carrega a configuração de valores monetários a partir das variáveis de ambiente
+func LoadDinheiroConfig() DinheiroConfig {
+	return DinheiroConfig{
+		MoedaPadrao: getEnv("MOEDA_PADRAO", "BRL"),
+		JSON:        getEnv("DINHEIRO_JSON", "numero"),
+
+		Arredondamento: getEnv("MOEDA_ARREDONDAMENTO", ""),
+	}
+}
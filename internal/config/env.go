@@ -0,0 +1,61 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"myapi/internal/decimal"
+)
+
+// getEnv - Lê uma variável de ambiente, usando o valor padrão quando vazia
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getEnvInt - Lê uma variável de ambiente inteira
+func getEnvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Valor inválido para %s (%q), usando %d", key, value, fallback)
+		return fallback
+	}
+	return n
+}
+
+// getEnvDuration - Lê uma variável de ambiente de duração (ex: "30s", "5m");
+// durações negativas são inválidas
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		log.Printf("Valor inválido para %s (%q), usando %s", key, value, fallback)
+		return fallback
+	}
+	return d
+}
+
+// getEnvDecimal - Lê uma variável de ambiente decimal ("1.65")
+func getEnvDecimal(key string, fallback string) decimal.Decimal {
+	value := os.Getenv(key)
+	if value == "" {
+		return decimal.MustParse(fallback)
+	}
+	d, err := decimal.Parse(value)
+	if err != nil {
+		log.Printf("Valor inválido para %s (%q), usando %s", key, value, fallback)
+		return decimal.MustParse(fallback)
+	}
+	return d
+}
@@ -0,0 +1,17 @@
+package config
+
+// EstoqueConfig - Parâmetros de estoque
+type EstoqueConfig struct {
+	// Código do depósito que recebe as alterações diretas de quantidade do item
+	DepositoPadrao string
+	// Método de custeio das saídas: "media" (custo médio ponderado) ou "fifo"
+	CustoMetodo string
+}
+
+// LoadEstoqueConfig - Carrega a configuração de estoque a partir das variáveis de ambiente
+func LoadEstoqueConfig() EstoqueConfig {
+	return EstoqueConfig{
+		DepositoPadrao: getEnv("DEPOSITO_PADRAO", "PRINCIPAL"),
+		CustoMetodo:    getEnv("CUSTO_METODO", "media"),
+	}
+}
@@ -0,0 +1,21 @@
+package config
+
+import "myapi/internal/decimal"
+
+// FiscalConfig - Parâmetros do cálculo de impostos
+type FiscalConfig struct {
+	// Arquivo "código;descrição" que substitui a tabela NCM embutida
+	NcmArquivo string
+	// Alíquotas de PIS e COFINS dos itens sem alíquota própria (regime não cumulativo)
+	AliquotaPis    decimal.Decimal
+	AliquotaCofins decimal.Decimal
+}
+
+// LoadFiscalConfig - Carrega a configuração fiscal a partir das variáveis de ambiente
+func LoadFiscalConfig() FiscalConfig {
+	return FiscalConfig{
+		NcmArquivo:     getEnv("FISCAL_NCM_ARQUIVO", ""),
+		AliquotaPis:    getEnvDecimal("FISCAL_ALIQUOTA_PIS", "1.65"),
+		AliquotaCofins: getEnvDecimal("FISCAL_ALIQUOTA_COFINS", "7.6"),
+	}
+}
@@ -0,0 +1,24 @@
+package config
+
+import "time"
+
+// IdempotencyConfig - Parâmetros do armazenamento de Idempotency-Key
+type IdempotencyConfig struct {
+	TTL           time.Duration
+	PurgeInterval time.Duration
+	// Validade da reserva enquanto a primeira execução não termina, para que
+	// uma instância que caiu no meio dela não bloqueie a chave por TTL
+	PendingTimeout time.Duration
+	// Espera máxima de uma repetição pela execução em andamento da mesma chave
+	WaitTimeout time.Duration
+}
+
+// LoadIdempotencyConfig - Carrega a configuração de idempotência a partir das variáveis de ambiente
+func LoadIdempotencyConfig() IdempotencyConfig {
+	return IdempotencyConfig{
+		TTL:            getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+		PurgeInterval:  getEnvDuration("IDEMPOTENCY_PURGE_INTERVAL", time.Hour),
+		PendingTimeout: getEnvDuration("IDEMPOTENCY_PENDING_TIMEOUT", 5*time.Minute),
+		WaitTimeout:    getEnvDuration("IDEMPOTENCY_WAIT_TIMEOUT", 30*time.Second),
+	}
+}
@@ -0,0 +1,16 @@
+package config
+
+import "time"
+
+// PrecoConfig - Parâmetros dos preços agendados
+type PrecoConfig struct {
+	// Intervalo da rotina que aplica os preços cuja vigência começou
+	AgendamentoInterval time.Duration
+}
+
+// LoadPrecoConfig - Carrega a configuração de preços a partir das variáveis de ambiente
+func LoadPrecoConfig() PrecoConfig {
+	return PrecoConfig{
+		AgendamentoInterval: getEnvDuration("PRECO_AGENDAMENTO_INTERVAL", time.Minute),
+	}
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimit - Limite de um token bucket: Capacity requisições de rajada,
+// reabastecidas a PerSecond tokens por segundo
+type RateLimit struct {
+	Capacity  int
+	PerSecond float64
+}
+
+// RateLimitConfig - Limites por grupo de rotas e cotas diárias por API key
+type RateLimitConfig struct {
+	Enabled bool
+	// "memory" (instância única) ou "postgres" (compartilhado entre réplicas)
+	Store string
+
+	Reads  RateLimit
+	Writes RateLimit
+	Bulk   RateLimit // importação/exportação
+
+	// Cota diária padrão por API key (0 = sem cota) e exceções por chave
+	DailyQuota     int64
+	QuotaOverrides map[string]int64
+}
+
+// LoadRateLimitConfig - Carrega a configuração de rate limiting a partir das variáveis de ambiente
+func LoadRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		Enabled: getEnv("RATE_LIMIT_ENABLED", "true") == "true",
+		Store:   getEnv("RATE_LIMIT_STORE", "memory"),
+
+		Reads:  parseRateLimit("RATE_LIMIT_READS", "300/m"),
+		Writes: parseRateLimit("RATE_LIMIT_WRITES", "60/m"),
+		Bulk:   parseRateLimit("RATE_LIMIT_BULK", "5/m"),
+
+		DailyQuota:     int64(getEnvInt("API_KEY_DAILY_QUOTA", 0)),
+		QuotaOverrides: parseQuotaOverrides(getEnv("API_KEY_QUOTA_OVERRIDES", "")),
+	}
+}
+
+// parseRateLimit - Lê um limite no formato "<quantidade>/<s|m|h>"
+func parseRateLimit(key, fallback string) RateLimit {
+	value := getEnv(key, fallback)
+	if limit, ok := rateLimitFromString(value); ok {
+		return limit
+	}
+	log.Printf("Valor inválido para %s (%q), usando %s", key, value, fallback)
+	limit, _ := rateLimitFromString(fallback)
+	return limit
+}
+
+func rateLimitFromString(value string) (RateLimit, bool) {
+	count, unit, _ := strings.Cut(value, "/")
+	n, err := strconv.Atoi(count)
+	if err != nil || n <= 0 {
+		return RateLimit{}, false
+	}
+	per := map[string]time.Duration{"s": time.Second, "m": time.Minute, "h": time.Hour}[unit]
+	if per == 0 {
+		return RateLimit{}, false
+	}
+	return RateLimit{Capacity: n, PerSecond: float64(n) / per.Seconds()}, true
+}
+
+// parseQuotaOverrides - Interpreta "chave1=5000,chave2=100"
+func parseQuotaOverrides(value string) map[string]int64 {
+	overrides := make(map[string]int64)
+	for _, pair := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			log.Printf("Cota inválida para a API key %q: %q", k, v)
+			continue
+		}
+		overrides[k] = n
+	}
+	return overrides
+}
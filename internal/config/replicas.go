@@ -0,0 +1,158 @@
+package config
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// replica - Conexão com uma réplica de leitura e seu estado de saúde
+type replica struct {
+	name    string
+	db      *gorm.DB
+	healthy atomic.Bool
+}
+
+var (
+	replicas      []*replica
+	replicaCursor atomic.Uint64
+)
+
+// ConnectReplicas - Abre as conexões com as réplicas listadas em DB_REPLICA_DSNS
+// (separadas por ";") e inicia a verificação periódica de saúde. Réplicas
+// indisponíveis são ejetadas do roteamento até voltarem a responder.
+func ConnectReplicas() {
+	cfg := LoadDatabaseConfig()
+	raw := getEnv("DB_REPLICA_DSNS", "")
+	if raw == "" {
+		return
+	}
+	interval := getEnvDuration("DB_REPLICA_HEALTH_INTERVAL", 5*time.Second)
+
+	for i, dsn := range strings.Split(raw, ";") {
+		dsn = strings.TrimSpace(dsn)
+		if dsn == "" {
+			continue
+		}
+		// Sem ping automático: a réplica começa ejetada e o health check a ativa
+		db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{DisableAutomaticPing: true})
+		if err != nil {
+			log.Printf("Erro ao configurar réplica %d: %v", i+1, err)
+			continue
+		}
+		sqlDB, err := db.DB()
+		if err != nil {
+			log.Printf("Erro ao configurar réplica %d: %v", i+1, err)
+			continue
+		}
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+		sqlDB.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+		rep := &replica{name: fmt.Sprintf("replica%d", i+1), db: db}
+		publishPoolStats("db_pool_"+rep.name, sqlDB)
+		replicas = append(replicas, rep)
+	}
+
+	checkReplicas()
+	expvar.Publish("db_replicas", expvar.Func(func() any {
+		status := make(map[string]bool, len(replicas))
+		for _, rep := range replicas {
+			status[rep.name] = rep.healthy.Load()
+		}
+		return status
+	}))
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			checkReplicas()
+		}
+	}()
+}
+
+// checkReplicas - Faz ping em cada réplica e atualiza seu estado de saúde
+func checkReplicas() {
+	for _, rep := range replicas {
+		sqlDB, err := rep.db.DB()
+		if err == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			err = sqlDB.PingContext(ctx)
+			cancel()
+		}
+		healthy := err == nil
+		if rep.healthy.Swap(healthy) != healthy {
+			if healthy {
+				log.Printf("Réplica %s disponível, voltando ao roteamento", rep.name)
+			} else {
+				log.Printf("Réplica %s ejetada: %v", rep.name, err)
+			}
+		}
+	}
+}
+
+// nextReplica - Escolhe, em round-robin, a próxima réplica saudável
+func nextReplica() *replica {
+	n := len(replicas)
+	if n == 0 {
+		return nil
+	}
+	start := replicaCursor.Add(1)
+	for i := 0; i < n; i++ {
+		rep := replicas[(start+uint64(i))%uint64(n)]
+		if rep.healthy.Load() {
+			return rep
+		}
+	}
+	return nil
+}
+
+type consistencyKey struct{}
+
+// requestConsistency - Estado de consistência de uma requisição
+type requestConsistency struct {
+	strong bool
+	wrote  atomic.Bool
+}
+
+// WithConsistency - Anexa ao contexto o controle de consistência da requisição.
+// Com strong=true todas as leituras vão para o primário.
+func WithConsistency(ctx context.Context, strong bool) context.Context {
+	return context.WithValue(ctx, consistencyKey{}, &requestConsistency{strong: strong})
+}
+
+// ReadsFromPrimary - Indica se as leituras da requisição devem ir para o primário
+func ReadsFromPrimary(ctx context.Context) bool {
+	rc, ok := ctx.Value(consistencyKey{}).(*requestConsistency)
+	return ok && (rc.strong || rc.wrote.Load())
+}
+
+// Reader - Conexão para leituras: usa uma réplica saudável, exceto quando a
+// requisição pediu consistência forte ou já escreveu no primário
+// (read-after-write)
+func Reader(ctx context.Context) *gorm.DB {
+	if ReadsFromPrimary(ctx) {
+		return DB.WithContext(ctx)
+	}
+	if rep := nextReplica(); rep != nil {
+		return rep.db.WithContext(ctx)
+	}
+	return DB.WithContext(ctx)
+}
+
+// Writer - Conexão para escritas (sempre o primário). Marca a requisição para
+// que as leituras seguintes também sejam feitas no primário.
+func Writer(ctx context.Context) *gorm.DB {
+	if rc, ok := ctx.Value(consistencyKey{}).(*requestConsistency); ok {
+		rc.wrote.Store(true)
+	}
+	return DB.WithContext(ctx)
+}
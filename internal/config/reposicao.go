@@ -0,0 +1,22 @@
+package config
+
+import "time"
+
+// ReposicaoConfig - Parâmetros das sugestões de compra e do alerta de estoque baixo
+type ReposicaoConfig struct {
+	// Janela, em dias, usada para o consumo médio diário
+	ConsumoDias int
+	// Intervalo da verificação de itens abaixo do ponto de pedido
+	Interval time.Duration
+	// URL que recebe (POST JSON) os eventos de estoque baixo; vazio desativa
+	WebhookURL string
+}
+
+// LoadReposicaoConfig - Carrega a configuração de reposição a partir das variáveis de ambiente
+func LoadReposicaoConfig() ReposicaoConfig {
+	return ReposicaoConfig{
+		ConsumoDias: getEnvInt("REPOSICAO_CONSUMO_DIAS", 30),
+		Interval:    getEnvDuration("REPOSICAO_INTERVAL", time.Hour),
+		WebhookURL:  getEnv("REPOSICAO_WEBHOOK_URL", ""),
+	}
+}
@@ -0,0 +1,19 @@
+package config
+
+import "time"
+
+// VendaConfig - Parâmetros dos pedidos de venda
+type VendaConfig struct {
+	// Prazo para confirmar um pedido antes de a reserva expirar
+	ReservaTTL time.Duration
+	// Intervalo da rotina que libera as reservas expiradas
+	ExpiracaoInterval time.Duration
+}
+
+// LoadVendaConfig - Carrega a configuração de vendas a partir das variáveis de ambiente
+func LoadVendaConfig() VendaConfig {
+	return VendaConfig{
+		ReservaTTL:        getEnvDuration("VENDA_RESERVA_TTL", 30*time.Minute),
+		ExpiracaoInterval: getEnvDuration("VENDA_EXPIRACAO_INTERVAL", time.Minute),
+	}
+}
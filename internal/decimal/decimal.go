@@ -0,0 +1,283 @@
+// Package decimal implementa números decimais de precisão exata para
+// valores monetários e custos, sem os erros de arredondamento de float64.
+package decimal
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Arredondamento - Regra usada ao reduzir a escala de um valor
+type Arredondamento int
+
+const (
+	// MeioParaCima - 0,125 → 0,13 (arredondamento comercial)
+	MeioParaCima Arredondamento = iota
+	// MeioParaPar - 0,125 → 0,12 e 0,135 → 0,14 (arredondamento bancário)
+	MeioParaPar
+	// Truncar - descarta as casas excedentes
+	Truncar
+)
+
+var ErrFormatoInvalido = errors.New("decimal em formato inválido")
+
+// Decimal - Valor decimal exato: valor × 10^-escala. O valor zero de
+// Decimal representa 0.
+type Decimal struct {
+	valor  *big.Int
+	escala int32
+}
+
+var dez = big.NewInt(10)
+
+// New - Cria o decimal valor × 10^-escala (New(1250, 2) = 12,50)
+func New(valor int64, escala int32) Decimal {
+	return Decimal{valor: big.NewInt(valor), escala: escala}
+}
+
+// NewFromInt - Cria um decimal inteiro
+func NewFromInt(valor int64) Decimal {
+	return New(valor, 0)
+}
+
+// NewFromFloat - Converte um float64 pela sua menor representação decimal
+// (0.1 vira exatamente 0,1). Usado apenas na leitura de dados legados.
+func NewFromFloat(f float64) Decimal {
+	d, _ := Parse(strconv.FormatFloat(f, 'f', -1, 64))
+	return d
+}
+
+// Parse - Lê um decimal no formato "-1234.5678"
+func Parse(s string) (Decimal, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Decimal{}, ErrFormatoInvalido
+	}
+	inteiro, fracao, _ := strings.Cut(s, ".")
+	if strings.ContainsAny(fracao, "+-") || (inteiro == "" || inteiro == "-" || inteiro == "+") && fracao == "" {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrFormatoInvalido, s)
+	}
+	valor, ok := new(big.Int).SetString(inteiro+fracao, 10)
+	if !ok {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrFormatoInvalido, s)
+	}
+	return Decimal{valor: valor, escala: int32(len(fracao))}, nil
+}
+
+// MustParse - Parse para constantes conhecidas; entra em pânico se inválido
+func MustParse(s string) Decimal {
+	d, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func (d Decimal) int() *big.Int {
+	if d.valor == nil {
+		return new(big.Int)
+	}
+	return d.valor
+}
+
+func potencia(n int32) *big.Int {
+	return new(big.Int).Exp(dez, big.NewInt(int64(n)), nil)
+}
+
+// reescalar - Mesmo valor com escala maior (sem perda)
+func (d Decimal) reescalar(escala int32) *big.Int {
+	if escala <= d.escala {
+		return d.int()
+	}
+	return new(big.Int).Mul(d.int(), potencia(escala-d.escala))
+}
+
+// Escala - Número de casas decimais
+func (d Decimal) Escala() int32 {
+	return d.escala
+}
+
+func (d Decimal) Add(o Decimal) Decimal {
+	escala := max(d.escala, o.escala)
+	return Decimal{valor: new(big.Int).Add(d.reescalar(escala), o.reescalar(escala)), escala: escala}
+}
+
+func (d Decimal) Sub(o Decimal) Decimal {
+	return d.Add(o.Neg())
+}
+
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{valor: new(big.Int).Mul(d.int(), o.int()), escala: d.escala + o.escala}
+}
+
+// MulInt - Multiplica por um inteiro (quantidade × custo unitário)
+func (d Decimal) MulInt(n int64) Decimal {
+	return d.Mul(NewFromInt(n))
+}
+
+// Div - Divide arredondando o resultado para a escala informada
+func (d Decimal) Div(o Decimal, escala int32, modo Arredondamento) Decimal {
+	if o.IsZero() {
+		panic("decimal: divisão por zero")
+	}
+	// d/o = (dv × 10^-ds) / (ov × 10^-os); o numerador é ajustado para que o
+	// quociente inteiro já tenha a escala pedida
+	num := new(big.Int).Set(d.int())
+	den := new(big.Int).Set(o.int())
+	if exp := escala + o.escala - d.escala; exp >= 0 {
+		num.Mul(num, potencia(exp))
+	} else {
+		den.Mul(den, potencia(-exp))
+	}
+	return Decimal{valor: dividir(num, den, modo), escala: escala}
+}
+
+// DivInt - Divide por um inteiro arredondando para a escala informada
+func (d Decimal) DivInt(n int64, escala int32, modo Arredondamento) Decimal {
+	return d.Div(NewFromInt(n), escala, modo)
+}
+
+// Round - Arredonda para a escala informada; escalas maiores apenas
+// acrescentam zeros
+func (d Decimal) Round(escala int32, modo Arredondamento) Decimal {
+	if escala >= d.escala {
+		return Decimal{valor: d.reescalar(escala), escala: escala}
+	}
+	return Decimal{valor: dividir(new(big.Int).Set(d.int()), potencia(d.escala-escala), modo), escala: escala}
+}
+
+// dividir - Quociente de num/den arredondado conforme o modo
+func dividir(num, den *big.Int, modo Arredondamento) *big.Int {
+	if den.Sign() < 0 {
+		num.Neg(num)
+		den = new(big.Int).Neg(den)
+	}
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() == 0 || modo == Truncar {
+		return q
+	}
+	// Compara o dobro do resto com o divisor para decidir o arredondamento
+	dobro := new(big.Int).Abs(r)
+	dobro.Lsh(dobro, 1)
+	cmp := dobro.Cmp(den)
+	if cmp > 0 || cmp == 0 && (modo == MeioParaCima || q.Bit(0) == 1) {
+		if num.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{valor: new(big.Int).Neg(d.int()), escala: d.escala}
+}
+
+func (d Decimal) Abs() Decimal {
+	return Decimal{valor: new(big.Int).Abs(d.int()), escala: d.escala}
+}
+
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Cmp - -1, 0 ou 1 conforme d seja menor, igual ou maior que o
+func (d Decimal) Cmp(o Decimal) int {
+	escala := max(d.escala, o.escala)
+	return d.reescalar(escala).Cmp(o.reescalar(escala))
+}
+
+// Equal - Igualdade numérica (1,5 = 1,50)
+func (d Decimal) Equal(o Decimal) bool {
+	return d.Cmp(o) == 0
+}
+
+// Float64 - Valor aproximado, para compatibilidade com clientes antigos
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// String - Representação com exatamente Escala casas decimais ("12.50")
+func (d Decimal) String() string {
+	s := new(big.Int).Abs(d.int()).String()
+	if d.escala > 0 {
+		if n := int(d.escala) + 1 - len(s); n > 0 {
+			s = strings.Repeat("0", n) + s
+		}
+		s = s[:len(s)-int(d.escala)] + "." + s[len(s)-int(d.escala):]
+	}
+	if d.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// Scan - Lê colunas numeric do Postgres
+func (d *Decimal) Scan(src any) error {
+	var err error
+	switch v := src.(type) {
+	case nil:
+		*d = Decimal{}
+	case string:
+		*d, err = Parse(v)
+	case []byte:
+		*d, err = Parse(string(v))
+	case int64:
+		*d = NewFromInt(v)
+	case float64:
+		*d = NewFromFloat(v)
+	default:
+		err = fmt.Errorf("decimal: tipo %T não suportado", src)
+	}
+	return err
+}
+
+// Value - Grava como texto para o Postgres converter em numeric sem perda
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// GormDataType - Tipo da coluna quando a tag não define um
+func (Decimal) GormDataType() string {
+	return "numeric"
+}
+
+// MarshalJSON - Emite o valor como número JSON com os dígitos exatos
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON - Aceita número (12.5) ou texto ("12.50")
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		s = unquoted
+	}
+	if strings.ContainsAny(s, "eE") {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("%w: %s", ErrFormatoInvalido, s)
+		}
+		*d = NewFromFloat(f)
+		return nil
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*d = parsed
+	return nil
+}
@@ -0,0 +1,73 @@
+package decimal
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRound(t *testing.T) {
+	casos := []struct {
+		valor  string
+		escala int32
+		modo   Arredondamento
+		quer   string
+	}{
+		{"0.125", 2, MeioParaCima, "0.13"},
+		{"0.135", 2, MeioParaCima, "0.14"},
+		{"0.124", 2, MeioParaCima, "0.12"},
+		{"-0.125", 2, MeioParaCima, "-0.13"},
+		{"0.125", 2, MeioParaPar, "0.12"},
+		{"0.135", 2, MeioParaPar, "0.14"},
+		{"0.1251", 2, MeioParaPar, "0.13"},
+		{"-0.125", 2, MeioParaPar, "-0.12"},
+		{"-0.135", 2, MeioParaPar, "-0.14"},
+		{"0.129", 2, Truncar, "0.12"},
+		{"-0.129", 2, Truncar, "-0.12"},
+		{"2.5", 0, MeioParaPar, "2"},
+		{"3.5", 0, MeioParaPar, "4"},
+		{"1.5", 3, MeioParaCima, "1.500"},
+	}
+	for _, c := range casos {
+		if got := MustParse(c.valor).Round(c.escala, c.modo).String(); got != c.quer {
+			t.Errorf("Round(%s, %d, %d) = %s, quer %s", c.valor, c.escala, c.modo, got, c.quer)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	validos := []struct {
+		entrada string
+		quer    string
+	}{
+		{"12.50", "12.50"},
+		{"-1234.5678", "-1234.5678"},
+		{"+1.5", "1.5"},
+		{" 7 ", "7"},
+		{".5", "0.5"},
+		{"-.05", "-0.05"},
+		{"5.", "5"},
+		{"0.000", "0.000"},
+	}
+	for _, c := range validos {
+		d, err := Parse(c.entrada)
+		if err != nil {
+			t.Errorf("Parse(%q): erro %v", c.entrada, err)
+			continue
+		}
+		if got := d.String(); got != c.quer {
+			t.Errorf("Parse(%q) = %s, quer %s", c.entrada, got, c.quer)
+		}
+	}
+
+	for _, entrada := range []string{"", " ", "-", "+", ".", "abc", "1.2.3", "1.-5", "1e3", "1,5", "--1"} {
+		if _, err := Parse(entrada); !errors.Is(err, ErrFormatoInvalido) {
+			t.Errorf("Parse(%q): erro %v, quer ErrFormatoInvalido", entrada, err)
+		}
+	}
+}
+
+func TestSomaExata(t *testing.T) {
+	if got := MustParse("0.1").Add(MustParse("0.2")); !got.Equal(MustParse("0.3")) {
+		t.Errorf("0.1 + 0.2 = %s, quer 0.3", got)
+	}
+}
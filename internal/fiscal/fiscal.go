@@ -0,0 +1,152 @@
+// Package fiscal reúne as tabelas fiscais brasileiras usadas na validação
+// dos itens e no cálculo de impostos: NCM, UFs e alíquotas de ICMS padrão.
+package fiscal
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+
+	"myapi/internal/decimal"
+)
+
+// Tabela NCM da TIPI, atualizada com go generate (ver gerar_ncm.go)
+//
+//go:generate go run gerar_ncm.go
+//go:embed ncm.txt
+var ncmEmbutido string
+
+var ncms atomic.Pointer[map[string]string]
+
+func init() {
+	if err := CarregarNcm(strings.NewReader(ncmEmbutido)); err != nil {
+		panic(err)
+	}
+}
+
+// CarregarNcm - Substitui a tabela NCM pelas linhas "código;descrição" do
+// arquivo; linhas vazias ou iniciadas por # são ignoradas
+func CarregarNcm(r io.Reader) error {
+	tabela := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		linha := strings.TrimSpace(scanner.Text())
+		if linha == "" || strings.HasPrefix(linha, "#") {
+			continue
+		}
+		codigo, descricao, _ := strings.Cut(linha, ";")
+		codigo = Normalizar(codigo)
+		if !digitos(codigo, 8) {
+			return fmt.Errorf("fiscal: NCM inválido na linha %d: %q", n, linha)
+		}
+		tabela[codigo] = strings.TrimSpace(descricao)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	ncms.Store(&tabela)
+	return nil
+}
+
+// Normalizar - Remove a pontuação de códigos como NCM e CEST ("8471.60.52" → "84716052")
+func Normalizar(codigo string) string {
+	return strings.NewReplacer(".", "", "-", "", " ", "").Replace(codigo)
+}
+
+// DescricaoNcm - Descrição do NCM na tabela carregada
+func DescricaoNcm(ncm string) (string, bool) {
+	descricao, ok := (*ncms.Load())[Normalizar(ncm)]
+	return descricao, ok
+}
+
+// NcmValido - NCM com 8 dígitos e presente na tabela
+func NcmValido(ncm string) bool {
+	_, ok := DescricaoNcm(ncm)
+	return ok
+}
+
+// CestValido - CEST com 7 dígitos
+func CestValido(cest string) bool {
+	return digitos(Normalizar(cest), 7)
+}
+
+// CfopValido - CFOP de saída com 4 dígitos começando pelo prefixo informado
+// ("5" dentro da UF, "6" para outra UF)
+func CfopValido(cfop, prefixo string) bool {
+	return digitos(cfop, 4) && strings.HasPrefix(cfop, prefixo)
+}
+
+// OrigemValida - Origem da mercadoria de 0 a 8 (tabela A do CST)
+func OrigemValida(origem int) bool {
+	return origem >= 0 && origem <= 8
+}
+
+// Importado - Origens com conteúdo de importação sujeitas à alíquota
+// interestadual de 4% (Resolução do Senado 13/2012)
+func Importado(origem int) bool {
+	switch origem {
+	case 1, 2, 3, 8:
+		return true
+	}
+	return false
+}
+
+func digitos(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// aliquotasInternas - Alíquota modal de ICMS nas operações internas de cada
+// UF (incluindo adicionais de fundo de pobreza incorporados à alíquota).
+// Valores de referência; as UFs que mudarem podem ser ajustadas pela tabela
+// de alíquotas de ICMS da API.
+var aliquotasInternas = map[string]string{
+	"AC": "19", "AL": "19", "AM": "20", "AP": "18", "BA": "20.5", "CE": "20", "DF": "20",
+	"ES": "17", "GO": "19", "MA": "23", "MG": "18", "MS": "17", "MT": "17", "PA": "19",
+	"PB": "20", "PE": "20.5", "PI": "22.5", "PR": "19.5", "RJ": "22", "RN": "18", "RO": "19.5",
+	"RR": "20", "RS": "17", "SC": "17", "SE": "20", "SP": "18", "TO": "20",
+}
+
+// UFValida - Sigla de uma unidade federativa
+func UFValida(uf string) bool {
+	_, ok := aliquotasInternas[uf]
+	return ok
+}
+
+// AliquotaInterna - Alíquota padrão de ICMS dentro da UF
+func AliquotaInterna(uf string) decimal.Decimal {
+	return decimal.MustParse(aliquotasInternas[uf])
+}
+
+// AliquotaInterestadual - Alíquota de ICMS entre UFs: 4% para importados,
+// 7% das regiões Sul e Sudeste (exceto ES) para as demais regiões e o ES,
+// 12% nos outros casos
+func AliquotaInterestadual(origem, destino string, origemMercadoria int) decimal.Decimal {
+	switch {
+	case Importado(origemMercadoria):
+		return decimal.NewFromInt(4)
+	case sulSudeste(origem) && !sulSudeste(destino):
+		return decimal.NewFromInt(7)
+	}
+	return decimal.NewFromInt(12)
+}
+
+// sulSudeste - UFs das regiões Sul e Sudeste, sem o Espírito Santo, que
+// para o ICMS interestadual é tratado com o Norte, Nordeste e Centro-Oeste
+func sulSudeste(uf string) bool {
+	switch uf {
+	case "MG", "PR", "RJ", "RS", "SC", "SP":
+		return true
+	}
+	return false
+}
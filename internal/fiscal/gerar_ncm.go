@@ -0,0 +1,80 @@
+//go:build ignore
+
+// Gera ncm.txt com todos os NCMs (códigos de 8 dígitos) da nomenclatura
+// publicada pelo Portal Único Siscomex. Uso: go generate ./internal/fiscal
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const fonte = "https://portalunico.siscomex.gov.br/classif/api/publico/nomenclatura/download/json"
+
+type nomenclatura struct {
+	Atualizacao   string `json:"Data_Ultima_Atualizacao_NCM"`
+	Ato           string `json:"Ato"`
+	Nomenclaturas []struct {
+		Codigo    string `json:"Codigo"`
+		Descricao string `json:"Descricao"`
+	} `json:"Nomenclaturas"`
+}
+
+func main() {
+	cliente := &http.Client{Timeout: 2 * time.Minute}
+	resp, err := cliente.Get(fonte)
+	if err != nil {
+		log.Fatalf("Erro ao baixar a nomenclatura: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		log.Fatalf("Erro ao baixar a nomenclatura: status %d", resp.StatusCode)
+	}
+	var dados nomenclatura
+	if err := json.NewDecoder(resp.Body).Decode(&dados); err != nil {
+		log.Fatalf("Erro ao ler a nomenclatura: %v", err)
+	}
+
+	ncms := map[string]string{}
+	for _, n := range dados.Nomenclaturas {
+		codigo := strings.NewReplacer(".", "", "-", "", " ", "").Replace(n.Codigo)
+		if len(codigo) != 8 {
+			continue
+		}
+		descricao := strings.TrimLeft(strings.TrimSpace(n.Descricao), "- ")
+		ncms[codigo] = strings.NewReplacer(";", ",", "\n", " ", "\r", "").Replace(descricao)
+	}
+	if len(ncms) == 0 {
+		log.Fatal("Nomenclatura sem NCMs de 8 dígitos")
+	}
+	codigos := make([]string, 0, len(ncms))
+	for codigo := range ncms {
+		codigos = append(codigos, codigo)
+	}
+	sort.Strings(codigos)
+
+	f, err := os.Create("ncm.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+	w := bufio.NewWriter(f)
+	fmt.Fprintf(w, "# Tabela NCM completa (TIPI), gerada por gerar_ncm.go a partir do Portal Único Siscomex.\n")
+	fmt.Fprintf(w, "# Atualização: %s (%s). Formato: código de 8 dígitos;descrição.\n", dados.Atualizacao, dados.Ato)
+	for _, codigo := range codigos {
+		fmt.Fprintf(w, "%s;%s\n", codigo, ncms[codigo])
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("%d NCMs gravados em ncm.txt", len(codigos))
+}
@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"net/http"
+
+	"myapi/internal/cache"
+	"myapi/internal/config"
+)
+
+// FlushCache - Esvazia o cache de todas as instâncias
+func FlushCache(w http.ResponseWriter, r *http.Request) {
+	if err := cache.FlushAll(config.Writer(r.Context())); err != nil {
+		http.Error(w, "Erro ao esvaziar o cache", http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte("Cache esvaziado com sucesso"))
+}
@@ -2,11 +2,15 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"myapi/internal/models"
 	"myapi/internal/repositories"
 	"net/http"
 	"strconv"
+
+	"gorm.io/gorm"
 )
 
 func ScalarHandler(w http.ResponseWriter, r *http.Request) {
@@ -32,7 +36,7 @@ func ScalarHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func ListCategoriasHandler(w http.ResponseWriter, r *http.Request) {
-	repository := repositories.NewCategoriaRepository()
+	repository := repositories.NewCategoriaRepository(r.Context())
 	categorias, err := repository.ListAll()
 	if err != nil {
 		http.Error(w, "Erro ao buscar categorias", http.StatusInternalServerError)
@@ -53,7 +57,7 @@ func GetCategoriaHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	repository := repositories.NewCategoriaRepository()
+	repository := repositories.NewCategoriaRepository(r.Context())
 	categoria, err := repository.GetByID(id)
 	if err != nil {
 		http.Error(w, "Categoria não encontrada", http.StatusNotFound)
@@ -69,25 +73,35 @@ func CreateCategoriaHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	repository := repositories.NewCategoriaRepository()
+	repository := repositories.NewCategoriaRepository(r.Context())
 	createdCategoria, err := repository.Create(&categoria)
 	if err != nil {
-		http.Error(w, "Erro ao criar a categoria", http.StatusInternalServerError)
+		categoriaError(w, err, "Erro ao criar a categoria")
 		return
 	}
 	json.NewEncoder(w).Encode(createdCategoria)
 }
 
+// UpdateCategoriaHandler - Atualiza a categoria; só move quando o corpo traz
+// parent_id (nulo leva para a raiz)
 func UpdateCategoriaHandler(w http.ResponseWriter, r *http.Request) {
+	corpo, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Erro ao ler a categoria", http.StatusBadRequest)
+		return
+	}
 	var categoria models.Categoria
-	if err := json.NewDecoder(r.Body).Decode(&categoria); err != nil {
+	var campos map[string]json.RawMessage
+	if err := json.Unmarshal(corpo, &categoria); err != nil {
 		http.Error(w, "Erro ao decodificar a categoria", http.StatusBadRequest)
 		return
 	}
+	json.Unmarshal(corpo, &campos)
+	_, moverPai := campos["parent_id"]
 
-	repository := repositories.NewCategoriaRepository()
-	if err := repository.Update(&categoria); err != nil {
-		http.Error(w, "Erro ao atualizar the categoria", http.StatusInternalServerError)
+	repository := repositories.NewCategoriaRepository(r.Context())
+	if err := repository.Update(&categoria, moverPai); err != nil {
+		categoriaError(w, err, "Erro ao atualizar the categoria")
 		return
 	}
 	json.NewEncoder(w).Encode(categoria)
@@ -105,10 +119,114 @@ func DeleteCategoriaHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	repository := repositories.NewCategoriaRepository()
+	repository := repositories.NewCategoriaRepository(r.Context())
 	if err := repository.Delete(id); err != nil {
-		http.Error(w, "Erro ao deletar a categoria", http.StatusInternalServerError)
+		categoriaError(w, err, "Erro ao deletar a categoria")
 		return
 	}
 	w.Write([]byte("Categoria deletada com sucesso"))
 }
+
+func ArvoreCategoriasHandler(w http.ResponseWriter, r *http.Request) {
+	var raiz *uint
+	if idStr := r.URL.Query().Get("id"); idStr != "" {
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			http.Error(w, "ID inválido", http.StatusBadRequest)
+			return
+		}
+		raizID := uint(id)
+		raiz = &raizID
+	}
+
+	repository := repositories.NewCategoriaRepository(r.Context())
+	arvore, err := repository.Arvore(raiz)
+	if err != nil {
+		categoriaError(w, err, "Erro ao montar a árvore de categorias")
+		return
+	}
+	json.NewEncoder(w).Encode(arvore)
+}
+
+func CaminhoCategoriaHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewCategoriaRepository(r.Context())
+	caminho, err := repository.Caminho(id)
+	if err != nil {
+		categoriaError(w, err, "Erro ao buscar o caminho da categoria")
+		return
+	}
+	json.NewEncoder(w).Encode(caminho)
+}
+
+func DescendentesCategoriaHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewCategoriaRepository(r.Context())
+	descendentes, err := repository.Descendentes(id)
+	if err != nil {
+		categoriaError(w, err, "Erro ao buscar as subcategorias")
+		return
+	}
+	json.NewEncoder(w).Encode(descendentes)
+}
+
+func AtributosCategoriaHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.URL.Query().Get("id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewCategoriaRepository(r.Context())
+	esquema, err := repository.Esquema(id)
+	if err != nil {
+		categoriaError(w, err, "Erro ao buscar os atributos da categoria")
+		return
+	}
+	json.NewEncoder(w).Encode(esquema)
+}
+
+type moverCategoriaRequest struct {
+	Id       int   `json:"id"`
+	ParentId *uint `json:"parent_id"`
+}
+
+func MoverCategoriaHandler(w http.ResponseWriter, r *http.Request) {
+	var req moverCategoriaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Erro ao decodificar a categoria", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewCategoriaRepository(r.Context())
+	categoria, err := repository.Mover(req.Id, req.ParentId)
+	if err != nil {
+		categoriaError(w, err, "Erro ao mover a categoria")
+		return
+	}
+	json.NewEncoder(w).Encode(categoria)
+}
+
+// categoriaError - Traduz os erros da árvore de categorias para o status HTTP adequado
+func categoriaError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrCategoriaCiclo), errors.Is(err, repositories.ErrEsquemaAtributos):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, repositories.ErrCategoriaComFilhas), errors.Is(err, repositories.ErrAtributosItens):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "Categoria não encontrada", http.StatusNotFound)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"myapi/internal/models"
+	"myapi/internal/repositories"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// ListClientes - Lista todos os clientes
+func ListClientes(w http.ResponseWriter, r *http.Request) {
+	repository := repositories.NewClienteRepository(r.Context())
+	clientes, err := repository.ListAll()
+	if err != nil {
+		http.Error(w, "Erro ao listar os clientes", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(clientes)
+}
+
+// GetCliente - Busca um cliente por ID
+func GetCliente(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewClienteRepository(r.Context())
+	cliente, err := repository.GetByID(id)
+	if err != nil {
+		http.Error(w, "Cliente não encontrado", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(cliente)
+}
+
+// CreateCliente - Cria um novo cliente
+func CreateCliente(w http.ResponseWriter, r *http.Request) {
+	var cliente models.Cliente
+	if err := json.NewDecoder(r.Body).Decode(&cliente); err != nil {
+		http.Error(w, "Erro ao decodificar o cliente", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewClienteRepository(r.Context())
+	createdCliente, err := repository.Create(&cliente)
+	if err != nil {
+		http.Error(w, "Erro ao criar o cliente", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(createdCliente)
+}
+
+// UpdateCliente - Atualiza um cliente existente
+func UpdateCliente(w http.ResponseWriter, r *http.Request) {
+	var cliente models.Cliente
+	if err := json.NewDecoder(r.Body).Decode(&cliente); err != nil {
+		http.Error(w, "Erro ao decodificar o cliente", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewClienteRepository(r.Context())
+	if err := repository.Update(&cliente); err != nil {
+		http.Error(w, "Erro ao atualizar o cliente", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(cliente)
+}
+
+// DeleteCliente - Deleta um cliente por ID
+func DeleteCliente(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewClienteRepository(r.Context())
+	if err := repository.Delete(id); err != nil {
+		http.Error(w, "Erro ao deletar o cliente", http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte("Cliente deletado com sucesso"))
+}
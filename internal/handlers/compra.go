@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"myapi/internal/models"
+	"myapi/internal/repositories"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// ListCompras - Lista os pedidos de compra, opcionalmente por ?status=
+func ListCompras(w http.ResponseWriter, r *http.Request) {
+	repository := repositories.NewCompraRepository(r.Context())
+	pedidos, err := repository.ListAll(r.URL.Query().Get("status"))
+	if err != nil {
+		http.Error(w, "Erro ao listar os pedidos de compra", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(pedidos)
+}
+
+// GetCompra - Busca um pedido de compra por ID
+func GetCompra(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewCompraRepository(r.Context())
+	pedido, err := repository.GetByID(id)
+	if err != nil {
+		http.Error(w, "Pedido de compra não encontrado", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(pedido)
+}
+
+// CreateCompra - Cria um pedido de compra em rascunho
+func CreateCompra(w http.ResponseWriter, r *http.Request) {
+	var pedido models.PedidoCompra
+	if err := json.NewDecoder(r.Body).Decode(&pedido); err != nil {
+		http.Error(w, "Erro ao decodificar o pedido de compra", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewCompraRepository(r.Context())
+	createdPedido, err := repository.Create(&pedido)
+	if err != nil {
+		compraError(w, err, "Erro ao criar o pedido de compra")
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(createdPedido)
+}
+
+// UpdateCompra - Atualiza um pedido de compra em rascunho
+func UpdateCompra(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	var pedido models.PedidoCompra
+	if err := json.NewDecoder(r.Body).Decode(&pedido); err != nil {
+		http.Error(w, "Erro ao decodificar o pedido de compra", http.StatusBadRequest)
+		return
+	}
+	pedido.Id = uint(id)
+
+	repository := repositories.NewCompraRepository(r.Context())
+	if err := repository.Update(&pedido); err != nil {
+		compraError(w, err, "Erro ao atualizar o pedido de compra")
+		return
+	}
+	json.NewEncoder(w).Encode(pedido)
+}
+
+// EnviarCompra - Marca o pedido como enviado ao fornecedor
+func EnviarCompra(w http.ResponseWriter, r *http.Request) {
+	mudarStatusCompra(w, r, models.CompraEnviado)
+}
+
+// CancelarCompra - Cancela o pedido (o que já foi recebido permanece no estoque)
+func CancelarCompra(w http.ResponseWriter, r *http.Request) {
+	mudarStatusCompra(w, r, models.CompraCancelado)
+}
+
+func mudarStatusCompra(w http.ResponseWriter, r *http.Request, status string) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewCompraRepository(r.Context())
+	pedido, err := repository.MudarStatus(id, status)
+	if err != nil {
+		compraError(w, err, "Erro ao alterar o status do pedido de compra")
+		return
+	}
+	json.NewEncoder(w).Encode(pedido)
+}
+
+type recebimentoRequest struct {
+	Linhas []repositories.LinhaRecebimento `json:"linhas"`
+}
+
+// CreateRecebimento - Recebe (total ou parcialmente) linhas do pedido,
+// lançando as entradas de estoque com o custo unitário efetivo
+func CreateRecebimento(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	var req recebimentoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Erro ao decodificar o recebimento", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewCompraRepository(r.Context())
+	recebimentos, err := repository.Receber(id, req.Linhas)
+	if err != nil {
+		compraError(w, err, "Erro ao registrar o recebimento")
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(recebimentos)
+}
+
+// ListRecebimentos - Histórico de recebimentos de um pedido de compra
+func ListRecebimentos(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewCompraRepository(r.Context())
+	recebimentos, err := repository.Recebimentos(id)
+	if err != nil {
+		http.Error(w, "Erro ao listar os recebimentos", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(recebimentos)
+}
+
+// compraError - Traduz os erros de compra para o status HTTP adequado
+func compraError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrTransicaoInvalida), errors.Is(err, repositories.ErrPedidoNaoEditavel):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, repositories.ErrRecebimentoExcede), errors.Is(err, repositories.ErrLinhaNaoEncontrada),
+		errors.Is(err, repositories.ErrRecebimentoCusto):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "Pedido, item ou depósito não encontrado", http.StatusNotFound)
+	default:
+		estoqueError(w, err, message)
+	}
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"myapi/internal/decimal"
+	"myapi/internal/models"
+	"myapi/internal/repositories"
+	"myapi/internal/services"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// tamanhoMaximoCSV - Limite do arquivo de cotações
+const tamanhoMaximoCSV = 5 << 20
+
+// ListCotacoes - Cotações cadastradas, opcionalmente de ?moeda=
+func ListCotacoes(w http.ResponseWriter, r *http.Request) {
+	repository := repositories.NewCotacaoRepository(r.Context())
+	cotacoes, err := repository.List(strings.ToUpper(r.URL.Query().Get("moeda")))
+	if err != nil {
+		http.Error(w, "Erro ao listar as cotações", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(cotacoes)
+}
+
+type cotacaoRequest struct {
+	Moeda string          `json:"moeda"`
+	Data  string          `json:"data"`
+	Taxa  decimal.Decimal `json:"taxa"`
+}
+
+// SaveCotacao - Registra a taxa de uma moeda em uma data (AAAA-MM-DD),
+// substituindo a existente
+func SaveCotacao(w http.ResponseWriter, r *http.Request) {
+	var req cotacaoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Erro ao decodificar a cotação", http.StatusBadRequest)
+		return
+	}
+	data, err := time.Parse(time.DateOnly, req.Data)
+	if err != nil {
+		http.Error(w, "Data inválida, use AAAA-MM-DD", http.StatusBadRequest)
+		return
+	}
+	cotacao := models.Cotacao{Moeda: strings.ToUpper(req.Moeda), Data: data, Taxa: req.Taxa}
+
+	repository := repositories.NewCotacaoRepository(r.Context())
+	if err := repository.Salvar([]models.Cotacao{cotacao}); err != nil {
+		cotacaoError(w, err, "Erro ao salvar a cotação")
+		return
+	}
+	json.NewEncoder(w).Encode(cotacao)
+}
+
+// ImportarCotacoes - Carrega cotações de um CSV (moeda,data,taxa) enviado no corpo
+func ImportarCotacoes(w http.ResponseWriter, r *http.Request) {
+	n, err := services.ImportarCotacoes(r.Context(), http.MaxBytesReader(w, r.Body, tamanhoMaximoCSV))
+	if err != nil {
+		var maxBytes *http.MaxBytesError
+		if errors.As(err, &maxBytes) {
+			http.Error(w, "Arquivo de cotações muito grande", http.StatusRequestEntityTooLarge)
+			return
+		}
+		cotacaoError(w, err, "Erro ao importar as cotações")
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]int{"importadas": n})
+}
+
+// DeleteCotacao - Deleta uma cotação por ID
+func DeleteCotacao(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewCotacaoRepository(r.Context())
+	if err := repository.Delete(id); err != nil {
+		http.Error(w, "Erro ao deletar a cotação", http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte("Cotação deletada com sucesso"))
+}
+
+// cotacaoError - Traduz os erros de cotação e conversão para o status HTTP adequado
+func cotacaoError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrCotacaoInvalida), errors.Is(err, repositories.ErrMoedaInvalida):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repositories.ErrSemCotacao):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"myapi/internal/models"
+	"myapi/internal/repositories"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// ListDepositos - Lista todos os depósitos
+func ListDepositos(w http.ResponseWriter, r *http.Request) {
+	repository := repositories.NewDepositoRepository(r.Context())
+	depositos, err := repository.ListAll()
+	if err != nil {
+		http.Error(w, "Erro ao listar os depósitos", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(depositos)
+}
+
+// GetDeposito - Busca um depósito por ID
+func GetDeposito(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewDepositoRepository(r.Context())
+	deposito, err := repository.GetByID(id)
+	if err != nil {
+		http.Error(w, "Depósito não encontrado", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(deposito)
+}
+
+// CreateDeposito - Cria um novo depósito
+func CreateDeposito(w http.ResponseWriter, r *http.Request) {
+	var deposito models.Deposito
+	if err := json.NewDecoder(r.Body).Decode(&deposito); err != nil {
+		http.Error(w, "Erro ao decodificar o depósito", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewDepositoRepository(r.Context())
+	createdDeposito, err := repository.Create(&deposito)
+	if err != nil {
+		http.Error(w, "Erro ao criar o depósito", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(createdDeposito)
+}
+
+// UpdateDeposito - Atualiza um depósito existente
+func UpdateDeposito(w http.ResponseWriter, r *http.Request) {
+	var deposito models.Deposito
+	if err := json.NewDecoder(r.Body).Decode(&deposito); err != nil {
+		http.Error(w, "Erro ao decodificar o depósito", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewDepositoRepository(r.Context())
+	if err := repository.Update(&deposito); err != nil {
+		http.Error(w, "Erro ao atualizar o depósito", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(deposito)
+}
+
+// DeleteDeposito - Deleta um depósito por ID
+func DeleteDeposito(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewDepositoRepository(r.Context())
+	if err := repository.Delete(id); err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrDepositoComSaldo):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			http.Error(w, "Depósito não encontrado", http.StatusNotFound)
+		default:
+			http.Error(w, "Erro ao deletar o depósito", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Write([]byte("Depósito deletado com sucesso"))
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"myapi/internal/models"
+	"myapi/internal/money"
+	"myapi/internal/repositories"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// GetEstoqueItem - Saldos de um item por depósito
+func GetEstoqueItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewEstoqueRepository(r.Context())
+	saldos, err := repository.ListByItem(id)
+	if err != nil {
+		http.Error(w, "Erro ao buscar o estoque do item", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(saldos)
+}
+
+type movimentacaoRequest struct {
+	ItemId        uint         `json:"item_id"`
+	DepositoId    uint         `json:"deposito_id"`
+	LocalizacaoId *uint        `json:"localizacao_id"`
+	LoteId        *uint        `json:"lote_id"`
+	Tipo          string       `json:"tipo"`
+	Quantidade    int          `json:"quantidade"`
+	CustoUnitario *money.Money `json:"custo_unitario"`
+	Referencia    string       `json:"referencia"`
+	Series        []string     `json:"series"`
+}
+
+// CreateMovimentacao - Registra uma entrada ou saída de estoque em um depósito
+func CreateMovimentacao(w http.ResponseWriter, r *http.Request) {
+	var req movimentacaoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Erro ao decodificar a movimentação", http.StatusBadRequest)
+		return
+	}
+	if req.Quantidade <= 0 {
+		http.Error(w, repositories.ErrQuantidadeInvalida.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.CustoUnitario != nil && req.CustoUnitario.Sign() < 0 {
+		http.Error(w, "Custo unitário não pode ser negativo", http.StatusBadRequest)
+		return
+	}
+
+	mov := models.Movimentacao{
+		ItemId:        req.ItemId,
+		DepositoId:    req.DepositoId,
+		LocalizacaoId: req.LocalizacaoId,
+		LoteId:        req.LoteId,
+		Tipo:          req.Tipo,
+		Quantidade:    req.Quantidade,
+		CustoUnitario: req.CustoUnitario,
+		Referencia:    req.Referencia,
+		Series:        req.Series,
+	}
+	switch req.Tipo {
+	case models.MovimentacaoEntrada:
+	case models.MovimentacaoSaida:
+		mov.Quantidade = -req.Quantidade
+	default:
+		http.Error(w, "Tipo deve ser \"entrada\" ou \"saida\"", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewEstoqueRepository(r.Context())
+	if err := repository.Movimentar(&mov); err != nil {
+		estoqueError(w, err, "Erro ao registrar a movimentação")
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(mov)
+}
+
+type transferenciaRequest struct {
+	ItemId     uint     `json:"item_id"`
+	OrigemId   uint     `json:"origem_id"`
+	DestinoId  uint     `json:"destino_id"`
+	Quantidade int      `json:"quantidade"`
+	Series     []string `json:"series"`
+}
+
+// CreateTransferencia - Move estoque de um depósito para outro
+func CreateTransferencia(w http.ResponseWriter, r *http.Request) {
+	var req transferenciaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Erro ao decodificar a transferência", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewEstoqueRepository(r.Context())
+	movs, err := repository.Transferir(req.ItemId, req.OrigemId, req.DestinoId, req.Quantidade, req.Series)
+	if err != nil {
+		estoqueError(w, err, "Erro ao transferir o estoque")
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(movs)
+}
+
+// estoqueError - Traduz os erros de estoque para o status HTTP adequado
+func estoqueError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrQuantidadeInvalida), errors.Is(err, repositories.ErrMesmoDeposito):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case localizacaoError(err), errors.Is(err, repositories.ErrLoteInvalido), errors.Is(err, repositories.ErrItemSemLote),
+		errors.Is(err, repositories.ErrLoteEntrada),
+		serieError(err), errors.Is(err, repositories.ErrItemKit), errors.Is(err, repositories.ErrItemProduto):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, repositories.ErrEstoqueInsuficiente), errors.Is(err, repositories.ErrLoteVencido),
+		errors.Is(err, repositories.ErrSerieEmEstoque), errors.Is(err, repositories.ErrSerieIndisponivel):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "Item ou depósito não encontrado", http.StatusNotFound)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
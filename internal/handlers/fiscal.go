@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"myapi/internal/fiscal"
+	"myapi/internal/models"
+	"myapi/internal/repositories"
+	"myapi/internal/services"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// CalcularImpostos - Tributos de uma linha de venda entre duas UFs
+func CalcularImpostos(w http.ResponseWriter, r *http.Request) {
+	var linha services.LinhaFiscal
+	if err := json.NewDecoder(r.Body).Decode(&linha); err != nil {
+		http.Error(w, "Erro ao decodificar a linha", http.StatusBadRequest)
+		return
+	}
+
+	calculo, err := services.CalcularImpostos(r.Context(), linha)
+	switch {
+	case errors.Is(err, repositories.ErrUFInvalida), errors.Is(err, repositories.ErrQuantidadeInvalida):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "Item não encontrado", http.StatusNotFound)
+		return
+	case err != nil:
+		http.Error(w, "Erro ao calcular os impostos", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(calculo)
+}
+
+// GetNcm - Descrição de um NCM na tabela carregada
+func GetNcm(w http.ResponseWriter, r *http.Request) {
+	ncm := fiscal.Normalizar(mux.Vars(r)["ncm"])
+	descricao, ok := fiscal.DescricaoNcm(ncm)
+	if !ok {
+		http.Error(w, "NCM não encontrado", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"ncm": ncm, "descricao": descricao})
+}
+
+// ListAliquotasIcms - Alíquotas de ICMS cadastradas entre UFs
+func ListAliquotasIcms(w http.ResponseWriter, r *http.Request) {
+	repository := repositories.NewAliquotaIcmsRepository(r.Context())
+	aliquotas, err := repository.ListAll()
+	if err != nil {
+		http.Error(w, "Erro ao listar as alíquotas de ICMS", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(aliquotas)
+}
+
+// SaveAliquotaIcms - Define a alíquota de ICMS entre duas UFs (iguais = alíquota interna)
+func SaveAliquotaIcms(w http.ResponseWriter, r *http.Request) {
+	var aliquota models.AliquotaIcms
+	if err := json.NewDecoder(r.Body).Decode(&aliquota); err != nil {
+		http.Error(w, "Erro ao decodificar a alíquota", http.StatusBadRequest)
+		return
+	}
+	aliquota.UfOrigem = strings.ToUpper(aliquota.UfOrigem)
+	aliquota.UfDestino = strings.ToUpper(aliquota.UfDestino)
+
+	repository := repositories.NewAliquotaIcmsRepository(r.Context())
+	if err := repository.Save(&aliquota); err != nil {
+		if errors.Is(err, repositories.ErrUFInvalida) || errors.Is(err, repositories.ErrDadosFiscais) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Erro ao salvar a alíquota de ICMS", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(aliquota)
+}
+
+// DeleteAliquotaIcms - Volta um par de UFs para a alíquota padrão
+func DeleteAliquotaIcms(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	repository := repositories.NewAliquotaIcmsRepository(r.Context())
+	if err := repository.Delete(strings.ToUpper(vars["origem"]), strings.ToUpper(vars["destino"])); err != nil {
+		http.Error(w, "Erro ao remover a alíquota de ICMS", http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte("Alíquota de ICMS removida com sucesso"))
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"myapi/internal/models"
+	"myapi/internal/repositories"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// ListFornecedores - Lista todos os fornecedores
+func ListFornecedores(w http.ResponseWriter, r *http.Request) {
+	repository := repositories.NewFornecedorRepository(r.Context())
+	fornecedores, err := repository.ListAll()
+	if err != nil {
+		http.Error(w, "Erro ao listar os fornecedores", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(fornecedores)
+}
+
+// GetFornecedor - Busca um fornecedor por ID
+func GetFornecedor(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewFornecedorRepository(r.Context())
+	fornecedor, err := repository.GetByID(id)
+	if err != nil {
+		http.Error(w, "Fornecedor não encontrado", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(fornecedor)
+}
+
+// CreateFornecedor - Cria um novo fornecedor
+func CreateFornecedor(w http.ResponseWriter, r *http.Request) {
+	var fornecedor models.Fornecedor
+	if err := json.NewDecoder(r.Body).Decode(&fornecedor); err != nil {
+		http.Error(w, "Erro ao decodificar o fornecedor", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewFornecedorRepository(r.Context())
+	createdFornecedor, err := repository.Create(&fornecedor)
+	if err != nil {
+		http.Error(w, "Erro ao criar o fornecedor", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(createdFornecedor)
+}
+
+// UpdateFornecedor - Atualiza um fornecedor existente
+func UpdateFornecedor(w http.ResponseWriter, r *http.Request) {
+	var fornecedor models.Fornecedor
+	if err := json.NewDecoder(r.Body).Decode(&fornecedor); err != nil {
+		http.Error(w, "Erro ao decodificar o fornecedor", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewFornecedorRepository(r.Context())
+	if err := repository.Update(&fornecedor); err != nil {
+		http.Error(w, "Erro ao atualizar o fornecedor", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(fornecedor)
+}
+
+// DeleteFornecedor - Deleta um fornecedor por ID
+func DeleteFornecedor(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewFornecedorRepository(r.Context())
+	if err := repository.Delete(id); err != nil {
+		http.Error(w, "Erro ao deletar o fornecedor", http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte("Fornecedor deletado com sucesso"))
+}
+
+// ListCodigosFornecedor - Códigos do fornecedor associados aos itens
+func ListCodigosFornecedor(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewFornecedorRepository(r.Context())
+	vinculos, err := repository.Codigos(id)
+	if err != nil {
+		http.Error(w, "Erro ao listar os códigos do fornecedor", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(vinculos)
+}
+
+// SaveCodigoFornecedor - Associa um código do fornecedor a um item
+func SaveCodigoFornecedor(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	var vinculo models.ItemFornecedor
+	if err := json.NewDecoder(r.Body).Decode(&vinculo); err != nil {
+		http.Error(w, "Erro ao decodificar o código do fornecedor", http.StatusBadRequest)
+		return
+	}
+	if vinculo.Codigo == "" {
+		http.Error(w, "Código não fornecido", http.StatusBadRequest)
+		return
+	}
+	vinculo.FornecedorId = uint(id)
+
+	repository := repositories.NewFornecedorRepository(r.Context())
+	if err := repository.SaveCodigo(&vinculo); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Fornecedor ou item não encontrado", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Erro ao salvar o código do fornecedor", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(vinculo)
+}
+
+// DeleteCodigoFornecedor - Remove a associação de um código do fornecedor
+func DeleteCodigoFornecedor(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewFornecedorRepository(r.Context())
+	if err := repository.DeleteCodigo(id, vars["codigo"]); err != nil {
+		http.Error(w, "Erro ao remover o código do fornecedor", http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte("Código do fornecedor removido com sucesso"))
+}
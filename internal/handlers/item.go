@@ -2,23 +2,108 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"myapi/internal/config"
+	"myapi/internal/decimal"
 	"myapi/internal/models"
 	"myapi/internal/repositories"
+	"myapi/internal/services"
+	"myapi/internal/sugestao"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/gorilla/mux"
 )
 
-// ListItens - Lista todos os itens
+// ListItens - Lista todos os itens, ou apenas os com ?q= no nome ou código,
+// com saldo em ?deposito={id}, os de ?categoria={id} (com
+// ?subcategorias=true, também os das subcategorias), com preço entre
+// ?preco_min= e ?preco_max=, com ?em_estoque=true|false e os que atendem a
+// cada ?atributo=polegadas>=24; ?currency= converte os preços (ver
+// converterPrecos). Com ?facetas=true responde {"itens", "facetas"}, com as
+// contagens do resultado e preços em faixas de ?faixa_preco=.
 func ListItens(w http.ResponseWriter, r *http.Request) {
-	repository := repositories.NewItemRepository()
-	items, err := repository.ListAll()
+	query := r.URL.Query()
+	filtro := repositories.FiltroItens{Texto: strings.TrimSpace(query.Get("q"))}
+	if depositoStr := query.Get("deposito"); depositoStr != "" {
+		depositoID, err := strconv.Atoi(depositoStr)
+		if err != nil {
+			http.Error(w, "Depósito inválido", http.StatusBadRequest)
+			return
+		}
+		filtro.DepositoId = &depositoID
+	}
+	if categoriaStr := query.Get("categoria"); categoriaStr != "" {
+		categoriaID, err := strconv.Atoi(categoriaStr)
+		if err != nil {
+			http.Error(w, "Categoria inválida", http.StatusBadRequest)
+			return
+		}
+		filtro.CategoriaId = &categoriaID
+		filtro.Subcategorias = query.Get("subcategorias") == "true"
+	}
+	for _, atributo := range query["atributo"] {
+		filtroAtributo, err := repositories.ParseFiltroAtributo(atributo)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		filtro.Atributos = append(filtro.Atributos, filtroAtributo)
+	}
+	for param, destino := range map[string]**decimal.Decimal{"preco_min": &filtro.PrecoMin, "preco_max": &filtro.PrecoMax} {
+		if valorStr := query.Get(param); valorStr != "" {
+			valor, err := decimal.Parse(valorStr)
+			if err != nil {
+				http.Error(w, "Valor inválido em "+param, http.StatusBadRequest)
+				return
+			}
+			*destino = &valor
+		}
+	}
+	if estoqueStr := query.Get("em_estoque"); estoqueStr != "" {
+		emEstoque, err := strconv.ParseBool(estoqueStr)
+		if err != nil {
+			http.Error(w, "em_estoque deve ser true ou false", http.StatusBadRequest)
+			return
+		}
+		filtro.EmEstoque = &emEstoque
+	}
+
+	repository := repositories.NewItemRepository(r.Context())
+	if query.Get("facetas") != "true" {
+		items, err := repository.List(filtro)
+		if err != nil {
+			http.Error(w, "Erro ao listar os itens", http.StatusNotFound)
+			return
+		}
+		if !converterPrecos(w, r, items) {
+			return
+		}
+		json.NewEncoder(w).Encode(items)
+		return
+	}
+
+	var faixaPreco decimal.Decimal
+	if faixaStr := query.Get("faixa_preco"); faixaStr != "" {
+		var err error
+		if faixaPreco, err = decimal.Parse(faixaStr); err != nil || faixaPreco.Sign() <= 0 {
+			http.Error(w, "Faixa de preço inválida", http.StatusBadRequest)
+			return
+		}
+	}
+	items, facetas, err := repository.Buscar(filtro, faixaPreco)
 	if err != nil {
 		http.Error(w, "Erro ao listar os itens", http.StatusNotFound)
 		return
 	}
-	json.NewEncoder(w).Encode(items)
+	if !converterPrecos(w, r, items) {
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]any{"itens": items, "facetas": facetas})
 }
 
 // GetItem - Busca um item por ID
@@ -36,13 +121,17 @@ func GetItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	repository := repositories.NewItemRepository()
+	repository := repositories.NewItemRepository(r.Context())
 	item, err := repository.GetByID(id)
 	if err != nil {
 		http.Error(w, "Item não encontrado", http.StatusNotFound)
 		return
 	}
-	json.NewEncoder(w).Encode(item)
+	itens := []models.Iten{*item}
+	if !converterPrecos(w, r, itens) {
+		return
+	}
+	json.NewEncoder(w).Encode(itens[0])
 }
 
 // GetItemByCode - Busca um item pelo campo "codigo"
@@ -55,13 +144,39 @@ func GetItemByCode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	repository := repositories.NewItemRepository()
+	repository := repositories.NewItemRepository(r.Context())
 	item, err := repository.GetByCode(code)
 	if err != nil {
 		http.Error(w, "Item não encontrado", http.StatusNotFound)
 		return
 	}
-	json.NewEncoder(w).Encode(item)
+	itens := []models.Iten{*item}
+	if !converterPrecos(w, r, itens) {
+		return
+	}
+	json.NewEncoder(w).Encode(itens[0])
+}
+
+// SugerirItens - Sugestões para ?prefix= (código ou palavra do nome, sem
+// diferenciar acentos), do índice em memória; até ?limite= itens, os mais
+// vendidos e com mais estoque primeiro
+func SugerirItens(w http.ResponseWriter, r *http.Request) {
+	cfg := config.LoadBuscaConfig()
+	limite := cfg.SugestoesPadrao
+	if limiteStr := r.URL.Query().Get("limite"); limiteStr != "" {
+		var err error
+		if limite, err = strconv.Atoi(limiteStr); err != nil || limite <= 0 || limite > cfg.SugestoesMaximo {
+			http.Error(w, fmt.Sprintf("Limite deve estar entre 1 e %d", cfg.SugestoesMaximo), http.StatusBadRequest)
+			return
+		}
+	}
+
+	prefixo := sugestao.Normalizar(r.URL.Query().Get("prefix"))
+	if utf8.RuneCountInString(prefixo) < cfg.PrefixoMinimo {
+		json.NewEncoder(w).Encode([]sugestao.Entrada{})
+		return
+	}
+	json.NewEncoder(w).Encode(sugestao.Itens.Buscar(prefixo, limite))
 }
 
 // CreateItem - Cria um novo item
@@ -73,9 +188,22 @@ func CreateItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	repository := repositories.NewItemRepository()
+	repository := repositories.NewItemRepository(r.Context())
 	createdItem, err := repository.Create(&item)
 	if err != nil {
+		if serieError(err) {
+			http.Error(w, "Itens serializados recebem estoque apenas por movimentações com números de série", http.StatusUnprocessableEntity)
+			return
+		}
+		if errors.Is(err, repositories.ErrLoteEntrada) {
+			http.Error(w, "Itens com controle de lote recebem estoque apenas por movimentações com lote", http.StatusUnprocessableEntity)
+			return
+		}
+		if errors.Is(err, repositories.ErrMoedaInvalida) || errors.Is(err, repositories.ErrDadosFiscais) ||
+			errors.Is(err, repositories.ErrEanInvalido) || errors.Is(err, repositories.ErrAtributos) {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
 		http.Error(w, "Erro ao criar o item", http.StatusInternalServerError)
 		return
 	}
@@ -91,8 +219,26 @@ func UpdateItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	repository := repositories.NewItemRepository()
+	repository := repositories.NewItemRepository(r.Context())
 	if err := repository.Update(&item); err != nil {
+		if errors.Is(err, repositories.ErrEstoqueInsuficiente) {
+			http.Error(w, "Estoque insuficiente no depósito padrão para reduzir a quantidade", http.StatusConflict)
+			return
+		}
+		if serieError(err) {
+			http.Error(w, "A quantidade de itens serializados só muda por movimentações com números de série", http.StatusUnprocessableEntity)
+			return
+		}
+		if errors.Is(err, repositories.ErrLoteEntrada) {
+			http.Error(w, "Itens com controle de lote recebem estoque apenas por movimentações com lote", http.StatusUnprocessableEntity)
+			return
+		}
+		if errors.Is(err, repositories.ErrItemKit) || errors.Is(err, repositories.ErrItemProduto) ||
+			errors.Is(err, repositories.ErrMoedaInvalida) || errors.Is(err, repositories.ErrDadosFiscais) ||
+			errors.Is(err, repositories.ErrEanInvalido) || errors.Is(err, repositories.ErrAtributos) {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
 		http.Error(w, "Erro ao atualizar o item", http.StatusInternalServerError)
 		return
 	}
@@ -114,10 +260,41 @@ func DeleteItem(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	repository := repositories.NewItemRepository()
+	repository := repositories.NewItemRepository(r.Context())
 	if err := repository.Delete(id); err != nil {
+		if errors.Is(err, repositories.ErrProdutoComVariantes) {
+			http.Error(w, "Remova as variantes antes do produto", http.StatusConflict)
+			return
+		}
+		if errors.Is(err, repositories.ErrComponenteKit) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, "Erro ao deletar o item", http.StatusInternalServerError)
 		return
 	}
 	w.Write([]byte("Item deletado com sucesso"))
 }
+
+// converterPrecos - Com ?currency=USD converte os preços dos itens pela
+// cotação atual ou, com ?data=AAAA-MM-DD, pela cotação daquela data.
+// Devolve false se já respondeu com erro.
+func converterPrecos(w http.ResponseWriter, r *http.Request, itens []models.Iten) bool {
+	moeda := strings.ToUpper(r.URL.Query().Get("currency"))
+	if moeda == "" {
+		return true
+	}
+	em := time.Now()
+	if dataStr := r.URL.Query().Get("data"); dataStr != "" {
+		var err error
+		if em, err = time.ParseInLocation(time.DateOnly, dataStr, time.Local); err != nil {
+			http.Error(w, "Data inválida, use AAAA-MM-DD", http.StatusBadRequest)
+			return false
+		}
+	}
+	if err := services.ConverterItens(r.Context(), itens, moeda, em); err != nil {
+		cotacaoError(w, err, "Erro ao converter os preços")
+		return false
+	}
+	return true
+}
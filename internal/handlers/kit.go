@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"myapi/internal/models"
+	"myapi/internal/repositories"
+	"myapi/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// GetKit - Composição do kit e a disponibilidade calculada a partir dos componentes
+func GetKit(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	kit, err := services.CalcularKit(r.Context(), uint(id))
+	if errors.Is(err, services.ErrNaoEhKit) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Erro ao calcular a disponibilidade do kit", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(kit)
+}
+
+type kitRequest struct {
+	Componentes []models.ComponenteKit `json:"componentes"`
+}
+
+// SaveKit - Define os componentes (itens ou outros kits) e quantidades do kit
+func SaveKit(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	var req kitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Erro ao decodificar o kit", http.StatusBadRequest)
+		return
+	}
+	if len(req.Componentes) == 0 {
+		http.Error(w, "O kit precisa de ao menos um componente", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewKitRepository(r.Context())
+	if err := repository.SaveComponentes(uint(id), req.Componentes); err != nil {
+		kitError(w, err, "Erro ao salvar o kit")
+		return
+	}
+	GetKit(w, r)
+}
+
+// DeleteKit - Remove a composição; o item volta a ser um item comum
+func DeleteKit(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewKitRepository(r.Context())
+	if err := repository.SaveComponentes(uint(id), nil); err != nil {
+		kitError(w, err, "Erro ao remover o kit")
+		return
+	}
+	w.Write([]byte("Kit removido com sucesso"))
+}
+
+// kitError - Traduz os erros de composição de kit para o status HTTP adequado
+func kitError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrQuantidadeInvalida):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repositories.ErrCicloKit), errors.Is(err, repositories.ErrKitVariacao),
+		errors.Is(err, repositories.ErrKitProduto):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, repositories.ErrKitComEstoque), errors.Is(err, repositories.ErrKitEmUso):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "Item não encontrado", http.StatusNotFound)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"myapi/internal/models"
+	"myapi/internal/repositories"
+	"myapi/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// ListLocalizacoes - Árvore de localizações (corredor > prateleira > posição) de um depósito
+func ListLocalizacoes(w http.ResponseWriter, r *http.Request) {
+	depositoID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewLocalizacaoRepository(r.Context())
+	localizacoes, err := repository.ListByDeposito(depositoID)
+	if err != nil {
+		http.Error(w, "Erro ao listar as localizações", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(services.ArvoreLocalizacoes(localizacoes))
+}
+
+// CreateLocalizacao - Cria um corredor, prateleira ou posição
+func CreateLocalizacao(w http.ResponseWriter, r *http.Request) {
+	var localizacao models.Localizacao
+	if err := json.NewDecoder(r.Body).Decode(&localizacao); err != nil {
+		http.Error(w, "Erro ao decodificar a localização", http.StatusBadRequest)
+		return
+	}
+	if err := services.ValidarLocalizacao(r.Context(), &localizacao); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewLocalizacaoRepository(r.Context())
+	createdLocalizacao, err := repository.Create(&localizacao)
+	if err != nil {
+		http.Error(w, "Erro ao criar a localização", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(createdLocalizacao)
+}
+
+// DeleteLocalizacao - Deleta uma localização por ID
+func DeleteLocalizacao(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewLocalizacaoRepository(r.Context())
+	if err := repository.Delete(id); err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrLocalizacaoEstoque), errors.Is(err, repositories.ErrLocalizacaoComFilhas):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			http.Error(w, "Localização não encontrada", http.StatusNotFound)
+		default:
+			http.Error(w, "Erro ao deletar a localização", http.StatusInternalServerError)
+		}
+		return
+	}
+	w.Write([]byte("Localização deletada com sucesso"))
+}
+
+// SaveRegraArmazenagem - Define a posição fixa de um item em um depósito
+func SaveRegraArmazenagem(w http.ResponseWriter, r *http.Request) {
+	var regra models.RegraArmazenagem
+	if err := json.NewDecoder(r.Body).Decode(&regra); err != nil {
+		http.Error(w, "Erro ao decodificar a regra", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewLocalizacaoRepository(r.Context())
+	if err := repository.SaveRegra(&regra); err != nil {
+		estoqueError(w, err, "Erro ao salvar a regra de armazenagem")
+		return
+	}
+	json.NewEncoder(w).Encode(regra)
+}
+
+type armazenagemRequest struct {
+	ItemId        uint  `json:"item_id"`
+	DepositoId    uint  `json:"deposito_id"`
+	LocalizacaoId *uint `json:"localizacao_id"`
+	Quantidade    int   `json:"quantidade"`
+}
+
+// CreateArmazenagem - Guarda em uma posição o estoque do depósito que ainda
+// não tem endereço. Sem localizacao_id, a posição é escolhida pela regra do
+// item ou pela posição livre mais próxima.
+func CreateArmazenagem(w http.ResponseWriter, r *http.Request) {
+	var req armazenagemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Erro ao decodificar a armazenagem", http.StatusBadRequest)
+		return
+	}
+
+	var localizacaoID uint
+	if req.LocalizacaoId != nil {
+		localizacaoID = *req.LocalizacaoId
+	} else {
+		id, err := services.EscolherPosicao(r.Context(), req.ItemId, req.DepositoId)
+		if err != nil {
+			estoqueError(w, err, "Erro ao escolher a posição")
+			return
+		}
+		localizacaoID = id
+	}
+
+	repository := repositories.NewLocalizacaoRepository(r.Context())
+	if err := repository.Armazenar(req.ItemId, req.DepositoId, localizacaoID, req.Quantidade); err != nil {
+		estoqueError(w, err, "Erro ao armazenar o item")
+		return
+	}
+	req.LocalizacaoId = &localizacaoID
+	json.NewEncoder(w).Encode(req)
+}
+
+type separacaoRequest struct {
+	Itens []services.ItemSeparacao `json:"itens"`
+}
+
+// CreateListaSeparacao - Gera a lista de separação dos itens pedidos,
+// ordenada pelo percurso no depósito
+func CreateListaSeparacao(w http.ResponseWriter, r *http.Request) {
+	depositoID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	var req separacaoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Erro ao decodificar a separação", http.StatusBadRequest)
+		return
+	}
+
+	lista, err := services.GerarListaSeparacao(r.Context(), depositoID, req.Itens)
+	if err != nil {
+		estoqueError(w, err, "Erro ao gerar a lista de separação")
+		return
+	}
+	json.NewEncoder(w).Encode(lista)
+}
+
+// localizacaoError - Erros de endereçamento que são do cliente
+func localizacaoError(err error) bool {
+	return errors.Is(err, repositories.ErrLocalizacaoInvalida) ||
+		errors.Is(err, repositories.ErrPosicaoOcupada) ||
+		errors.Is(err, repositories.ErrSemEstoqueLivre) ||
+		errors.Is(err, services.ErrSemPosicaoLivre)
+}
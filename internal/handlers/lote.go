@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"myapi/internal/models"
+	"myapi/internal/repositories"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// ListLotesItem - Lotes de um item com os saldos por depósito
+func ListLotesItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewLoteRepository(r.Context())
+	lotes, err := repository.ListByItem(id)
+	if err != nil {
+		http.Error(w, "Erro ao listar os lotes", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(lotes)
+}
+
+// CreateLote - Cadastra um lote (número, fabricação e validade) de um item
+func CreateLote(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	var lote models.Lote
+	if err := json.NewDecoder(r.Body).Decode(&lote); err != nil {
+		http.Error(w, "Erro ao decodificar o lote", http.StatusBadRequest)
+		return
+	}
+	if lote.Numero == "" {
+		http.Error(w, "Número do lote é obrigatório", http.StatusBadRequest)
+		return
+	}
+	if lote.Fabricacao != nil && lote.Validade != nil && lote.Validade.Before(*lote.Fabricacao) {
+		http.Error(w, "Validade anterior à fabricação", http.StatusBadRequest)
+		return
+	}
+	lote.ItemId = uint(id)
+
+	repository := repositories.NewLoteRepository(r.Context())
+	createdLote, err := repository.Create(&lote)
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "Item não encontrado", http.StatusNotFound)
+		return
+	case errors.Is(err, repositories.ErrItemSemLote):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	case err != nil:
+		http.Error(w, "Erro ao criar o lote", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(createdLote)
+}
+
+// ListLotesVencendo - Saldos de lotes que vencem nos próximos ?dias= (padrão 30), incluindo os vencidos
+func ListLotesVencendo(w http.ResponseWriter, r *http.Request) {
+	dias := 30
+	if diasStr := r.URL.Query().Get("dias"); diasStr != "" {
+		n, err := strconv.Atoi(diasStr)
+		if err != nil || n < 0 {
+			http.Error(w, "Dias inválido", http.StatusBadRequest)
+			return
+		}
+		dias = n
+	}
+
+	repository := repositories.NewLoteRepository(r.Context())
+	lotes, err := repository.ListVencendo(time.Now().AddDate(0, 0, dias))
+	if err != nil {
+		http.Error(w, "Erro ao listar os lotes a vencer", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(lotes)
+}
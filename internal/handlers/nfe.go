@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"myapi/internal/nfe"
+	"myapi/internal/repositories"
+	"myapi/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// tamanhoMaximoXML - Limite do arquivo da NF-e
+const tamanhoMaximoXML = 5 << 20
+
+// ImportarNfe - Carrega o XML de uma NF-e enviado no corpo e devolve a
+// importação pendente com a correspondência e as propostas de cada linha
+func ImportarNfe(w http.ResponseWriter, r *http.Request) {
+	importacao, err := services.ImportarNfe(r.Context(), http.MaxBytesReader(w, r.Body, tamanhoMaximoXML))
+	if err != nil {
+		var maxBytes *http.MaxBytesError
+		if errors.As(err, &maxBytes) {
+			http.Error(w, "Arquivo da NF-e muito grande", http.StatusRequestEntityTooLarge)
+			return
+		}
+		nfeError(w, err, "Erro ao importar a NF-e")
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(importacao)
+}
+
+// ListImportacoesNfe - Lista as importações de NF-e, opcionalmente por ?status=
+func ListImportacoesNfe(w http.ResponseWriter, r *http.Request) {
+	repository := repositories.NewNfeRepository(r.Context())
+	importacoes, err := repository.ListAll(r.URL.Query().Get("status"))
+	if err != nil {
+		http.Error(w, "Erro ao listar as importações de NF-e", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(importacoes)
+}
+
+// GetImportacaoNfe - Busca uma importação de NF-e por ID
+func GetImportacaoNfe(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewNfeRepository(r.Context())
+	importacao, err := repository.GetByID(id)
+	if err != nil {
+		http.Error(w, "Importação de NF-e não encontrada", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(importacao)
+}
+
+type confirmacaoNfeRequest struct {
+	DepositoId *uint                           `json:"deposito_id"`
+	Linhas     []repositories.LinhaConfirmacao `json:"linhas"`
+}
+
+// ConfirmarImportacaoNfe - Cadastra os itens propostos e lança as entradas
+// de estoque da nota
+func ConfirmarImportacaoNfe(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	var req confirmacaoNfeRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Erro ao decodificar a confirmação", http.StatusBadRequest)
+			return
+		}
+	}
+
+	repository := repositories.NewNfeRepository(r.Context())
+	importacao, err := repository.Confirmar(id, req.DepositoId, req.Linhas)
+	if err != nil {
+		nfeError(w, err, "Erro ao confirmar a importação da NF-e")
+		return
+	}
+	json.NewEncoder(w).Encode(importacao)
+}
+
+// DeleteImportacaoNfe - Descarta uma importação pendente
+func DeleteImportacaoNfe(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewNfeRepository(r.Context())
+	if err := repository.Delete(id); err != nil {
+		nfeError(w, err, "Erro ao descartar a importação da NF-e")
+		return
+	}
+	w.Write([]byte("Importação de NF-e descartada com sucesso"))
+}
+
+// nfeError - Traduz os erros de importação de NF-e para o status HTTP adequado
+func nfeError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, nfe.ErrXmlInvalido), errors.Is(err, nfe.ErrVersao):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, repositories.ErrNfeJaImportada), errors.Is(err, repositories.ErrNfeConfirmada):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, repositories.ErrLinhaNfe), errors.Is(err, repositories.ErrQuantidadeFracionada),
+		errors.Is(err, repositories.ErrLotesNfe),
+		errors.Is(err, repositories.ErrEanInvalido), errors.Is(err, repositories.ErrMoedaInvalida),
+		errors.Is(err, repositories.ErrDadosFiscais), errors.Is(err, repositories.ErrAtributos):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "Importação, item ou depósito não encontrado", http.StatusNotFound)
+	default:
+		estoqueError(w, err, message)
+	}
+}
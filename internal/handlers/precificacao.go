@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"myapi/internal/models"
+	"myapi/internal/repositories"
+	"myapi/internal/services"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// ListTabelasPreco - Lista as tabelas de preços
+func ListTabelasPreco(w http.ResponseWriter, r *http.Request) {
+	repository := repositories.NewTabelaPrecoRepository(r.Context())
+	tabelas, err := repository.ListAll()
+	if err != nil {
+		http.Error(w, "Erro ao listar as tabelas de preços", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(tabelas)
+}
+
+// GetTabelaPreco - Busca uma tabela de preços por ID
+func GetTabelaPreco(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewTabelaPrecoRepository(r.Context())
+	tabela, err := repository.GetByID(id)
+	if err != nil {
+		http.Error(w, "Tabela de preços não encontrada", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(tabela)
+}
+
+// CreateTabelaPreco - Cria uma nova tabela de preços
+func CreateTabelaPreco(w http.ResponseWriter, r *http.Request) {
+	var tabela models.TabelaPreco
+	if err := json.NewDecoder(r.Body).Decode(&tabela); err != nil {
+		http.Error(w, "Erro ao decodificar a tabela de preços", http.StatusBadRequest)
+		return
+	}
+	if tabela.Codigo == "" {
+		http.Error(w, "Código da tabela é obrigatório", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewTabelaPrecoRepository(r.Context())
+	createdTabela, err := repository.Create(&tabela)
+	if err != nil {
+		http.Error(w, "Erro ao criar a tabela de preços", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(createdTabela)
+}
+
+// UpdateTabelaPreco - Atualiza uma tabela de preços existente
+func UpdateTabelaPreco(w http.ResponseWriter, r *http.Request) {
+	var tabela models.TabelaPreco
+	if err := json.NewDecoder(r.Body).Decode(&tabela); err != nil {
+		http.Error(w, "Erro ao decodificar a tabela de preços", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewTabelaPrecoRepository(r.Context())
+	if err := repository.Update(&tabela); err != nil {
+		http.Error(w, "Erro ao atualizar a tabela de preços", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(tabela)
+}
+
+// DeleteTabelaPreco - Deleta uma tabela de preços sem clientes ou regras vinculados
+func DeleteTabelaPreco(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewTabelaPrecoRepository(r.Context())
+	if err := repository.Delete(id); err != nil {
+		if errors.Is(err, repositories.ErrTabelaEmUso) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "Erro ao deletar a tabela de preços", http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte("Tabela de preços deletada com sucesso"))
+}
+
+// ListPrecosTabela - Preços próprios dos itens na tabela
+func ListPrecosTabela(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewTabelaPrecoRepository(r.Context())
+	precos, err := repository.ListPrecos(id)
+	if err != nil {
+		http.Error(w, "Erro ao listar os preços da tabela", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(precos)
+}
+
+// SavePrecoTabela - Define o preço de um item na tabela: {"preco": 9.90, "moeda": "BRL"}
+func SavePrecoTabela(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, errId := strconv.Atoi(vars["id"])
+	itemID, errItem := strconv.Atoi(vars["itemId"])
+	if errId != nil || errItem != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	var preco models.PrecoTabela
+	if err := json.NewDecoder(r.Body).Decode(&preco); err != nil {
+		http.Error(w, "Erro ao decodificar o preço", http.StatusBadRequest)
+		return
+	}
+	preco.TabelaPrecoId = uint(id)
+	preco.ItemId = uint(itemID)
+
+	repository := repositories.NewTabelaPrecoRepository(r.Context())
+	if err := repository.SavePreco(&preco); err != nil {
+		precificacaoError(w, err, "Erro ao salvar o preço da tabela")
+		return
+	}
+	json.NewEncoder(w).Encode(preco)
+}
+
+// DeletePrecoTabela - Remove o preço próprio do item na tabela
+func DeletePrecoTabela(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, errId := strconv.Atoi(vars["id"])
+	itemID, errItem := strconv.Atoi(vars["itemId"])
+	if errId != nil || errItem != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewTabelaPrecoRepository(r.Context())
+	if err := repository.DeletePreco(id, itemID); err != nil {
+		http.Error(w, "Erro ao remover o preço da tabela", http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte("Preço da tabela removido com sucesso"))
+}
+
+// ListRegrasPreco - Lista as regras de preço
+func ListRegrasPreco(w http.ResponseWriter, r *http.Request) {
+	repository := repositories.NewRegraPrecoRepository(r.Context())
+	regras, err := repository.ListAll()
+	if err != nil {
+		http.Error(w, "Erro ao listar as regras de preço", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(regras)
+}
+
+// GetRegraPreco - Busca uma regra de preço por ID
+func GetRegraPreco(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewRegraPrecoRepository(r.Context())
+	regra, err := repository.GetByID(id)
+	if err != nil {
+		http.Error(w, "Regra de preço não encontrada", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(regra)
+}
+
+// CreateRegraPreco - Cria uma regra de preço
+func CreateRegraPreco(w http.ResponseWriter, r *http.Request) {
+	var regra models.RegraPreco
+	if err := json.NewDecoder(r.Body).Decode(&regra); err != nil {
+		http.Error(w, "Erro ao decodificar a regra de preço", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewRegraPrecoRepository(r.Context())
+	createdRegra, err := repository.Create(&regra)
+	if err != nil {
+		precificacaoError(w, err, "Erro ao criar a regra de preço")
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(createdRegra)
+}
+
+// UpdateRegraPreco - Atualiza uma regra de preço existente
+func UpdateRegraPreco(w http.ResponseWriter, r *http.Request) {
+	var regra models.RegraPreco
+	if err := json.NewDecoder(r.Body).Decode(&regra); err != nil {
+		http.Error(w, "Erro ao decodificar a regra de preço", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewRegraPrecoRepository(r.Context())
+	if err := repository.Update(&regra); err != nil {
+		precificacaoError(w, err, "Erro ao atualizar a regra de preço")
+		return
+	}
+	json.NewEncoder(w).Encode(regra)
+}
+
+// DeleteRegraPreco - Deleta uma regra de preço por ID
+func DeleteRegraPreco(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewRegraPrecoRepository(r.Context())
+	if err := repository.Delete(id); err != nil {
+		http.Error(w, "Erro ao deletar a regra de preço", http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte("Regra de preço deletada com sucesso"))
+}
+
+// GetPrecificacao - Preço final do item para ?quantidade= (padrão 1),
+// ?tabela= (código) ou ?cliente= e ?em= (RFC 3339, padrão agora), com as
+// etapas que levaram a ele
+func GetPrecificacao(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	query := r.URL.Query()
+	consulta := services.ConsultaPreco{ItemId: uint(id), Quantidade: 1, Tabela: query.Get("tabela"), Em: time.Now()}
+	if quantidadeStr := query.Get("quantidade"); quantidadeStr != "" {
+		if consulta.Quantidade, err = strconv.Atoi(quantidadeStr); err != nil || consulta.Quantidade <= 0 {
+			http.Error(w, "Quantidade inválida", http.StatusBadRequest)
+			return
+		}
+	}
+	if clienteStr := query.Get("cliente"); clienteStr != "" {
+		clienteID, err := strconv.Atoi(clienteStr)
+		if err != nil {
+			http.Error(w, "Cliente inválido", http.StatusBadRequest)
+			return
+		}
+		consulta.ClienteId = uint(clienteID)
+	}
+	if emStr := query.Get("em"); emStr != "" {
+		if consulta.Em, err = time.Parse(time.RFC3339, emStr); err != nil {
+			http.Error(w, "Data inválida, use o formato 2006-01-02T15:04:05-03:00", http.StatusBadRequest)
+			return
+		}
+	}
+
+	preco, err := services.Precificar(r.Context(), consulta)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			http.Error(w, "Item, cliente ou tabela de preços não encontrado", http.StatusNotFound)
+		case errors.Is(err, repositories.ErrSemCotacao):
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		default:
+			http.Error(w, "Erro ao calcular o preço", http.StatusInternalServerError)
+		}
+		return
+	}
+	json.NewEncoder(w).Encode(preco)
+}
+
+// precificacaoError - Traduz os erros de tabelas e regras de preço para o status HTTP adequado
+func precificacaoError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrPrecoNegativo), errors.Is(err, repositories.ErrRegraInvalida),
+		errors.Is(err, repositories.ErrMoedaInvalida):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "Item, categoria ou tabela de preços não encontrado", http.StatusNotFound)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
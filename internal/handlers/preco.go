@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"myapi/internal/models"
+	"myapi/internal/repositories"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// ListPrecosItem - Linha do tempo de preços de um item, incluindo os agendados
+func ListPrecosItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewPrecoRepository(r.Context())
+	precos, err := repository.Historico(id)
+	if err != nil {
+		http.Error(w, "Erro ao listar os preços", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(precos)
+}
+
+// GetPrecoVigente - Preço do item em ?em= (RFC 3339, padrão agora)
+func GetPrecoVigente(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	em := time.Now()
+	if emStr := r.URL.Query().Get("em"); emStr != "" {
+		if em, err = time.Parse(time.RFC3339, emStr); err != nil {
+			http.Error(w, "Data inválida, use o formato 2006-01-02T15:04:05-03:00", http.StatusBadRequest)
+			return
+		}
+	}
+
+	repository := repositories.NewPrecoRepository(r.Context())
+	preco, err := repository.VigenteEm(id, em)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Item sem preço registrado na data", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Erro ao buscar o preço", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(preco)
+}
+
+// AgendarPreco - Altera o preço do item a partir de vigente_de (padrão agora)
+// e, com vigente_ate, só até essa data
+func AgendarPreco(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	var preco models.HistoricoPreco
+	if err := json.NewDecoder(r.Body).Decode(&preco); err != nil {
+		http.Error(w, "Erro ao decodificar o preço", http.StatusBadRequest)
+		return
+	}
+	preco.ItemId = uint(id)
+
+	repository := repositories.NewPrecoRepository(r.Context())
+	if err := repository.Agendar(&preco); err != nil {
+		precoError(w, err, "Erro ao registrar o preço")
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(preco)
+}
+
+// CancelarPreco - Remove um preço agendado que ainda não entrou em vigor
+func CancelarPreco(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, errId := strconv.Atoi(vars["id"])
+	precoID, errPreco := strconv.Atoi(vars["precoId"])
+	if errId != nil || errPreco != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewPrecoRepository(r.Context())
+	if err := repository.Cancelar(id, precoID); err != nil {
+		precoError(w, err, "Erro ao cancelar o preço")
+		return
+	}
+	w.Write([]byte("Preço agendado cancelado com sucesso"))
+}
+
+// precoError - Traduz os erros de preço para o status HTTP adequado
+func precoError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrPrecoNegativo), errors.Is(err, repositories.ErrPrecoRetroativo),
+		errors.Is(err, repositories.ErrPrecoVigencia):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, repositories.ErrPrecoAplicado):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "Item ou preço não encontrado", http.StatusNotFound)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"encoding/json"
+	"myapi/internal/services"
+	"net/http"
+	"time"
+)
+
+// GetValorizacao - Valor do estoque por categoria e depósito em ?data=AAAA-MM-DD (padrão hoje)
+func GetValorizacao(w http.ResponseWriter, r *http.Request) {
+	data := time.Now()
+	if dataStr := r.URL.Query().Get("data"); dataStr != "" {
+		var err error
+		if data, err = time.ParseInLocation(time.DateOnly, dataStr, time.Local); err != nil {
+			http.Error(w, "Data inválida, use AAAA-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+
+	valorizacao, err := services.ValorizarEstoque(r.Context(), data)
+	if err != nil {
+		http.Error(w, "Erro ao valorizar o estoque", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(valorizacao)
+}
+
+// GetCMV - Custo das mercadorias vendidas entre ?de= e ?ate= (AAAA-MM-DD, inclusive)
+func GetCMV(w http.ResponseWriter, r *http.Request) {
+	de, errDe := time.ParseInLocation(time.DateOnly, r.URL.Query().Get("de"), time.Local)
+	ate, errAte := time.ParseInLocation(time.DateOnly, r.URL.Query().Get("ate"), time.Local)
+	if errDe != nil || errAte != nil || ate.Before(de) {
+		http.Error(w, "Informe o período em ?de= e ?ate= (AAAA-MM-DD)", http.StatusBadRequest)
+		return
+	}
+
+	cmv, err := services.CalcularCMV(r.Context(), de, ate)
+	if err != nil {
+		http.Error(w, "Erro ao calcular o custo das mercadorias vendidas", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(cmv)
+}
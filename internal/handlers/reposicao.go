@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"myapi/internal/config"
+	"myapi/internal/models"
+	"myapi/internal/repositories"
+	"myapi/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// GetReposicaoItem - Parâmetros de reposição de um item
+func GetReposicaoItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewReposicaoRepository(r.Context())
+	parametro, err := repository.GetParametro(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Item sem parâmetros de reposição", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Erro ao buscar os parâmetros de reposição", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(parametro)
+}
+
+// SaveReposicaoItem - Define mínimo, máximo, ponto de pedido, lead time e fornecedor de um item
+func SaveReposicaoItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	var parametro models.ParametroReposicao
+	if err := json.NewDecoder(r.Body).Decode(&parametro); err != nil {
+		http.Error(w, "Erro ao decodificar os parâmetros de reposição", http.StatusBadRequest)
+		return
+	}
+	parametro.ItemId = uint(id)
+	if parametro.Minimo < 0 || parametro.LeadTimeDias < 0 || (parametro.Maximo > 0 && parametro.Maximo < parametro.Minimo) {
+		http.Error(w, "Parâmetros inválidos: máximo deve ser maior que o mínimo", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewReposicaoRepository(r.Context())
+	if err := repository.SaveParametro(&parametro); err != nil {
+		http.Error(w, "Erro ao salvar os parâmetros de reposição", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(parametro)
+}
+
+// ListSugestoesCompra - Quantidades sugeridas para compra, agrupadas por fornecedor
+func ListSugestoesCompra(w http.ResponseWriter, r *http.Request) {
+	dias := config.LoadReposicaoConfig().ConsumoDias
+	if diasStr := r.URL.Query().Get("dias"); diasStr != "" {
+		n, err := strconv.Atoi(diasStr)
+		if err != nil || n <= 0 {
+			http.Error(w, "Dias inválido", http.StatusBadRequest)
+			return
+		}
+		dias = n
+	}
+
+	sugestoes, err := services.SugerirCompras(r.Context(), dias)
+	if err != nil {
+		http.Error(w, "Erro ao calcular as sugestões de compra", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(sugestoes)
+}
+
+// ListNotificacoes - Lista as notificações; ?nao_lidas=true filtra as pendentes
+func ListNotificacoes(w http.ResponseWriter, r *http.Request) {
+	repository := repositories.NewReposicaoRepository(r.Context())
+	notificacoes, err := repository.ListNotificacoes(r.URL.Query().Get("nao_lidas") == "true")
+	if err != nil {
+		http.Error(w, "Erro ao listar as notificações", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(notificacoes)
+}
+
+// MarcarNotificacaoLida - Marca uma notificação como lida
+func MarcarNotificacaoLida(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewReposicaoRepository(r.Context())
+	if err := repository.MarcarLida(id); err != nil {
+		http.Error(w, "Erro ao atualizar a notificação", http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte("Notificação marcada como lida"))
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"myapi/internal/repositories"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// ListSeriesItem - Números de série de um item; ?status=em_estoque filtra as unidades em estoque
+func ListSeriesItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewSerieRepository(r.Context())
+	series, err := repository.ListByItem(id, r.URL.Query().Get("status"))
+	if err != nil {
+		http.Error(w, "Erro ao listar os números de série", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(series)
+}
+
+type registroSeriesRequest struct {
+	DepositoId uint     `json:"deposito_id"`
+	Series     []string `json:"series"`
+}
+
+// RegistrarSeries - Cadastra números de série para o estoque que o item já
+// tinha no depósito antes de ser serializado
+func RegistrarSeries(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	var req registroSeriesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Erro ao decodificar os números de série", http.StatusBadRequest)
+		return
+	}
+	if len(req.Series) == 0 {
+		http.Error(w, "Informe ao menos um número de série", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewSerieRepository(r.Context())
+	series, err := repository.Registrar(uint(id), req.DepositoId, req.Series)
+	if err != nil {
+		estoqueError(w, err, "Erro ao registrar os números de série")
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(series)
+}
+
+// GetSerie - Localização atual e histórico de movimentações de uma unidade pelo número de série
+func GetSerie(w http.ResponseWriter, r *http.Request) {
+	numero := mux.Vars(r)["numero"]
+
+	repository := repositories.NewSerieRepository(r.Context())
+	historicos, err := repository.Historico(numero)
+	if err != nil {
+		http.Error(w, "Erro ao buscar o número de série", http.StatusInternalServerError)
+		return
+	}
+	if len(historicos) == 0 {
+		http.Error(w, "Número de série não encontrado", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(historicos)
+}
+
+func serieError(err error) bool {
+	return errors.Is(err, repositories.ErrItemNaoSerializado) ||
+		errors.Is(err, repositories.ErrSeriesQuantidade) ||
+		errors.Is(err, repositories.ErrSerieRepetida) ||
+		errors.Is(err, repositories.ErrSemEstoqueSemSerie)
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"myapi/internal/models"
+	"myapi/internal/repositories"
+	"myapi/internal/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// GetVariacoes - Produto, variantes e o estoque delas somado por depósito
+func GetVariacoes(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	produto, err := services.ConsultarProduto(r.Context(), uint(id))
+	if err != nil {
+		variacaoError(w, err, "Erro ao consultar as variantes")
+		return
+	}
+	json.NewEncoder(w).Encode(produto)
+}
+
+type variacoesRequest struct {
+	Dimensoes models.DimensoesVariacao `json:"dimensoes"`
+}
+
+// SaveVariacoes - Define as dimensões (cor, tamanho...) do produto
+func SaveVariacoes(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	var req variacoesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Erro ao decodificar as dimensões", http.StatusBadRequest)
+		return
+	}
+	if len(req.Dimensoes) == 0 {
+		http.Error(w, "O produto precisa de ao menos uma dimensão", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewVariacaoRepository(r.Context())
+	if err := repository.SaveDimensoes(uint(id), req.Dimensoes); err != nil {
+		variacaoError(w, err, "Erro ao salvar as dimensões")
+		return
+	}
+	GetVariacoes(w, r)
+}
+
+// DeleteVariacoes - Remove as dimensões de um produto sem variantes; ele
+// volta a ser um item comum
+func DeleteVariacoes(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewVariacaoRepository(r.Context())
+	if err := repository.SaveDimensoes(uint(id), nil); err != nil {
+		variacaoError(w, err, "Erro ao remover as dimensões")
+		return
+	}
+	w.Write([]byte("Variações removidas com sucesso"))
+}
+
+// GetMatrizVariacoes - Todas as combinações das dimensões, com a variante
+// de cada uma ou o código e o nome que ela receberá
+func GetMatrizVariacoes(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewVariacaoRepository(r.Context())
+	matriz, err := repository.Matriz(uint(id))
+	if err != nil {
+		variacaoError(w, err, "Erro ao montar a matriz de variantes")
+		return
+	}
+	json.NewEncoder(w).Encode(matriz)
+}
+
+type matrizRequest struct {
+	Variantes        []repositories.VarianteMatriz `json:"variantes"`
+	ApenasInformadas bool                          `json:"apenas_informadas"`
+}
+
+// CriarMatrizVariacoes - Cria de uma vez as variantes das combinações que
+// ainda não existem (sem corpo, todas com os dados do produto)
+func CriarMatrizVariacoes(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+	var req matrizRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Erro ao decodificar as variantes", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewVariacaoRepository(r.Context())
+	matriz, err := repository.CriarVariantes(uint(id), req.Variantes, req.ApenasInformadas)
+	if err != nil {
+		variacaoError(w, err, "Erro ao criar as variantes")
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(matriz)
+}
+
+// variacaoError - Traduz os erros de variações para o status HTTP adequado
+func variacaoError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrDimensoes), errors.Is(err, repositories.ErrVariante),
+		errors.Is(err, repositories.ErrPrecoNegativo), errors.Is(err, repositories.ErrMoedaInvalida),
+		errors.Is(err, repositories.ErrEanInvalido), errors.Is(err, repositories.ErrDadosFiscais),
+		errors.Is(err, repositories.ErrAtributos):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, repositories.ErrProdutoComEstoque), errors.Is(err, repositories.ErrProdutoComVariantes):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, repositories.ErrNaoEhProduto):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "Item não encontrado", http.StatusNotFound)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
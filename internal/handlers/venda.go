@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"myapi/internal/config"
+	"myapi/internal/models"
+	"myapi/internal/repositories"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+// ListVendas - Lista os pedidos de venda, opcionalmente por ?status=
+func ListVendas(w http.ResponseWriter, r *http.Request) {
+	repository := repositories.NewVendaRepository(r.Context())
+	pedidos, err := repository.ListAll(r.URL.Query().Get("status"))
+	if err != nil {
+		http.Error(w, "Erro ao listar os pedidos de venda", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(pedidos)
+}
+
+// GetVenda - Busca um pedido de venda por ID
+func GetVenda(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewVendaRepository(r.Context())
+	pedido, err := repository.GetByID(id)
+	if err != nil {
+		http.Error(w, "Pedido de venda não encontrado", http.StatusNotFound)
+		return
+	}
+	json.NewEncoder(w).Encode(pedido)
+}
+
+// CreateVenda - Cria um pedido de venda reservando o estoque das linhas
+func CreateVenda(w http.ResponseWriter, r *http.Request) {
+	var pedido models.PedidoVenda
+	if err := json.NewDecoder(r.Body).Decode(&pedido); err != nil {
+		http.Error(w, "Erro ao decodificar o pedido de venda", http.StatusBadRequest)
+		return
+	}
+
+	repository := repositories.NewVendaRepository(r.Context())
+	createdPedido, err := repository.Create(&pedido, config.LoadVendaConfig().ReservaTTL)
+	if err != nil {
+		vendaError(w, err, "Erro ao criar o pedido de venda")
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(createdPedido)
+}
+
+// ConfirmarVenda - Confirma o pedido; a reserva deixa de expirar
+func ConfirmarVenda(w http.ResponseWriter, r *http.Request) {
+	transicaoVenda(w, r, (*repositories.VendaRepository).Confirmar)
+}
+
+type atendimentoRequest struct {
+	Series map[string][]string `json:"series"`
+}
+
+// AtenderVenda - Baixa o estoque reservado do pedido. O corpo é opcional e
+// informa os números de série dos itens serializados por código.
+func AtenderVenda(w http.ResponseWriter, r *http.Request) {
+	var req atendimentoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "Erro ao decodificar o atendimento", http.StatusBadRequest)
+		return
+	}
+	transicaoVenda(w, r, func(repository *repositories.VendaRepository, id int) (*models.PedidoVenda, error) {
+		return repository.Atender(id, req.Series)
+	})
+}
+
+// CancelarVenda - Cancela o pedido liberando a reserva
+func CancelarVenda(w http.ResponseWriter, r *http.Request) {
+	transicaoVenda(w, r, (*repositories.VendaRepository).Cancelar)
+}
+
+func transicaoVenda(w http.ResponseWriter, r *http.Request, fn func(*repositories.VendaRepository, int) (*models.PedidoVenda, error)) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	pedido, err := fn(repositories.NewVendaRepository(r.Context()), id)
+	if err != nil {
+		vendaError(w, err, "Erro ao alterar o status do pedido de venda")
+		return
+	}
+	json.NewEncoder(w).Encode(pedido)
+}
+
+// vendaError - Traduz os erros de venda para o status HTTP adequado
+func vendaError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, repositories.ErrPedidoSemItens), errors.Is(err, repositories.ErrItemInexistente):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, repositories.ErrTransicaoInvalida), errors.Is(err, repositories.ErrEstoqueInsuficiente),
+		errors.Is(err, repositories.ErrReservaExpirada):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		http.Error(w, "Pedido de venda não encontrado", http.StatusNotFound)
+	default:
+		estoqueError(w, err, message)
+	}
+}
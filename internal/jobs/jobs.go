@@ -0,0 +1,178 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"myapi/internal/cache"
+	"myapi/internal/config"
+	"myapi/internal/repositories"
+	"myapi/internal/services"
+	"myapi/internal/sugestao"
+)
+
+// Chaves dos advisory locks que garantem uma única execução entre réplicas
+const (
+	lockEstoqueBaixo int64 = iota + 1001
+	lockPrecosAgendados
+)
+
+// every - Executa fn a cada intervalo em uma goroutine
+func every(interval time.Duration, fn func()) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			fn()
+		}
+	}()
+}
+
+// exclusivo - Executa fn apenas se conseguir o advisory lock da chave, para
+// que a rotina rode em uma única instância por vez
+func exclusivo(key int64, fn func()) {
+	sqlDB, err := config.DB.DB()
+	if err != nil {
+		log.Printf("Erro ao obter conexão para a rotina %d: %v", key, err)
+		return
+	}
+	ctx := context.Background()
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		log.Printf("Erro ao obter conexão para a rotina %d: %v", key, err)
+		return
+	}
+	defer conn.Close()
+
+	var ok bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&ok); err != nil || !ok {
+		return
+	}
+	defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", key)
+	fn()
+}
+
+// ExpirarReservas - Libera periodicamente as reservas de pedidos de venda não confirmados
+func ExpirarReservas(interval time.Duration) {
+	every(interval, func() {
+		n, err := repositories.NewVendaRepository(context.Background()).ExpirarReservas()
+		if err != nil {
+			log.Printf("Erro ao expirar reservas: %v", err)
+		}
+		if n > 0 {
+			log.Printf("%d pedido(s) de venda expirado(s)", n)
+		}
+	})
+}
+
+// VerificarEstoqueBaixo - Notifica periodicamente os itens que cruzaram o ponto de pedido
+func VerificarEstoqueBaixo(cfg config.ReposicaoConfig) {
+	every(cfg.Interval, func() {
+		exclusivo(lockEstoqueBaixo, func() {
+			if err := services.VerificarEstoqueBaixo(context.Background(), cfg.ConsumoDias, cfg.WebhookURL); err != nil {
+				log.Printf("Erro ao verificar estoque baixo: %v", err)
+			}
+		})
+	})
+}
+
+// AplicarPrecosAgendados - Aplica periodicamente aos itens os preços cuja vigência começou
+func AplicarPrecosAgendados(interval time.Duration) {
+	every(interval, func() {
+		exclusivo(lockPrecosAgendados, func() {
+			n, err := repositories.NewPrecoRepository(context.Background()).AplicarAgendados()
+			if err != nil {
+				log.Printf("Erro ao aplicar preços agendados: %v", err)
+			}
+			if n > 0 {
+				log.Printf("%d preço(s) agendado(s) aplicado(s)", n)
+			}
+		})
+	})
+}
+
+// Sugestoes - Carrega o índice de sugestões e o mantém atualizado. Cada
+// invalidação de item recebida pelo cache (inclusive as desta instância,
+// entregues após o commit) recarrega o item; a reconstrução periódica, ou
+// após avisos perdidos, refaz o índice e a popularidade de todos os itens.
+func Sugestoes(cfg config.BuscaConfig) {
+	alterados := make(chan uint, 1024)
+	reconstruir := make(chan struct{}, 1)
+	pedirReconstrucao := func() {
+		select {
+		case reconstruir <- struct{}{}:
+		default:
+		}
+	}
+	cache.Observar(func(payload string) {
+		if payload == "*" {
+			pedirReconstrucao()
+			return
+		}
+		nome, chave, _ := strings.Cut(payload, ":")
+		var id uint
+		if _, err := fmt.Sscanf(chave, "id:%d", &id); nome != "itens" || err != nil {
+			return
+		}
+		// Com a fila cheia, recarregar tudo sai mais barato que esperar
+		select {
+		case alterados <- id:
+		default:
+			pedirReconstrucao()
+		}
+	})
+
+	go func() {
+		reconstruirSugestoes(cfg.PopularidadeDias)
+		ticker := time.NewTicker(cfg.ReconstrucaoInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				reconstruirSugestoes(cfg.PopularidadeDias)
+			case <-reconstruir:
+				reconstruirSugestoes(cfg.PopularidadeDias)
+			case id := <-alterados:
+				// Junta as alterações que já estão na fila em uma só consulta
+				ids := []uint{id}
+				for len(ids) < 500 && len(alterados) > 0 {
+					ids = append(ids, <-alterados)
+				}
+				atualizarSugestoes(ids, cfg.PopularidadeDias)
+			}
+		}
+	}()
+}
+
+func reconstruirSugestoes(dias int) {
+	entradas, err := repositories.NewSugestaoRepository(context.Background()).Entradas(nil, dias)
+	if err != nil {
+		log.Printf("Erro ao carregar o índice de sugestões: %v", err)
+		return
+	}
+	sugestao.Itens.Carregar(entradas)
+}
+
+// atualizarSugestoes - Recarrega os itens alterados, lendo do primário
+// porque as réplicas podem ainda não ter a alteração avisada
+func atualizarSugestoes(ids []uint, dias int) {
+	ctx := config.WithConsistency(context.Background(), true)
+	entradas, err := repositories.NewSugestaoRepository(ctx).Entradas(ids, dias)
+	if err != nil {
+		log.Printf("Erro ao atualizar o índice de sugestões: %v", err)
+		return
+	}
+	encontrados := map[uint]bool{}
+	for _, entrada := range entradas {
+		sugestao.Itens.Atualizar(entrada)
+		encontrados[entrada.Id] = true
+	}
+	for _, id := range ids {
+		if !encontrados[id] {
+			sugestao.Itens.Remover(id)
+		}
+	}
+}
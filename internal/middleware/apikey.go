@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+
+	"myapi/internal/config"
+)
+
+// ApiKey - Valida o header X-API-Key contra as chaves conhecidas. Apenas uma
+// chave conhecida identifica o cliente (rate limiting, cotas e
+// idempotência); as demais requisições são identificadas pelo IP.
+func ApiKey(keys map[string]bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if apiKey := r.Header.Get("X-API-Key"); keys[apiKey] {
+				r = r.WithContext(config.WithApiKey(r.Context(), apiKey))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package middleware
+
+import (
+	"net/http"
+
+	"myapi/internal/config"
+)
+
+// Autor - Identifica o autor das alterações pelo header X-Usuario
+func Autor(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := config.WithAutor(r.Context(), r.Header.Get("X-Usuario"))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"net/http"
+
+	"myapi/internal/config"
+)
+
+// Consistency - Define por requisição se as leituras podem ir para as réplicas.
+// Com "?consistency=strong" todas as leituras são feitas no primário.
+func Consistency(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		strong := r.URL.Query().Get("consistency") == "strong"
+		ctx := config.WithConsistency(r.Context(), strong)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
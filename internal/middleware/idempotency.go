@@ -0,0 +1,184 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"myapi/internal/config"
+	"myapi/internal/models"
+	"myapi/internal/repositories"
+)
+
+// Idempotency - Trata o header Idempotency-Key em POST e PATCH.
+// A primeira requisição com uma chave é executada e sua resposta é guardada
+// por TTL; as repetições recebem a mesma resposta sem reexecutar o handler.
+// A chave vale por cliente (API key, usuário ou IP). Reutilizá-la com outra
+// requisição (corpo ou query string) retorna 422. Uma repetição enquanto a
+// primeira execução não terminou espera por ela e devolve a sua resposta;
+// passado WaitTimeout, recebe 409.
+func Idempotency(cfg config.IdempotencyConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("Idempotency-Key")
+			if key == "" || (r.Method != http.MethodPost && r.Method != http.MethodPatch) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				http.Error(w, "Erro ao ler a requisição", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			hash := requestHash(r, body)
+			key = scopedKey(r, key)
+
+			repository := repositories.NewIdempotencyRepository(r.Context())
+			stored, err := reservar(r.Context(), repository, key, hash, cfg)
+			if err != nil && !errors.Is(err, errIdempotencyPending) {
+				http.Error(w, "Erro ao processar Idempotency-Key", http.StatusInternalServerError)
+				return
+			}
+			if stored != nil {
+				if stored.RequestHash != hash {
+					http.Error(w, "Idempotency-Key já utilizada com outra requisição", http.StatusUnprocessableEntity)
+					return
+				}
+				if errors.Is(err, errIdempotencyPending) {
+					w.Header().Set("Retry-After", "1")
+					http.Error(w, "Requisição com a mesma Idempotency-Key em andamento", http.StatusConflict)
+					return
+				}
+				w.Header().Set("Content-Type", stored.ContentType)
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(stored.StatusCode)
+				w.Write(stored.Body)
+				return
+			}
+
+			// Se o handler falhar (ou entrar em pânico) a reserva é desfeita
+			saved := false
+			defer func() {
+				if saved {
+					return
+				}
+				if err := repository.Release(key); err != nil {
+					log.Printf("Erro ao liberar Idempotency-Key %q: %v", key, err)
+				}
+			}()
+
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			// Erros de servidor não são guardados para que o cliente possa tentar de novo
+			if rec.status >= http.StatusInternalServerError {
+				return
+			}
+			now := time.Now()
+			err = repository.Save(&models.IdempotencyKey{
+				Key:         key,
+				RequestHash: hash,
+				StatusCode:  rec.status,
+				ContentType: w.Header().Get("Content-Type"),
+				Body:        rec.body.Bytes(),
+				CreatedAt:   now,
+				ExpiresAt:   now.Add(cfg.TTL),
+			})
+			if err != nil {
+				log.Printf("Erro ao gravar Idempotency-Key %q: %v", key, err)
+				return
+			}
+			saved = true
+		})
+	}
+}
+
+// errIdempotencyPending - A primeira execução não terminou dentro do tempo de espera
+var errIdempotencyPending = errors.New("requisição com a mesma Idempotency-Key em andamento")
+
+// reservar - Reserva a chave ou devolve a resposta guardada. Enquanto outra
+// execução da mesma chave estiver em andamento (com a mesma requisição),
+// consulta de novo a intervalos crescentes até ela terminar: se a resposta
+// foi guardada, ela é devolvida; se a reserva foi desfeita (erro 5xx), esta
+// requisição a obtém e executa. Desiste após WaitTimeout com
+// errIdempotencyPending e o registro em andamento.
+func reservar(ctx context.Context, repository *repositories.IdempotencyRepository, key, hash string, cfg config.IdempotencyConfig) (*models.IdempotencyKey, error) {
+	deadline := time.Now().Add(cfg.WaitTimeout)
+	intervalo := 50 * time.Millisecond
+	for {
+		now := time.Now()
+		stored, err := repository.Reserve(&models.IdempotencyKey{
+			Key:         key,
+			RequestHash: hash,
+			StatusCode:  models.IdempotencyPending,
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(cfg.PendingTimeout),
+		})
+		if err != nil || stored == nil || stored.StatusCode != models.IdempotencyPending || stored.RequestHash != hash {
+			return stored, err
+		}
+		if !now.Add(intervalo).Before(deadline) {
+			return stored, errIdempotencyPending
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(intervalo):
+		}
+		intervalo = min(intervalo*2, time.Second)
+	}
+}
+
+// PurgeIdempotencyKeys - Remove periodicamente as chaves expiradas
+func PurgeIdempotencyKeys(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := repositories.NewIdempotencyRepository(context.Background()).DeleteExpired(); err != nil {
+				log.Printf("Erro ao remover Idempotency-Keys expiradas: %v", err)
+			}
+		}
+	}()
+}
+
+// scopedKey - Chave armazenada: o mesmo valor enviado por clientes
+// diferentes identifica requisições diferentes
+func scopedKey(r *http.Request, key string) string {
+	h := sha256.New()
+	io.WriteString(h, clientIdentity(r)+"\n"+key)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// requestHash - Identifica a requisição pelo método, caminho, query string e corpo
+func requestHash(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder - Repassa a resposta ao cliente guardando uma cópia
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	rec.status = status
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.body.Write(b)
+	return rec.ResponseWriter.Write(b)
+}
@@ -0,0 +1,226 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"myapi/internal/config"
+	"myapi/internal/repositories"
+)
+
+// RateLimitStore - Armazena os token buckets e o consumo das cotas diárias
+type RateLimitStore interface {
+	// Take - Tenta consumir um token do bucket; retorna se foi permitido e os tokens restantes
+	Take(ctx context.Context, key string, limit config.RateLimit) (bool, float64, error)
+	// IncrementQuota - Soma uma requisição à cota do dia e retorna o total
+	IncrementQuota(ctx context.Context, apiKey string, day time.Time) (int64, error)
+}
+
+// NewRateLimitStore - Cria o store configurado em RATE_LIMIT_STORE
+func NewRateLimitStore(cfg config.RateLimitConfig) RateLimitStore {
+	if cfg.Store == "postgres" {
+		return newPostgresRateLimitStore(cfg, time.Minute)
+	}
+	return NewMemoryRateLimitStore()
+}
+
+// RateLimit - Limita as requisições por cliente (API key ou IP) com token
+// bucket, com limites separados para leituras, escritas e importação/exportação,
+// e aplica a cota diária de cada API key. Responde 429 com Retry-After quando
+// o limite é excedido.
+func RateLimit(cfg config.RateLimitConfig, store RateLimitStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !cfg.Enabled {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			group, limit := routeGroup(cfg, r)
+			apiKey := config.ApiKey(r.Context())
+			allowed, remaining, err := store.Take(r.Context(), group+":"+clientIdentity(r), limit)
+			if err != nil {
+				// Em caso de falha do store a requisição segue (fail open)
+				log.Printf("Erro no rate limiting: %v", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("RateLimit-Limit", strconv.Itoa(limit.Capacity))
+			w.Header().Set("RateLimit-Remaining", strconv.Itoa(int(math.Max(0, math.Floor(remaining)))))
+			w.Header().Set("RateLimit-Reset", strconv.Itoa(int(math.Ceil((float64(limit.Capacity)-remaining)/limit.PerSecond))))
+			if !allowed {
+				retryAfter := math.Ceil((1 - remaining) / limit.PerSecond)
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Max(1, retryAfter))))
+				http.Error(w, "Limite de requisições excedido", http.StatusTooManyRequests)
+				return
+			}
+
+			if apiKey != "" {
+				if quota := dailyQuota(cfg, apiKey); quota > 0 {
+					now := time.Now().UTC()
+					used, err := store.IncrementQuota(r.Context(), apiKey, now)
+					if err != nil {
+						log.Printf("Erro ao contabilizar cota da API key: %v", err)
+					} else if used > quota {
+						midnight := now.Truncate(24 * time.Hour).Add(24 * time.Hour)
+						w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(midnight.Sub(now).Seconds()))))
+						http.Error(w, "Cota diária da API key excedida", http.StatusTooManyRequests)
+						return
+					}
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// routeGroup - Classifica a requisição em leitura, escrita ou importação/exportação
+func routeGroup(cfg config.RateLimitConfig, r *http.Request) (string, config.RateLimit) {
+	if strings.Contains(r.URL.Path, "/import") || strings.Contains(r.URL.Path, "/export") {
+		return "bulk", cfg.Bulk
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return "reads", cfg.Reads
+	}
+	return "writes", cfg.Writes
+}
+
+func dailyQuota(cfg config.RateLimitConfig, apiKey string) int64 {
+	if quota, ok := cfg.QuotaOverrides[apiKey]; ok {
+		return quota
+	}
+	return cfg.DailyQuota
+}
+
+// clientIdentity - Identifica o cliente pela API key validada pelo
+// middleware ApiKey, pelo usuário (X-Usuario, resolvido pelo middleware
+// Autor) ou, sem nenhum dos dois, pelo IP. Uma chave qualquer no header não
+// basta: trocá-la a cada requisição daria um bucket novo a cada vez. O
+// usuário é confiável como na autoria das alterações: quem define X-Usuario
+// é o gateway autenticado à frente da API.
+func clientIdentity(r *http.Request) string {
+	if apiKey := config.ApiKey(r.Context()); apiKey != "" {
+		return "key:" + apiKey
+	}
+	if autor := config.Autor(r.Context()); autor != "" {
+		return "user:" + autor
+	}
+	return "ip:" + clientIP(r)
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// MemoryRateLimitStore - Store em memória, para uma única instância
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+	quotas  map[string]int64
+	day     string
+}
+
+type memoryBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	limit     config.RateLimit
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	s := &MemoryRateLimitStore{
+		buckets: make(map[string]*memoryBucket),
+		quotas:  make(map[string]int64),
+	}
+	go s.sweep(time.Minute)
+	return s
+}
+
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, limit config.RateLimit) (bool, float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{tokens: float64(limit.Capacity), updatedAt: now, limit: limit}
+		s.buckets[key] = b
+	}
+	b.tokens = math.Min(float64(limit.Capacity), b.tokens+now.Sub(b.updatedAt).Seconds()*limit.PerSecond)
+	b.updatedAt = now
+	if b.tokens < 1 {
+		return false, b.tokens, nil
+	}
+	b.tokens--
+	return true, b.tokens, nil
+}
+
+func (s *MemoryRateLimitStore) IncrementQuota(ctx context.Context, apiKey string, day time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if d := day.Format("2006-01-02"); d != s.day {
+		s.day = d
+		s.quotas = make(map[string]int64)
+	}
+	s.quotas[apiKey]++
+	return s.quotas[apiKey], nil
+}
+
+// sweep - Remove os buckets que já estariam cheios, para o mapa não crescer
+// indefinidamente (um bucket removido equivale a um bucket cheio)
+func (s *MemoryRateLimitStore) sweep(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.mu.Lock()
+		for key, b := range s.buckets {
+			if b.tokens+time.Since(b.updatedAt).Seconds()*b.limit.PerSecond >= float64(b.limit.Capacity) {
+				delete(s.buckets, key)
+			}
+		}
+		s.mu.Unlock()
+	}
+}
+
+// postgresRateLimitStore - Store no Postgres, compartilhado entre réplicas
+type postgresRateLimitStore struct{}
+
+// newPostgresRateLimitStore - Cria o store e inicia a remoção periódica dos
+// buckets parados há mais tempo do que o maior reabastecimento completo
+// (equivalentes a buckets cheios) e das cotas de dias anteriores
+func newPostgresRateLimitStore(cfg config.RateLimitConfig, interval time.Duration) postgresRateLimitStore {
+	var idle time.Duration
+	for _, limit := range []config.RateLimit{cfg.Reads, cfg.Writes, cfg.Bulk} {
+		idle = max(idle, time.Duration(float64(limit.Capacity)/limit.PerSecond*float64(time.Second)))
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			repository := repositories.NewRateLimitRepository(context.Background())
+			if err := repository.DeleteStale(time.Now().Add(-idle)); err != nil {
+				log.Printf("Erro ao remover buckets de rate limiting: %v", err)
+			}
+		}
+	}()
+	return postgresRateLimitStore{}
+}
+
+func (postgresRateLimitStore) Take(ctx context.Context, key string, limit config.RateLimit) (bool, float64, error) {
+	return repositories.NewRateLimitRepository(ctx).Take(key, limit.Capacity, limit.PerSecond)
+}
+
+func (postgresRateLimitStore) IncrementQuota(ctx context.Context, apiKey string, day time.Time) (int64, error) {
+	return repositories.NewRateLimitRepository(ctx).IncrementQuota(apiKey, day)
+}
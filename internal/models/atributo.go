@@ -0,0 +1,83 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// Tipos de atributo de item
+const (
+	AtributoTexto    = "texto"
+	AtributoNumero   = "numero"
+	AtributoEnum     = "enum"
+	AtributoBooleano = "booleano"
+)
+
+// DefinicaoAtributo - Atributo que os itens de uma categoria podem ter.
+// Unidade vale para números (o valor é gravado nela); Opcoes lista os
+// valores aceitos em enums.
+type DefinicaoAtributo struct {
+	Nome        string   `json:"nome"`
+	Tipo        string   `json:"tipo"`
+	Unidade     string   `json:"unidade,omitempty"`
+	Opcoes      []string `json:"opcoes,omitempty"`
+	Obrigatorio bool     `json:"obrigatorio"`
+}
+
+// EsquemaAtributos - Atributos definidos por uma categoria, gravados em JSONB
+type EsquemaAtributos []DefinicaoAtributo
+
+// Scan - Lê a coluna jsonb
+func (e *EsquemaAtributos) Scan(src any) error {
+	return scanJSON(src, e)
+}
+
+// Value - Grava como JSON; sem atributos, uma lista vazia
+func (e EsquemaAtributos) Value() (driver.Value, error) {
+	if e == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(e)
+	return string(b), err
+}
+
+// GormDataType - Tipo da coluna quando a tag não define um
+func (EsquemaAtributos) GormDataType() string {
+	return "jsonb"
+}
+
+// Atributos - Valores dos atributos de um item (texto, número ou booleano),
+// gravados em JSONB
+type Atributos map[string]any
+
+// Scan - Lê a coluna jsonb
+func (a *Atributos) Scan(src any) error {
+	return scanJSON(src, a)
+}
+
+// Value - Grava como JSON; sem atributos, um objeto vazio
+func (a Atributos) Value() (driver.Value, error) {
+	if a == nil {
+		return "{}", nil
+	}
+	b, err := json.Marshal(a)
+	return string(b), err
+}
+
+// GormDataType - Tipo da coluna quando a tag não define um
+func (Atributos) GormDataType() string {
+	return "jsonb"
+}
+
+func scanJSON(src, dst any) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, dst)
+	case string:
+		return json.Unmarshal([]byte(v), dst)
+	}
+	return fmt.Errorf("models: não é possível ler %T como JSON", src)
+}
@@ -1,8 +1,30 @@
 package models
 
+// Categoria - Categoria de itens. ParentId nulo indica uma categoria raiz;
+// a hierarquia é consultada pela tabela de caminhos (CategoriaCaminho).
+// Atributos define os atributos dos itens da categoria e das subcategorias.
 type Categoria struct {
-	Id        uint   `gorm:"primaryKey" json:"id"`
-	Nome      string `json:"nome"`
-	Codigo    string `gorm:"unique" json:"codigo"`
-	Descricao string `json:"descricao"`
+	Id        uint             `gorm:"primaryKey" json:"id"`
+	Nome      string           `json:"nome"`
+	Codigo    string           `gorm:"unique" json:"codigo"`
+	Descricao string           `json:"descricao"`
+	ParentId  *uint            `gorm:"index" json:"parent_id"`
+	Atributos EsquemaAtributos `gorm:"type:jsonb;not null;default:'[]'" json:"atributos"`
+}
+
+// CategoriaCaminho - Tabela de fechamento da árvore de categorias: uma linha
+// para cada par ancestral–descendente, incluindo a própria categoria com
+// profundidade 0. Permite buscar subárvores e caminhos sem recursão.
+type CategoriaCaminho struct {
+	AncestralId   uint `gorm:"primaryKey" json:"ancestral_id"`
+	DescendenteId uint `gorm:"primaryKey;index" json:"descendente_id"`
+	Profundidade  int  `json:"profundidade"`
+}
+
+func (CategoriaCaminho) TableName() string { return "categorias_caminhos" }
+
+// CategoriaNo - Categoria com as subcategorias, na montagem da árvore
+type CategoriaNo struct {
+	Categoria
+	Filhas []*CategoriaNo `json:"filhas"`
 }
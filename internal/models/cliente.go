@@ -0,0 +1,11 @@
+package models
+
+type Cliente struct {
+	Id        uint   `gorm:"primaryKey" json:"id"`
+	Nome      string `json:"nome"`
+	Documento string `gorm:"unique" json:"documento"`
+	Email     string `json:"email"`
+	Telefone  string `json:"telefone"`
+	// Tabela de preços do cliente (nula = preço padrão do item)
+	TabelaPrecoId *uint `gorm:"index" json:"tabela_preco_id"`
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"time"
+
+	"myapi/internal/money"
+)
+
+// Status de um pedido de compra
+const (
+	CompraRascunho             = "rascunho"
+	CompraEnviado              = "enviado"
+	CompraParcialmenteRecebido = "parcialmente_recebido"
+	CompraRecebido             = "recebido"
+	CompraCancelado            = "cancelado"
+)
+
+// transicoesCompra - Status para os quais cada status pode mudar
+var transicoesCompra = map[string][]string{
+	CompraRascunho:             {CompraEnviado, CompraCancelado},
+	CompraEnviado:              {CompraParcialmenteRecebido, CompraRecebido, CompraCancelado},
+	CompraParcialmenteRecebido: {CompraParcialmenteRecebido, CompraRecebido, CompraCancelado},
+}
+
+// PedidoCompra - Pedido de compra a um fornecedor. As entradas de estoque
+// são feitas no DepositoId à medida que as linhas são recebidas.
+type PedidoCompra struct {
+	Id           uint               `gorm:"primaryKey" json:"id"`
+	FornecedorId uint               `gorm:"index" json:"fornecedor_id"`
+	DepositoId   uint               `json:"deposito_id"`
+	Status       string             `gorm:"index" json:"status"`
+	Observacao   string             `json:"observacao"`
+	CriadoEm     time.Time          `gorm:"autoCreateTime" json:"criado_em"`
+	AtualizadoEm time.Time          `gorm:"autoUpdateTime" json:"atualizado_em"`
+	Fornecedor   *Fornecedor        `gorm:"foreignKey:FornecedorId" json:"fornecedor,omitempty"`
+	Itens        []PedidoCompraItem `gorm:"foreignKey:PedidoCompraId" json:"itens"`
+}
+
+// PodeMudarPara - Indica se a máquina de status permite a transição
+func (p *PedidoCompra) PodeMudarPara(status string) bool {
+	for _, s := range transicoesCompra[p.Status] {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// PedidoCompraItem - Linha do pedido de compra
+type PedidoCompraItem struct {
+	Id                 uint        `gorm:"primaryKey" json:"id"`
+	PedidoCompraId     uint        `gorm:"index" json:"pedido_compra_id"`
+	ItemId             uint        `json:"item_id"`
+	Quantidade         int         `json:"quantidade"`
+	QuantidadeRecebida int         `json:"quantidade_recebida"`
+	CustoUnitario      money.Money `gorm:"type:numeric(18,4)" json:"custo_unitario"`
+}
+
+// RecebimentoCompra - Recebimento (total ou parcial) de uma linha do pedido,
+// com o custo unitário efetivamente cobrado
+type RecebimentoCompra struct {
+	Id                 uint        `gorm:"primaryKey" json:"id"`
+	PedidoCompraItemId uint        `gorm:"index" json:"pedido_compra_item_id"`
+	MovimentacaoId     uint        `json:"movimentacao_id"`
+	Quantidade         int         `json:"quantidade"`
+	CustoUnitario      money.Money `gorm:"type:numeric(18,4)" json:"custo_unitario"`
+	CriadoEm           time.Time   `gorm:"autoCreateTime" json:"criado_em"`
+}
+
+func (PedidoCompra) TableName() string      { return "pedidos_compra" }
+func (PedidoCompraItem) TableName() string  { return "pedidos_compra_itens" }
+func (RecebimentoCompra) TableName() string { return "recebimentos_compra" }
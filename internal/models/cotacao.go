@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"myapi/internal/decimal"
+)
+
+// Cotacao - Taxa de câmbio de uma moeda a partir de uma data: 1 unidade da
+// moeda vale Taxa na moeda padrão. As cotações são mantidas manualmente (API
+// ou CSV); uma data sem cotação usa a mais recente anterior a ela.
+type Cotacao struct {
+	Id       uint            `gorm:"primaryKey" json:"id"`
+	Moeda    string          `gorm:"size:3;uniqueIndex:idx_cotacao_moeda_data" json:"moeda"`
+	Data     time.Time       `gorm:"type:date;uniqueIndex:idx_cotacao_moeda_data" json:"data"`
+	Taxa     decimal.Decimal `gorm:"type:numeric(18,8)" json:"taxa"`
+	CriadoEm time.Time       `gorm:"autoCreateTime" json:"criado_em"`
+}
+
+func (Cotacao) TableName() string { return "cotacoes" }
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+
+	"myapi/internal/money"
+)
+
+// Métodos de custeio do estoque
+const (
+	CustoMedio = "media"
+	CustoFifo  = "fifo"
+)
+
+// CamadaCusto - Entrada de estoque de um item em um depósito, consumida em
+// ordem de chegada pelas saídas (FIFO). Guarda o valor total, e não o
+// unitário, para que o consumo da última unidade zere a camada sem sobras
+// de arredondamento.
+type CamadaCusto struct {
+	Id             uint        `gorm:"primaryKey" json:"id"`
+	ItemId         uint        `gorm:"index:idx_camada_saldo" json:"item_id"`
+	DepositoId     uint        `gorm:"index:idx_camada_saldo" json:"deposito_id"`
+	MovimentacaoId *uint       `json:"movimentacao_id"`
+	Quantidade     int         `json:"quantidade"`
+	Restante       int         `json:"restante"`
+	Valor          money.Money `gorm:"type:numeric(18,2);not null;default:0" json:"valor"`
+	ValorRestante  money.Money `gorm:"type:numeric(18,2);not null;default:0" json:"valor_restante"`
+	CriadoEm       time.Time   `gorm:"autoCreateTime" json:"criado_em"`
+}
+
+func (CamadaCusto) TableName() string { return "camadas_custo" }
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"myapi/internal/money"
+)
+
+type Deposito struct {
+	Id        uint   `gorm:"primaryKey" json:"id"`
+	Nome      string `json:"nome"`
+	Codigo    string `gorm:"unique" json:"codigo"`
+	Descricao string `json:"descricao"`
+}
+
+// EstoqueDeposito - Saldo de um item em um depósito. Quantidade é o estoque
+// físico; Reservado é a parte comprometida com pedidos de venda. Valor é o
+// valor do estoque pelo custo médio ponderado.
+type EstoqueDeposito struct {
+	ItemId     uint        `gorm:"primaryKey" json:"item_id"`
+	DepositoId uint        `gorm:"primaryKey" json:"deposito_id"`
+	Quantidade int         `json:"quantidade"`
+	Reservado  int         `gorm:"not null;default:0" json:"reservado"`
+	Valor      money.Money `gorm:"type:numeric(18,2);not null;default:0" json:"valor"`
+	Deposito   *Deposito   `gorm:"foreignKey:DepositoId" json:"deposito,omitempty"`
+}
+
+// Disponivel - Quantidade que pode ser vendida ou retirada
+func (e EstoqueDeposito) Disponivel() int {
+	return e.Quantidade - e.Reservado
+}
+
+// Tipos de movimentação de estoque
+const (
+	MovimentacaoEntrada       = "entrada"
+	MovimentacaoSaida         = "saida"
+	MovimentacaoAjuste        = "ajuste"
+	MovimentacaoTransferencia = "transferencia"
+)
+
+// Movimentacao - Registro de cada variação de estoque. Quantidade é positiva
+// nas entradas e negativa nas saídas; uma transferência gera uma saída no
+// depósito de origem e uma entrada no de destino, com a mesma Referencia.
+// LocalizacaoId, quando informada, indica a posição de onde o item saiu ou
+// onde foi guardado. LoteId é o lote informado na movimentação; Lotes traz
+// quanto foi lançado em cada lote (numa saída sem lote, os consumidos por FEFO).
+// Series lista os números de série que entraram ou saíram (itens serializados).
+// CustoUnitario é informado nas entradas (ou calculado) e Valor é o custo
+// total da movimentação, negativo nas saídas, pelo método de custeio.
+type Movimentacao struct {
+	Id            uint               `gorm:"primaryKey" json:"id"`
+	ItemId        uint               `gorm:"index" json:"item_id"`
+	DepositoId    uint               `gorm:"index" json:"deposito_id"`
+	LocalizacaoId *uint              `json:"localizacao_id,omitempty"`
+	LoteId        *uint              `json:"lote_id,omitempty"`
+	Tipo          string             `json:"tipo"`
+	Quantidade    int                `json:"quantidade"`
+	CustoUnitario *money.Money       `gorm:"type:numeric(18,4)" json:"custo_unitario,omitempty"`
+	Valor         money.Money        `gorm:"type:numeric(18,2);not null;default:0" json:"valor"`
+	Referencia    string             `json:"referencia"`
+	CriadoEm      time.Time          `gorm:"autoCreateTime" json:"criado_em"`
+	Lotes         []MovimentacaoLote `gorm:"foreignKey:MovimentacaoId" json:"lotes,omitempty"`
+	Series        []string           `gorm:"-" json:"series,omitempty"`
+}
+
+func (Movimentacao) TableName() string { return "movimentacoes" }
@@ -0,0 +1,28 @@
+package models
+
+import "myapi/internal/decimal"
+
+// DadosFiscais - Classificação e tributação padrão do item. Alíquotas em
+// percentual; AliquotaIcms nula usa a alíquota interna da UF de origem.
+// CFOPs vazios usam 5102 (dentro da UF) e 6102/6108 (para outra UF).
+type DadosFiscais struct {
+	Ncm               string           `gorm:"size:8" json:"ncm"`
+	Cest              string           `gorm:"size:7" json:"cest"`
+	CfopEstadual      string           `gorm:"size:4" json:"cfop_estadual"`
+	CfopInterestadual string           `gorm:"size:4" json:"cfop_interestadual"`
+	Origem            int              `gorm:"not null;default:0" json:"origem"`
+	AliquotaIcms      *decimal.Decimal `gorm:"type:numeric(7,4)" json:"aliquota_icms"`
+	AliquotaIpi       *decimal.Decimal `gorm:"type:numeric(7,4)" json:"aliquota_ipi"`
+	AliquotaPis       *decimal.Decimal `gorm:"type:numeric(7,4)" json:"aliquota_pis"`
+	AliquotaCofins    *decimal.Decimal `gorm:"type:numeric(7,4)" json:"aliquota_cofins"`
+}
+
+// AliquotaIcms - Alíquota de ICMS entre duas UFs; com origem igual ao
+// destino, a alíquota interna da UF. Substitui a tabela padrão do sistema.
+type AliquotaIcms struct {
+	UfOrigem  string          `gorm:"primaryKey;size:2" json:"uf_origem"`
+	UfDestino string          `gorm:"primaryKey;size:2" json:"uf_destino"`
+	Aliquota  decimal.Decimal `gorm:"type:numeric(7,4)" json:"aliquota"`
+}
+
+func (AliquotaIcms) TableName() string { return "aliquotas_icms" }
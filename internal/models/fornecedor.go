@@ -0,0 +1,12 @@
+package models
+
+type Fornecedor struct {
+	Id       uint   `gorm:"primaryKey" json:"id"`
+	Nome     string `json:"nome"`
+	Codigo   string `gorm:"unique" json:"codigo"`
+	Cnpj     string `json:"cnpj"`
+	Email    string `json:"email"`
+	Telefone string `json:"telefone"`
+}
+
+func (Fornecedor) TableName() string { return "fornecedores" }
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// IdempotencyKey - Resposta armazenada para um Idempotency-Key já processado
+type IdempotencyKey struct {
+	Key         string    `gorm:"primaryKey;size:255" json:"key"`
+	RequestHash string    `gorm:"size:64;not null" json:"request_hash"`
+	StatusCode  int       `json:"status_code"` // IdempotencyPending durante a primeira execução
+	ContentType string    `json:"content_type"`
+	Body        []byte    `json:"-"`
+	CreatedAt   time.Time `json:"created_at"`
+	ExpiresAt   time.Time `gorm:"index" json:"expires_at"`
+}
+
+// IdempotencyPending - Status da chave reservada cuja primeira execução
+// ainda não terminou
+const IdempotencyPending = 0
@@ -1,10 +1,24 @@
 package models
 
+import "myapi/internal/money"
+
 type Iten struct {
-	Id         uint    `gorm:"primaryKey" json:"id"`
-	Nome       string  `json:"nome"`
-	Codigo     string  `gorm:"unique" json:"codigo"`
-	Descricao  string  `json:"descricao"`
-	Preco      float64 `json:"preco"`
-	Quantidade int     `json:"quantidade"`
+	Id           uint         `gorm:"primaryKey" json:"id"`
+	Nome         string       `json:"nome"`
+	Codigo       string       `gorm:"unique" json:"codigo"`
+	Ean          string       `gorm:"size:14;index" json:"ean"`
+	Descricao    string       `json:"descricao"`
+	Preco        money.Money  `gorm:"type:numeric(10,2)" json:"preco"`
+	Moeda        string       `gorm:"size:3" json:"moeda"`
+	Quantidade   int          `json:"quantidade"`
+	CategoriaId  *uint        `gorm:"index" json:"categoria_id"`
+	ControlaLote bool         `gorm:"not null;default:false" json:"controla_lote"`
+	Serializado  bool         `gorm:"not null;default:false" json:"serializado"`
+	Fiscal       DadosFiscais `gorm:"embedded" json:"fiscal"`
+	Atributos    Atributos    `gorm:"type:jsonb;not null;default:'{}';index:,type:gin" json:"atributos"`
+	// Dimensões de um produto com variantes; nas variantes, o produto
+	// (ProdutoId) e o valor de cada dimensão (Variacao)
+	Dimensoes DimensoesVariacao `gorm:"type:jsonb;not null;default:'[]'" json:"dimensoes,omitempty"`
+	ProdutoId *uint             `gorm:"uniqueIndex:idx_itens_variacao" json:"produto_id,omitempty"`
+	Variacao  Variacao          `gorm:"type:jsonb;uniqueIndex:idx_itens_variacao" json:"variacao,omitempty"`
 }
@@ -0,0 +1,13 @@
+package models
+
+// ComponenteKit - Quantidade de um item (que pode ser outro kit) em cada
+// unidade do kit. Um item com componentes é um kit: não tem estoque próprio
+// e a disponibilidade vem dos componentes.
+type ComponenteKit struct {
+	KitId        uint  `gorm:"primaryKey" json:"kit_id"`
+	ComponenteId uint  `gorm:"primaryKey;index" json:"componente_id"`
+	Quantidade   int   `json:"quantidade"`
+	Componente   *Iten `gorm:"foreignKey:ComponenteId" json:"componente,omitempty"`
+}
+
+func (ComponenteKit) TableName() string { return "componentes_kit" }
@@ -0,0 +1,39 @@
+package models
+
+// Tipos de localização dentro de um depósito, do nível mais alto ao mais baixo
+const (
+	LocalizacaoCorredor   = "corredor"
+	LocalizacaoPrateleira = "prateleira"
+	LocalizacaoPosicao    = "posicao"
+)
+
+// Localizacao - Nó da hierarquia corredor > prateleira > posição de um
+// depósito. O estoque fica apenas nas posições (bins). Ordem define a
+// sequência de percurso entre os nós de um mesmo pai.
+type Localizacao struct {
+	Id         uint          `gorm:"primaryKey" json:"id"`
+	DepositoId uint          `gorm:"uniqueIndex:idx_localizacao_codigo" json:"deposito_id"`
+	ParentId   *uint         `gorm:"index" json:"parent_id"`
+	Tipo       string        `json:"tipo"`
+	Codigo     string        `gorm:"uniqueIndex:idx_localizacao_codigo" json:"codigo"`
+	Ordem      int           `json:"ordem"`
+	Filhos     []Localizacao `gorm:"-" json:"filhos,omitempty"`
+}
+
+// EstoqueLocalizacao - Saldo de um item em uma posição
+type EstoqueLocalizacao struct {
+	ItemId        uint `gorm:"primaryKey" json:"item_id"`
+	LocalizacaoId uint `gorm:"primaryKey" json:"localizacao_id"`
+	Quantidade    int  `json:"quantidade"`
+}
+
+// RegraArmazenagem - Posição fixa de um item em um depósito. Sem regra, a
+// armazenagem usa a posição livre mais próxima no percurso.
+type RegraArmazenagem struct {
+	ItemId        uint `gorm:"primaryKey" json:"item_id"`
+	DepositoId    uint `gorm:"primaryKey" json:"deposito_id"`
+	LocalizacaoId uint `json:"localizacao_id"`
+}
+
+func (Localizacao) TableName() string        { return "localizacoes" }
+func (EstoqueLocalizacao) TableName() string { return "estoque_localizacoes" }
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// Lote - Lote de fabricação de um item com controle de lote. Validade nula
+// indica lote que não vence.
+type Lote struct {
+	Id         uint          `gorm:"primaryKey" json:"id"`
+	ItemId     uint          `gorm:"uniqueIndex:idx_lote_numero" json:"item_id"`
+	Numero     string        `gorm:"uniqueIndex:idx_lote_numero" json:"numero"`
+	Fabricacao *time.Time    `gorm:"type:date" json:"fabricacao,omitempty"`
+	Validade   *time.Time    `gorm:"type:date;index" json:"validade,omitempty"`
+	CriadoEm   time.Time     `gorm:"autoCreateTime" json:"criado_em"`
+	Saldos     []EstoqueLote `gorm:"foreignKey:LoteId" json:"saldos,omitempty"`
+}
+
+// Vencido - Indica se o lote já passou da validade na data informada
+func (l Lote) Vencido(em time.Time) bool {
+	if l.Validade == nil {
+		return false
+	}
+	hoje := time.Date(em.Year(), em.Month(), em.Day(), 0, 0, 0, 0, time.UTC)
+	return l.Validade.Before(hoje)
+}
+
+// EstoqueLote - Saldo de um lote em um depósito. A soma dos lotes pode ser
+// menor que o saldo do depósito; a diferença é estoque sem lote.
+type EstoqueLote struct {
+	LoteId     uint  `gorm:"primaryKey" json:"lote_id"`
+	DepositoId uint  `gorm:"primaryKey" json:"deposito_id"`
+	ItemId     uint  `gorm:"index" json:"item_id"`
+	Quantidade int   `json:"quantidade"`
+	Lote       *Lote `gorm:"foreignKey:LoteId" json:"lote,omitempty"`
+}
+
+// MovimentacaoLote - Quantidade de cada lote afetada por uma movimentação
+type MovimentacaoLote struct {
+	Id             uint `gorm:"primaryKey" json:"-"`
+	MovimentacaoId uint `gorm:"index" json:"-"`
+	LoteId         uint `json:"lote_id"`
+	Quantidade     int  `json:"quantidade"`
+}
+
+func (EstoqueLote) TableName() string      { return "estoque_lotes" }
+func (MovimentacaoLote) TableName() string { return "movimentacoes_lotes" }
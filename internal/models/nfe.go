@@ -0,0 +1,98 @@
+package models
+
+import (
+	"time"
+
+	"myapi/internal/decimal"
+	"myapi/internal/money"
+)
+
+// Status de uma importação de NF-e
+const (
+	NfePendente   = "pendente"
+	NfeConfirmada = "confirmada"
+)
+
+// Formas de correspondência entre a linha da nota e um item: encontrado na
+// importação ou definido na confirmação
+const (
+	CorrespondenciaFornecedor = "codigo_fornecedor"
+	CorrespondenciaEan        = "ean"
+	CorrespondenciaCodigo     = "codigo"
+	CorrespondenciaManual     = "manual"
+	CorrespondenciaCadastro   = "cadastro"
+)
+
+// ImportacaoNfe - NF-e de entrada carregada do XML. Fica pendente até a
+// confirmação, que cria os itens propostos e lança as entradas de estoque
+// no DepositoId; cada nota (chave de acesso) é importada uma única vez.
+type ImportacaoNfe struct {
+	Id           uint                 `gorm:"primaryKey" json:"id"`
+	Chave        string               `gorm:"size:44;unique" json:"chave"`
+	Numero       string               `json:"numero"`
+	Serie        string               `json:"serie"`
+	EmitidaEm    time.Time            `json:"emitida_em"`
+	EmitenteCnpj string               `gorm:"size:14" json:"emitente_cnpj"`
+	EmitenteNome string               `json:"emitente_nome"`
+	FornecedorId *uint                `gorm:"index" json:"fornecedor_id"`
+	DepositoId   *uint                `json:"deposito_id"`
+	Status       string               `gorm:"index" json:"status"`
+	ValorTotal   money.Money          `gorm:"type:numeric(18,2)" json:"valor_total"`
+	Autor        string               `json:"autor"`
+	CriadoEm     time.Time            `gorm:"autoCreateTime" json:"criado_em"`
+	ConfirmadoEm *time.Time           `json:"confirmado_em"`
+	Linhas       []ImportacaoNfeLinha `gorm:"foreignKey:ImportacaoNfeId" json:"linhas"`
+}
+
+// ImportacaoNfeLinha - Produto de uma linha da nota. ItemId é o item
+// encontrado (pela Correspondencia) ou, após a confirmação, o item criado.
+// Proposta traz, nas linhas sem item, o cadastro sugerido a partir da nota.
+// Lotes são os lotes informados pelo emitente (grupo rastro).
+type ImportacaoNfeLinha struct {
+	Id              uint                `gorm:"primaryKey" json:"id"`
+	ImportacaoNfeId uint                `gorm:"index" json:"importacao_nfe_id"`
+	Numero          int                 `json:"numero"`
+	Codigo          string              `json:"codigo"`
+	Ean             string              `gorm:"size:14" json:"ean"`
+	Descricao       string              `json:"descricao"`
+	Ncm             string              `gorm:"size:8" json:"ncm"`
+	Cest            string              `gorm:"size:7" json:"cest"`
+	Cfop            string              `gorm:"size:4" json:"cfop"`
+	Origem          int                 `json:"origem"`
+	Unidade         string              `json:"unidade"`
+	Quantidade      decimal.Decimal     `gorm:"type:numeric(15,4)" json:"quantidade"`
+	CustoUnitario   money.Money         `gorm:"type:numeric(21,10)" json:"custo_unitario"`
+	ValorTotal      money.Money         `gorm:"type:numeric(18,2)" json:"valor_total"`
+	ItemId          *uint               `json:"item_id"`
+	Correspondencia string              `json:"correspondencia,omitempty"`
+	Ignorada        bool                `gorm:"not null;default:false" json:"ignorada"`
+	MovimentacaoId  *uint               `json:"movimentacao_id,omitempty"`
+	Proposta        *Iten               `gorm:"-" json:"proposta,omitempty"`
+	Lotes           []ImportacaoNfeLote `gorm:"foreignKey:ImportacaoNfeLinhaId" json:"lotes,omitempty"`
+}
+
+// ImportacaoNfeLote - Lote de parte da quantidade de uma linha da nota.
+// LoteId é o lote do item usado (ou criado) na confirmação.
+type ImportacaoNfeLote struct {
+	Id                   uint            `gorm:"primaryKey" json:"id"`
+	ImportacaoNfeLinhaId uint            `gorm:"index" json:"-"`
+	Numero               string          `json:"numero"`
+	Quantidade           decimal.Decimal `gorm:"type:numeric(15,4)" json:"quantidade"`
+	Fabricacao           time.Time       `gorm:"type:date" json:"fabricacao"`
+	Validade             time.Time       `gorm:"type:date" json:"validade"`
+	LoteId               *uint           `json:"lote_id,omitempty"`
+	MovimentacaoId       *uint           `json:"movimentacao_id,omitempty"`
+}
+
+// ItemFornecedor - Código com que o fornecedor identifica um item nas suas
+// notas (cProd); gravado na confirmação das importações
+type ItemFornecedor struct {
+	FornecedorId uint   `gorm:"primaryKey" json:"fornecedor_id"`
+	Codigo       string `gorm:"primaryKey;size:60" json:"codigo"`
+	ItemId       uint   `gorm:"index" json:"item_id"`
+}
+
+func (ImportacaoNfe) TableName() string      { return "importacoes_nfe" }
+func (ImportacaoNfeLinha) TableName() string { return "importacoes_nfe_linhas" }
+func (ImportacaoNfeLote) TableName() string  { return "importacoes_nfe_lotes" }
+func (ItemFornecedor) TableName() string     { return "itens_fornecedor" }
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"myapi/internal/decimal"
+	"myapi/internal/money"
+)
+
+// TabelaPreco - Lista de preços nomeada (ex: VAREJO, ATACADO). Os clientes
+// vinculados a uma tabela compram pelos preços e regras dela.
+type TabelaPreco struct {
+	Id        uint   `gorm:"primaryKey" json:"id"`
+	Codigo    string `gorm:"unique" json:"codigo"`
+	Nome      string `json:"nome"`
+	Descricao string `json:"descricao"`
+}
+
+// PrecoTabela - Preço de um item em uma tabela, no lugar de Iten.Preco.
+// Moeda é a do preço (padrão: a do item); a precificação converte para a
+// moeda do item.
+type PrecoTabela struct {
+	TabelaPrecoId uint        `gorm:"primaryKey" json:"tabela_preco_id"`
+	ItemId        uint        `gorm:"primaryKey;index" json:"item_id"`
+	Preco         money.Money `gorm:"type:numeric(10,2)" json:"preco"`
+	Moeda         string      `gorm:"size:3" json:"moeda"`
+}
+
+// RegraPreco - Desconto percentual ou preço fixo aplicado a um item, aos
+// itens de uma categoria (pelo código) ou, sem nenhum dos dois, a todos os
+// itens. QuantidadeMinima define faixas por quantidade; VigenteDe/VigenteAte
+// limitam promoções no tempo; TabelaPrecoId restringe a regra a uma tabela.
+// Moeda é a do preço fixo, convertido para a moeda de cada item.
+type RegraPreco struct {
+	Id               uint             `gorm:"primaryKey" json:"id"`
+	Nome             string           `json:"nome"`
+	TabelaPrecoId    *uint            `gorm:"index" json:"tabela_preco_id"`
+	ItemId           *uint            `gorm:"index" json:"item_id"`
+	CategoriaCodigo  string           `gorm:"index" json:"categoria_codigo"`
+	QuantidadeMinima int              `gorm:"not null;default:1" json:"quantidade_minima"`
+	Percentual       *decimal.Decimal `gorm:"type:numeric(7,4)" json:"percentual"`
+	Preco            *money.Money     `gorm:"type:numeric(10,2)" json:"preco"`
+	Moeda            string           `gorm:"size:3" json:"moeda,omitempty"`
+	VigenteDe        *time.Time       `json:"vigente_de"`
+	VigenteAte       *time.Time       `json:"vigente_ate"`
+}
+
+func (TabelaPreco) TableName() string { return "tabelas_preco" }
+func (PrecoTabela) TableName() string { return "precos_tabela" }
+func (RegraPreco) TableName() string  { return "regras_preco" }
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"myapi/internal/money"
+)
+
+// HistoricoPreco - Preço de um item em um período. VigenteAte nulo indica o
+// último preço da linha do tempo. Preços futuros ficam com Aplicado falso até
+// a rotina de agendamento copiá-los para Iten.Preco.
+type HistoricoPreco struct {
+	Id         uint        `gorm:"primaryKey" json:"id"`
+	ItemId     uint        `gorm:"index:idx_historico_preco_item" json:"item_id"`
+	Preco      money.Money `gorm:"type:numeric(10,2)" json:"preco"`
+	Moeda      string      `gorm:"size:3" json:"moeda"`
+	VigenteDe  time.Time   `gorm:"index:idx_historico_preco_item" json:"vigente_de"`
+	VigenteAte *time.Time  `json:"vigente_ate"`
+	Autor      string      `json:"autor"`
+	Aplicado   bool        `gorm:"not null;default:false;index" json:"aplicado"`
+	CriadoEm   time.Time   `gorm:"autoCreateTime" json:"criado_em"`
+}
+
+func (HistoricoPreco) TableName() string { return "historico_precos" }
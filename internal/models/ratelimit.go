@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// RateLimitBucket - Token bucket compartilhado entre instâncias
+type RateLimitBucket struct {
+	Key       string    `gorm:"primaryKey;size:255" json:"key"`
+	Tokens    float64   `json:"tokens"`
+	Allowed   bool      `json:"allowed"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ApiKeyQuota - Consumo diário de uma API key
+type ApiKeyQuota struct {
+	ApiKey string    `gorm:"primaryKey;size:255" json:"api_key"`
+	Dia    time.Time `gorm:"primaryKey;type:date" json:"dia"`
+	Total  int64     `json:"total"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// ParametroReposicao - Níveis de estoque de um item e o fornecedor de
+// reposição. Com PontoPedido zero, o ponto de pedido é calculado como
+// Minimo + consumo médio diário * LeadTimeDias.
+type ParametroReposicao struct {
+	ItemId       uint `gorm:"primaryKey" json:"item_id"`
+	FornecedorId uint `gorm:"index" json:"fornecedor_id"`
+	Minimo       int  `json:"minimo"`
+	Maximo       int  `json:"maximo"`
+	PontoPedido  int  `json:"ponto_pedido"`
+	LeadTimeDias int  `json:"lead_time_dias"`
+	// Abaixo indica se o item estava abaixo do ponto de pedido na última
+	// verificação, para notificar apenas quando o limite é cruzado
+	Abaixo bool `json:"abaixo"`
+}
+
+// Notificacao - Aviso gerado pelo sistema (ex: estoque baixo)
+type Notificacao struct {
+	Id       uint      `gorm:"primaryKey" json:"id"`
+	Tipo     string    `gorm:"index" json:"tipo"`
+	ItemId   *uint     `json:"item_id,omitempty"`
+	Mensagem string    `json:"mensagem"`
+	Lida     bool      `json:"lida"`
+	CriadoEm time.Time `gorm:"autoCreateTime" json:"criado_em"`
+}
+
+const NotificacaoEstoqueBaixo = "estoque_baixo"
+
+func (ParametroReposicao) TableName() string { return "parametros_reposicao" }
+func (Notificacao) TableName() string        { return "notificacoes" }
@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Situações de um número de série
+const (
+	SerieEmEstoque = "em_estoque"
+	SerieBaixada   = "baixada"
+)
+
+// NumeroSerie - Unidade individual de um item serializado. Enquanto está em
+// estoque, DepositoId (e LocalizacaoId, se informada na entrada) indicam
+// onde ela está.
+type NumeroSerie struct {
+	Id            uint      `gorm:"primaryKey" json:"id"`
+	ItemId        uint      `gorm:"uniqueIndex:idx_serie_numero" json:"item_id"`
+	Numero        string    `gorm:"uniqueIndex:idx_serie_numero;index" json:"numero"`
+	Status        string    `json:"status"`
+	DepositoId    *uint     `json:"deposito_id"`
+	LocalizacaoId *uint     `json:"localizacao_id"`
+	CriadoEm      time.Time `gorm:"autoCreateTime" json:"criado_em"`
+}
+
+// MovimentacaoSerie - Número de série que entrou ou saiu em uma movimentação
+type MovimentacaoSerie struct {
+	MovimentacaoId uint `gorm:"primaryKey"`
+	NumeroSerieId  uint `gorm:"primaryKey;index"`
+}
+
+func (NumeroSerie) TableName() string       { return "numeros_serie" }
+func (MovimentacaoSerie) TableName() string { return "movimentacoes_series" }
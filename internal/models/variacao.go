@@ -0,0 +1,93 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+)
+
+// DimensaoVariacao - Eixo em que as variantes de um produto diferem (cor,
+// tamanho, modelo) e os valores que ele assume
+type DimensaoVariacao struct {
+	Nome    string   `json:"nome"`
+	Valores []string `json:"valores"`
+}
+
+// DimensoesVariacao - Dimensões de um produto com variantes, gravadas em
+// JSONB. Um item com dimensões é um produto: não tem estoque próprio e a
+// quantidade é a soma das variantes.
+type DimensoesVariacao []DimensaoVariacao
+
+// Scan - Lê a coluna jsonb
+func (d *DimensoesVariacao) Scan(src any) error {
+	return scanJSON(src, d)
+}
+
+// Value - Grava como JSON; sem dimensões, uma lista vazia
+func (d DimensoesVariacao) Value() (driver.Value, error) {
+	if d == nil {
+		return "[]", nil
+	}
+	b, err := json.Marshal(d)
+	return string(b), err
+}
+
+// GormDataType - Tipo da coluna quando a tag não define um
+func (DimensoesVariacao) GormDataType() string {
+	return "jsonb"
+}
+
+// Variacao - Valor de cada dimensão do produto em uma variante
+// ({"modelo": "In-Ear"}), gravado em JSONB
+type Variacao map[string]string
+
+// Scan - Lê a coluna jsonb
+func (v *Variacao) Scan(src any) error {
+	return scanJSON(src, v)
+}
+
+// Value - Grava como JSON; fora de uma variante, NULL
+func (v Variacao) Value() (driver.Value, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	return string(b), err
+}
+
+// GormDataType - Tipo da coluna quando a tag não define um
+func (Variacao) GormDataType() string {
+	return "jsonb"
+}
+
+// Combinacoes - Todas as variações possíveis, variando primeiro a última
+// dimensão; sem dimensões, nenhuma
+func (d DimensoesVariacao) Combinacoes() []Variacao {
+	if len(d) == 0 {
+		return nil
+	}
+	combinacoes := []Variacao{{}}
+	for _, dimensao := range d {
+		proximas := make([]Variacao, 0, len(combinacoes)*len(dimensao.Valores))
+		for _, parcial := range combinacoes {
+			for _, valor := range dimensao.Valores {
+				v := make(Variacao, len(parcial)+1)
+				for nome, atual := range parcial {
+					v[nome] = atual
+				}
+				v[dimensao.Nome] = valor
+				proximas = append(proximas, v)
+			}
+		}
+		combinacoes = proximas
+	}
+	return combinacoes
+}
+
+// Valores - Valores da variação na ordem das dimensões
+func (d DimensoesVariacao) Valores(v Variacao) []string {
+	valores := make([]string, 0, len(d))
+	for _, dimensao := range d {
+		valores = append(valores, v[dimensao.Nome])
+	}
+	return valores
+}
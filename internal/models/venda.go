@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"myapi/internal/money"
+)
+
+// Status de um pedido de venda
+const (
+	VendaReservado  = "reservado"
+	VendaConfirmado = "confirmado"
+	VendaAtendido   = "atendido"
+	VendaCancelado  = "cancelado"
+	VendaExpirado   = "expirado"
+)
+
+// PedidoVenda - Pedido de um cliente. Enquanto reservado ou confirmado, as
+// quantidades das linhas ficam reservadas no depósito; um pedido reservado
+// que não é confirmado até ExpiraEm tem a reserva liberada.
+type PedidoVenda struct {
+	Id           uint              `gorm:"primaryKey" json:"id"`
+	ClienteId    uint              `gorm:"index" json:"cliente_id"`
+	DepositoId   uint              `json:"deposito_id"`
+	Status       string            `gorm:"index" json:"status"`
+	ExpiraEm     *time.Time        `gorm:"index" json:"expira_em"`
+	CriadoEm     time.Time         `gorm:"autoCreateTime" json:"criado_em"`
+	AtualizadoEm time.Time         `gorm:"autoUpdateTime" json:"atualizado_em"`
+	Cliente      *Cliente          `gorm:"foreignKey:ClienteId" json:"cliente,omitempty"`
+	Itens        []PedidoVendaItem `gorm:"foreignKey:PedidoVendaId" json:"itens"`
+}
+
+// PedidoVendaItem - Linha do pedido; o preço é copiado de Iten.Preco no
+// momento do pedido
+type PedidoVendaItem struct {
+	Id            uint        `gorm:"primaryKey" json:"id"`
+	PedidoVendaId uint        `gorm:"index" json:"pedido_venda_id"`
+	ItemId        uint        `json:"item_id"`
+	Codigo        string      `json:"codigo"`
+	Quantidade    int         `json:"quantidade"`
+	PrecoUnitario money.Money `gorm:"type:numeric(12,2)" json:"preco_unitario"`
+	Moeda         string      `gorm:"size:3" json:"moeda"`
+}
+
+// Reservado - Indica se o pedido ainda mantém estoque reservado
+func (p *PedidoVenda) Reservado() bool {
+	return p.Status == VendaReservado || p.Status == VendaConfirmado
+}
+
+func (PedidoVenda) TableName() string     { return "pedidos_venda" }
+func (PedidoVendaItem) TableName() string { return "pedidos_venda_itens" }
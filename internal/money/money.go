@@ -0,0 +1,285 @@
+// Package money define o tipo usado em preços e custos: um valor decimal
+// exato com a sua moeda.
+package money
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"myapi/internal/decimal"
+)
+
+// Regra - Casas decimais e modo de arredondamento de um tipo de valor
+type Regra struct {
+	Escala int32
+	Modo   decimal.Arredondamento
+}
+
+var (
+	// RegraPreco - Preços e totais: 2 casas, meio para cima
+	RegraPreco = Regra{Escala: 2, Modo: decimal.MeioParaCima}
+	// RegraCusto - Custos unitários: 4 casas, meio para cima
+	RegraCusto = Regra{Escala: 4, Modo: decimal.MeioParaCima}
+	// RegraImposto - Tributos: 2 casas, meio para par
+	RegraImposto = Regra{Escala: 2, Modo: decimal.MeioParaPar}
+)
+
+var (
+	moedaPadrao atomic.Value
+	jsonTexto   atomic.Bool
+	regrasMoeda atomic.Pointer[map[string]Regra]
+)
+
+func init() {
+	moedaPadrao.Store("BRL")
+	regrasMoeda.Store(&map[string]Regra{})
+}
+
+// Configurar - Define a moeda dos valores sem moeda explícita (como os lidos
+// do banco) e se o JSON emite os valores como texto ("12.50") em vez de
+// número (12.50). Os dois formatos são sempre aceitos na entrada.
+func Configurar(moeda string, texto bool) {
+	moedaPadrao.Store(moeda)
+	jsonTexto.Store(texto)
+}
+
+// MoedaPadrao - Moeda dos valores sem moeda explícita
+func MoedaPadrao() string {
+	return moedaPadrao.Load().(string)
+}
+
+// CodigoValido - Indica se o código tem o formato ISO 4217 (três letras maiúsculas)
+func CodigoValido(moeda string) bool {
+	if len(moeda) != 3 {
+		return false
+	}
+	for _, c := range moeda {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// ConfigurarArredondamento - Define a regra dos preços em cada moeda; moedas
+// sem regra própria usam RegraPreco
+func ConfigurarArredondamento(regras map[string]Regra) {
+	regrasMoeda.Store(&regras)
+}
+
+// RegraDaMoeda - Regra de arredondamento dos preços na moeda
+func RegraDaMoeda(moeda string) Regra {
+	if regra, ok := (*regrasMoeda.Load())[moeda]; ok {
+		return regra
+	}
+	return RegraPreco
+}
+
+// ParseRegras - Lê regras por moeda no formato "JPY=0,USD=2,EUR=2:par": casas
+// decimais e, opcionalmente, o modo ("cima", padrão, "par" ou "truncar")
+func ParseRegras(s string) (map[string]Regra, error) {
+	regras := map[string]Regra{}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		moeda, def, ok := strings.Cut(item, "=")
+		moeda = strings.ToUpper(strings.TrimSpace(moeda))
+		if !ok || !CodigoValido(moeda) {
+			return nil, fmt.Errorf("money: regra inválida %q", item)
+		}
+		casasStr, modoStr, _ := strings.Cut(def, ":")
+		casas, err := strconv.Atoi(strings.TrimSpace(casasStr))
+		if err != nil || casas < 0 || casas > 8 {
+			return nil, fmt.Errorf("money: casas decimais inválidas em %q", item)
+		}
+		regra := Regra{Escala: int32(casas), Modo: decimal.MeioParaCima}
+		switch strings.TrimSpace(modoStr) {
+		case "", "cima":
+		case "par":
+			regra.Modo = decimal.MeioParaPar
+		case "truncar":
+			regra.Modo = decimal.Truncar
+		default:
+			return nil, fmt.Errorf("money: modo de arredondamento inválido em %q", item)
+		}
+		regras[moeda] = regra
+	}
+	return regras, nil
+}
+
+// Money - Valor monetário exato. O valor zero é 0 na moeda padrão. No banco
+// é gravado só o valor (numeric); a moeda, quando varia, fica em coluna própria.
+type Money struct {
+	valor decimal.Decimal
+	moeda string
+}
+
+// New - Valor na moeda informada (vazia = moeda padrão)
+func New(valor decimal.Decimal, moeda string) Money {
+	return Money{valor: valor, moeda: moeda}
+}
+
+// FromDecimal - Valor na moeda padrão
+func FromDecimal(valor decimal.Decimal) Money {
+	return Money{valor: valor}
+}
+
+// Parse - Lê "12.50" na moeda padrão
+func Parse(s string) (Money, error) {
+	d, err := decimal.Parse(s)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{valor: d}, nil
+}
+
+// MustParse - Parse para constantes conhecidas; entra em pânico se inválido
+func MustParse(s string) Money {
+	m, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Decimal - Valor sem a moeda
+func (m Money) Decimal() decimal.Decimal {
+	return m.valor
+}
+
+// Moeda - Código ISO 4217 da moeda
+func (m Money) Moeda() string {
+	if m.moeda == "" {
+		return MoedaPadrao()
+	}
+	return m.moeda
+}
+
+// EmMoeda - Mesmo valor marcado com outra moeda (sem conversão)
+func (m Money) EmMoeda(moeda string) Money {
+	return Money{valor: m.valor, moeda: moeda}
+}
+
+// mesmaMoeda - Operações entre moedas diferentes são erro de programação:
+// a conversão precisa ser explícita
+func (m Money) mesmaMoeda(o Money) string {
+	if m.IsZero() && m.moeda == "" {
+		return o.moeda
+	}
+	if o.IsZero() && o.moeda == "" || m.Moeda() == o.Moeda() {
+		return m.moeda
+	}
+	panic(fmt.Sprintf("money: operação entre moedas diferentes (%s e %s)", m.Moeda(), o.Moeda()))
+}
+
+func (m Money) Add(o Money) Money {
+	return Money{valor: m.valor.Add(o.valor), moeda: m.mesmaMoeda(o)}
+}
+
+func (m Money) Sub(o Money) Money {
+	return Money{valor: m.valor.Sub(o.valor), moeda: m.mesmaMoeda(o)}
+}
+
+// MulInt - Multiplica por uma quantidade, sem arredondar
+func (m Money) MulInt(n int64) Money {
+	return Money{valor: m.valor.MulInt(n), moeda: m.moeda}
+}
+
+// Mul - Multiplica por um fator (alíquota, percentual, câmbio), sem arredondar
+func (m Money) Mul(fator decimal.Decimal) Money {
+	return Money{valor: m.valor.Mul(fator), moeda: m.moeda}
+}
+
+// DivInt - Divide por uma quantidade, arredondando pela regra
+func (m Money) DivInt(n int64, regra Regra) Money {
+	return Money{valor: m.valor.DivInt(n, regra.Escala, regra.Modo), moeda: m.moeda}
+}
+
+// Arredondar - Aplica a regra de arredondamento do tipo de valor
+func (m Money) Arredondar(regra Regra) Money {
+	return Money{valor: m.valor.Round(regra.Escala, regra.Modo), moeda: m.moeda}
+}
+
+// Proporcional - Parte do valor correspondente a parte/todo, pela regra.
+// Quando a parte é o todo, devolve o valor inteiro, para que saldos zerados
+// não deixem resíduo de arredondamento.
+func (m Money) Proporcional(parte, todo int, regra Regra) Money {
+	if todo <= 0 || parte >= todo {
+		return m
+	}
+	return m.MulInt(int64(parte)).DivInt(int64(todo), regra)
+}
+
+// Converter - Converte para a moeda de destino pelas taxas das duas moedas
+// em relação à moeda padrão (valor × taxaOrigem / taxaDestino), arredondando
+// pela regra da moeda de destino
+func (m Money) Converter(taxaOrigem, taxaDestino decimal.Decimal, destino string) Money {
+	regra := RegraDaMoeda(destino)
+	return Money{valor: m.valor.Mul(taxaOrigem).Div(taxaDestino, regra.Escala, regra.Modo), moeda: destino}
+}
+
+func (m Money) Neg() Money {
+	return Money{valor: m.valor.Neg(), moeda: m.moeda}
+}
+
+func (m Money) Abs() Money {
+	return Money{valor: m.valor.Abs(), moeda: m.moeda}
+}
+
+func (m Money) Sign() int {
+	return m.valor.Sign()
+}
+
+func (m Money) IsZero() bool {
+	return m.valor.IsZero()
+}
+
+// Cmp - Compara valores da mesma moeda
+func (m Money) Cmp(o Money) int {
+	m.mesmaMoeda(o)
+	return m.valor.Cmp(o.valor)
+}
+
+// Float64 - Valor aproximado, para compatibilidade com clientes antigos
+func (m Money) Float64() float64 {
+	return m.valor.Float64()
+}
+
+func (m Money) String() string {
+	return m.valor.String()
+}
+
+// Scan - Lê colunas numeric; a moeda fica a padrão
+func (m *Money) Scan(src any) error {
+	m.moeda = ""
+	return m.valor.Scan(src)
+}
+
+// Value - Grava apenas o valor
+func (m Money) Value() (driver.Value, error) {
+	return m.valor.Value()
+}
+
+// GormDataType - Tipo da coluna quando a tag não define um
+func (Money) GormDataType() string {
+	return "numeric"
+}
+
+// MarshalJSON - Número ou texto, conforme Configurar
+func (m Money) MarshalJSON() ([]byte, error) {
+	if jsonTexto.Load() {
+		return []byte(strconv.Quote(m.valor.String())), nil
+	}
+	return m.valor.MarshalJSON()
+}
+
+// UnmarshalJSON - Aceita número (12.5) ou texto ("12.50")
+func (m *Money) UnmarshalJSON(data []byte) error {
+	m.moeda = ""
+	return m.valor.UnmarshalJSON(data)
+}
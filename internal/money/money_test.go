@@ -0,0 +1,88 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"myapi/internal/decimal"
+)
+
+func TestArredondar(t *testing.T) {
+	casos := []struct {
+		valor string
+		regra Regra
+		quer  string
+	}{
+		{"0.125", RegraPreco, "0.13"},
+		{"-0.125", RegraPreco, "-0.13"},
+		{"0.125", RegraImposto, "0.12"},
+		{"0.135", RegraImposto, "0.14"},
+		{"-0.125", RegraImposto, "-0.12"},
+		{"-0.135", RegraImposto, "-0.14"},
+		{"1.23456", RegraCusto, "1.2346"},
+		{"0.129", Regra{Escala: 2, Modo: decimal.Truncar}, "0.12"},
+		{"-0.129", Regra{Escala: 2, Modo: decimal.Truncar}, "-0.12"},
+		{"123.5", Regra{Escala: 0, Modo: decimal.MeioParaCima}, "124"},
+	}
+	for _, c := range casos {
+		if got := MustParse(c.valor).Arredondar(c.regra).String(); got != c.quer {
+			t.Errorf("Arredondar(%s, %+v) = %s, quer %s", c.valor, c.regra, got, c.quer)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	t.Cleanup(func() { Configurar("BRL", false) })
+	casos := []struct {
+		texto bool
+		valor string
+		quer  string
+	}{
+		{false, "12.50", `12.50`},
+		{false, "-0.05", `-0.05`},
+		{false, "1234567890123.4567", `1234567890123.4567`},
+		{true, "12.50", `"12.50"`},
+		{true, "-0.05", `"-0.05"`},
+		{true, "1234567890123.4567", `"1234567890123.4567"`},
+	}
+	for _, c := range casos {
+		Configurar("BRL", c.texto)
+		data, err := json.Marshal(MustParse(c.valor))
+		if err != nil {
+			t.Fatalf("Marshal(%s): %v", c.valor, err)
+		}
+		if string(data) != c.quer {
+			t.Errorf("Marshal(%s, texto=%v) = %s, quer %s", c.valor, c.texto, data, c.quer)
+		}
+		var lido Money
+		if err := json.Unmarshal(data, &lido); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", data, err)
+		}
+		if lido.String() != c.valor {
+			t.Errorf("ida e volta de %s (texto=%v) = %s", c.valor, c.texto, lido)
+		}
+	}
+}
+
+func TestJSONEntrada(t *testing.T) {
+	casos := map[string]string{
+		`12.5`:    "12.5",
+		`"12.50"`: "12.50",
+		`0.1`:     "0.1",
+		`1e2`:     "100",
+	}
+	for entrada, quer := range casos {
+		var m Money
+		if err := json.Unmarshal([]byte(entrada), &m); err != nil {
+			t.Errorf("Unmarshal(%s): %v", entrada, err)
+			continue
+		}
+		if m.String() != quer {
+			t.Errorf("Unmarshal(%s) = %s, quer %s", entrada, m, quer)
+		}
+	}
+	var m Money
+	if err := json.Unmarshal([]byte(`"abc"`), &m); err == nil {
+		t.Errorf(`Unmarshal("abc") sem erro`)
+	}
+}
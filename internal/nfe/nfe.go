@@ -0,0 +1,280 @@
+// Package nfe lê o XML da NF-e modelo 55 (layout 4.00), com ou sem o
+// envelope nfeProc do protocolo de autorização. A leitura é toda local: o
+// arquivo não é consultado na SEFAZ nem tem a assinatura verificada.
+package nfe
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"myapi/internal/decimal"
+)
+
+// Versao - Único layout aceito
+const Versao = "4.00"
+
+var (
+	ErrXmlInvalido = errors.New("XML da NF-e inválido")
+	ErrVersao      = errors.New("versão da NF-e não suportada, use o layout 4.00")
+)
+
+// Nota - Dados da NF-e usados na entrada de mercadorias
+type Nota struct {
+	Chave        string
+	Numero       string
+	Serie        string
+	EmitidaEm    time.Time
+	EmitenteCnpj string
+	EmitenteNome string
+	ValorTotal   decimal.Decimal
+	Itens        []Item
+}
+
+// Item - Produto de uma linha (det) da nota, na unidade comercial
+type Item struct {
+	Numero        int
+	Codigo        string
+	Ean           string
+	Descricao     string
+	Ncm           string
+	Cest          string
+	Cfop          string
+	Origem        int
+	Unidade       string
+	Quantidade    decimal.Decimal
+	ValorUnitario decimal.Decimal
+	ValorTotal    decimal.Decimal
+	Lotes         []Lote
+}
+
+// Lote - Rastreabilidade (grupo rastro) de parte da quantidade do item
+type Lote struct {
+	Numero     string
+	Quantidade decimal.Decimal
+	Fabricacao time.Time
+	Validade   time.Time
+}
+
+// infNFe - Trecho do layout lido pelo pacote; o restante é ignorado
+type infNFe struct {
+	Id     string `xml:"Id,attr"`
+	Versao string `xml:"versao,attr"`
+	Ide    struct {
+		Serie  string `xml:"serie"`
+		Numero string `xml:"nNF"`
+		DhEmi  string `xml:"dhEmi"`
+	} `xml:"ide"`
+	Emit struct {
+		Cnpj  string `xml:"CNPJ"`
+		Cpf   string `xml:"CPF"`
+		XNome string `xml:"xNome"`
+	} `xml:"emit"`
+	Det []struct {
+		NItem string `xml:"nItem,attr"`
+		Prod  struct {
+			CProd  string `xml:"cProd"`
+			CEAN   string `xml:"cEAN"`
+			XProd  string `xml:"xProd"`
+			Ncm    string `xml:"NCM"`
+			Cest   string `xml:"CEST"`
+			Cfop   string `xml:"CFOP"`
+			UCom   string `xml:"uCom"`
+			QCom   string `xml:"qCom"`
+			VUnCom string `xml:"vUnCom"`
+			VProd  string `xml:"vProd"`
+			Rastro []struct {
+				NLote string `xml:"nLote"`
+				QLote string `xml:"qLote"`
+				DFab  string `xml:"dFab"`
+				DVal  string `xml:"dVal"`
+			} `xml:"rastro"`
+		} `xml:"prod"`
+		Imposto struct {
+			// O grupo de ICMS varia com o CST (ICMS00, ICMS20, ICMSSN102...);
+			// todos trazem a origem da mercadoria
+			Icms struct {
+				Grupo struct {
+					Orig string `xml:"orig"`
+				} `xml:",any"`
+			} `xml:"ICMS"`
+		} `xml:"imposto"`
+	} `xml:"det"`
+	Total struct {
+		VNF string `xml:"ICMSTot>vNF"`
+	} `xml:"total"`
+}
+
+// Ler - Lê a nota do XML, procurando o grupo infNFe em qualquer nível
+func Ler(r io.Reader) (*Nota, error) {
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: grupo infNFe não encontrado", ErrXmlInvalido)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrXmlInvalido, err)
+		}
+		if inicio, ok := token.(xml.StartElement); ok && inicio.Name.Local == "infNFe" {
+			var inf infNFe
+			if err := decoder.DecodeElement(&inf, &inicio); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrXmlInvalido, err)
+			}
+			return converter(&inf)
+		}
+	}
+}
+
+// converter - Valida os campos lidos e monta a nota
+func converter(inf *infNFe) (*Nota, error) {
+	if inf.Versao != Versao {
+		return nil, ErrVersao
+	}
+	nota := &Nota{
+		Chave:        strings.TrimPrefix(inf.Id, "NFe"),
+		Numero:       inf.Ide.Numero,
+		Serie:        inf.Ide.Serie,
+		EmitenteCnpj: inf.Emit.Cnpj,
+		EmitenteNome: strings.TrimSpace(inf.Emit.XNome),
+	}
+	if !ChaveValida(nota.Chave) {
+		return nil, fmt.Errorf("%w: chave de acesso %q", ErrXmlInvalido, nota.Chave)
+	}
+	if nota.EmitenteCnpj == "" {
+		nota.EmitenteCnpj = inf.Emit.Cpf
+	}
+	emitidaEm, err := time.Parse(time.RFC3339, inf.Ide.DhEmi)
+	if err != nil {
+		return nil, fmt.Errorf("%w: data de emissão %q", ErrXmlInvalido, inf.Ide.DhEmi)
+	}
+	nota.EmitidaEm = emitidaEm
+	if nota.ValorTotal, err = valor("vNF", inf.Total.VNF); err != nil {
+		return nil, err
+	}
+	if len(inf.Det) == 0 {
+		return nil, fmt.Errorf("%w: nota sem itens", ErrXmlInvalido)
+	}
+
+	for _, det := range inf.Det {
+		item := Item{
+			Codigo:    strings.TrimSpace(det.Prod.CProd),
+			Ean:       det.Prod.CEAN,
+			Descricao: strings.TrimSpace(det.Prod.XProd),
+			Ncm:       det.Prod.Ncm,
+			Cest:      det.Prod.Cest,
+			Cfop:      det.Prod.Cfop,
+			Unidade:   strings.TrimSpace(det.Prod.UCom),
+		}
+		if item.Numero, err = strconv.Atoi(det.NItem); err != nil {
+			return nil, fmt.Errorf("%w: nItem %q", ErrXmlInvalido, det.NItem)
+		}
+		// "SEM GTIN" indica produto sem código de barras
+		if !GtinValido(item.Ean) {
+			item.Ean = ""
+		}
+		if det.Imposto.Icms.Grupo.Orig != "" {
+			if item.Origem, err = strconv.Atoi(det.Imposto.Icms.Grupo.Orig); err != nil {
+				return nil, fmt.Errorf("%w: origem %q no item %d", ErrXmlInvalido, det.Imposto.Icms.Grupo.Orig, item.Numero)
+			}
+		}
+		if item.Quantidade, err = valor("qCom", det.Prod.QCom); err != nil {
+			return nil, err
+		}
+		if item.ValorUnitario, err = valor("vUnCom", det.Prod.VUnCom); err != nil {
+			return nil, err
+		}
+		if item.ValorTotal, err = valor("vProd", det.Prod.VProd); err != nil {
+			return nil, err
+		}
+		for _, rastro := range det.Prod.Rastro {
+			lote := Lote{Numero: strings.TrimSpace(rastro.NLote)}
+			if lote.Numero == "" {
+				return nil, fmt.Errorf("%w: nLote vazio no item %d", ErrXmlInvalido, item.Numero)
+			}
+			if lote.Quantidade, err = valor("qLote", rastro.QLote); err != nil {
+				return nil, err
+			}
+			if lote.Fabricacao, err = data("dFab", rastro.DFab); err != nil {
+				return nil, err
+			}
+			if lote.Validade, err = data("dVal", rastro.DVal); err != nil {
+				return nil, err
+			}
+			item.Lotes = append(item.Lotes, lote)
+		}
+		nota.Itens = append(nota.Itens, item)
+	}
+	return nota, nil
+}
+
+func valor(campo, s string) (decimal.Decimal, error) {
+	d, err := decimal.Parse(s)
+	if err != nil {
+		return decimal.Decimal{}, fmt.Errorf("%w: %s %q", ErrXmlInvalido, campo, s)
+	}
+	return d, nil
+}
+
+// data - Data no formato AAAA-MM-DD do layout
+func data(campo, s string) (time.Time, error) {
+	d, err := time.Parse(time.DateOnly, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s %q", ErrXmlInvalido, campo, s)
+	}
+	return d, nil
+}
+
+// ChaveValida - Chave de acesso com 44 dígitos e dígito verificador
+// (módulo 11) correto
+func ChaveValida(chave string) bool {
+	if !digitos(chave) || len(chave) != 44 {
+		return false
+	}
+	soma, peso := 0, 2
+	for i := 42; i >= 0; i-- {
+		soma += int(chave[i]-'0') * peso
+		if peso++; peso > 9 {
+			peso = 2
+		}
+	}
+	dv := 11 - soma%11
+	if dv >= 10 {
+		dv = 0
+	}
+	return int(chave[43]-'0') == dv
+}
+
+// GtinValido - GTIN-8, 12, 13 ou 14 com dígito verificador correto
+func GtinValido(gtin string) bool {
+	switch len(gtin) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+	if !digitos(gtin) {
+		return false
+	}
+	soma := 0
+	for i := len(gtin) - 2; i >= 0; i-- {
+		peso := 1
+		if (len(gtin)-2-i)%2 == 0 {
+			peso = 3
+		}
+		soma += int(gtin[i]-'0') * peso
+	}
+	return int(gtin[len(gtin)-1]-'0') == (10-soma%10)%10
+}
+
+func digitos(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
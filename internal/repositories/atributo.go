@@ -0,0 +1,276 @@
+package repositories
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"myapi/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrEsquemaAtributos = errors.New("esquema de atributos inválido")
+	ErrAtributos        = errors.New("atributos inválidos")
+	ErrFiltroAtributo   = errors.New("filtro de atributo inválido")
+	ErrAtributosItens   = errors.New("a alteração invalidaria os atributos de itens da categoria")
+)
+
+// FiltroAtributo - Condição sobre um atributo na listagem de itens. Os
+// operadores <, <=, > e >= comparam números; = e != aceitam texto, número
+// ou booleano.
+type FiltroAtributo struct {
+	Nome     string
+	Operador string
+	Valor    string
+}
+
+// operadoresAtributo - Os de dois caracteres vêm antes para não serem
+// confundidos com os de um
+var operadoresAtributo = []string{">=", "<=", "!=", ">", "<", "="}
+
+// ParseFiltroAtributo - Lê "polegadas>=24" ou "tipo_ddr=DDR5"
+func ParseFiltroAtributo(s string) (FiltroAtributo, error) {
+	i := strings.IndexAny(s, "<>=!")
+	if i <= 0 {
+		return FiltroAtributo{}, fmt.Errorf("%w: %q", ErrFiltroAtributo, s)
+	}
+	filtro := FiltroAtributo{Nome: s[:i]}
+	for _, operador := range operadoresAtributo {
+		if strings.HasPrefix(s[i:], operador) {
+			filtro.Operador, filtro.Valor = operador, s[i+len(operador):]
+			break
+		}
+	}
+	if filtro.Operador == "" || !nomeAtributoValido(filtro.Nome) {
+		return FiltroAtributo{}, fmt.Errorf("%w: %q", ErrFiltroAtributo, s)
+	}
+	if filtro.Operador != "=" && filtro.Operador != "!=" {
+		if _, err := strconv.ParseFloat(filtro.Valor, 64); err != nil {
+			return FiltroAtributo{}, fmt.Errorf("%w: %s compara apenas números", ErrFiltroAtributo, filtro.Operador)
+		}
+	}
+	return filtro, nil
+}
+
+// filtrarAtributo - Aplica o filtro à consulta de itens. Igualdade usa
+// contenção de JSONB (atende pelo índice GIN), tentando o valor como texto,
+// número e booleano; itens sem o atributo não passam em nenhum operador.
+func filtrarAtributo(q *gorm.DB, filtro FiltroAtributo) *gorm.DB {
+	if filtro.Operador != "=" && filtro.Operador != "!=" {
+		numero, _ := strconv.ParseFloat(filtro.Valor, 64)
+		return q.Where(fmt.Sprintf(
+			"CASE WHEN jsonb_typeof(itens.atributos -> ?) = 'number' THEN (itens.atributos ->> ?)::numeric END %s ?",
+			filtro.Operador), filtro.Nome, filtro.Nome, numero)
+	}
+
+	candidatos := []any{filtro.Valor}
+	if numero, err := strconv.ParseFloat(filtro.Valor, 64); err == nil {
+		candidatos = append(candidatos, numero)
+	}
+	if booleano, err := strconv.ParseBool(filtro.Valor); err == nil {
+		candidatos = append(candidatos, booleano)
+	}
+	var condicoes []string
+	var args []any
+	for _, candidato := range candidatos {
+		// Os valores vêm de tipos simples; Marshal não falha
+		contido, _ := json.Marshal(map[string]any{filtro.Nome: candidato})
+		condicoes = append(condicoes, "itens.atributos @> ?::jsonb")
+		args = append(args, string(contido))
+	}
+	igual := "(" + strings.Join(condicoes, " OR ") + ")"
+	if filtro.Operador == "=" {
+		return q.Where(igual, args...)
+	}
+	return q.Where("itens.atributos -> ? IS NOT NULL AND NOT "+igual, append([]any{filtro.Nome}, args...)...)
+}
+
+// validarEsquema - Nomes únicos e válidos, tipos conhecidos, opções apenas
+// (e obrigatoriamente) em enums e unidade apenas em números
+func validarEsquema(esquema models.EsquemaAtributos) error {
+	nomes := map[string]bool{}
+	for _, definicao := range esquema {
+		switch {
+		case !nomeAtributoValido(definicao.Nome):
+			return fmt.Errorf("%w: nome %q deve ter apenas letras minúsculas, dígitos e _", ErrEsquemaAtributos, definicao.Nome)
+		case nomes[definicao.Nome]:
+			return fmt.Errorf("%w: atributo %s repetido", ErrEsquemaAtributos, definicao.Nome)
+		}
+		nomes[definicao.Nome] = true
+		switch definicao.Tipo {
+		case models.AtributoTexto, models.AtributoNumero, models.AtributoEnum, models.AtributoBooleano:
+		default:
+			return fmt.Errorf("%w: tipo %q do atributo %s (use texto, numero, enum ou booleano)", ErrEsquemaAtributos, definicao.Tipo, definicao.Nome)
+		}
+		if definicao.Tipo == models.AtributoEnum && len(definicao.Opcoes) == 0 {
+			return fmt.Errorf("%w: o enum %s precisa de opções", ErrEsquemaAtributos, definicao.Nome)
+		}
+		if definicao.Tipo != models.AtributoEnum && len(definicao.Opcoes) > 0 {
+			return fmt.Errorf("%w: opções valem apenas para enums (%s)", ErrEsquemaAtributos, definicao.Nome)
+		}
+		if definicao.Tipo != models.AtributoNumero && definicao.Unidade != "" {
+			return fmt.Errorf("%w: unidade vale apenas para números (%s)", ErrEsquemaAtributos, definicao.Nome)
+		}
+	}
+	return nil
+}
+
+// esquemaEfetivo - Atributos da categoria somados aos das categorias acima
+// dela; um atributo redefinido vale pela definição mais próxima
+func esquemaEfetivo(tx *gorm.DB, categoriaID uint) (models.EsquemaAtributos, error) {
+	var caminho []models.Categoria
+	if err := tx.Select("categorias.id", "categorias.atributos").
+		Joins("JOIN categorias_caminhos ON categorias_caminhos.ancestral_id = categorias.id").
+		Where("categorias_caminhos.descendente_id = ?", categoriaID).
+		Order("categorias_caminhos.profundidade DESC").Find(&caminho).Error; err != nil {
+		return nil, err
+	}
+	if len(caminho) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	posicoes := map[string]int{}
+	esquema := models.EsquemaAtributos{}
+	for _, categoria := range caminho {
+		for _, definicao := range categoria.Atributos {
+			if i, ok := posicoes[definicao.Nome]; ok {
+				esquema[i] = definicao
+				continue
+			}
+			posicoes[definicao.Nome] = len(esquema)
+			esquema = append(esquema, definicao)
+		}
+	}
+	return esquema, nil
+}
+
+// validarAtributos - Confere os atributos do item com o esquema da sua
+// categoria: todos conhecidos, do tipo certo e os obrigatórios presentes. As
+// categorias do caminho ficam travadas para compartilhamento, de modo que uma
+// mudança de esquema ou movimento concorrente espere pela gravação do item
+// (e o revalide em revalidarItens)
+func validarAtributos(tx *gorm.DB, item *models.Iten) error {
+	if item.CategoriaId == nil {
+		if len(item.Atributos) > 0 {
+			return fmt.Errorf("%w: atributos exigem uma categoria", ErrAtributos)
+		}
+		return nil
+	}
+	esquema, err := esquemaEfetivo(tx.Clauses(clause.Locking{Strength: "SHARE", Table: clause.Table{Name: "categorias"}}), *item.CategoriaId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: categoria %d não encontrada", ErrAtributos, *item.CategoriaId)
+	}
+	if err != nil {
+		return err
+	}
+	return conferirAtributos(esquema, item.Atributos)
+}
+
+// revalidarItens - Confere os itens da subárvore da categoria com o esquema
+// que passam a herdar depois de uma mudança de esquema ou de um movimento; o
+// primeiro item inválido recusa a alteração
+func revalidarItens(tx *gorm.DB, categoriaID uint) error {
+	var itens []models.Iten
+	if err := tx.Select("itens.id", "itens.codigo", "itens.categoria_id", "itens.atributos").
+		Joins("JOIN categorias_caminhos ON categorias_caminhos.descendente_id = itens.categoria_id").
+		Where("categorias_caminhos.ancestral_id = ?", categoriaID).
+		Order("itens.id").Find(&itens).Error; err != nil {
+		return err
+	}
+	esquemas := map[uint]models.EsquemaAtributos{}
+	for _, item := range itens {
+		esquema, ok := esquemas[*item.CategoriaId]
+		if !ok {
+			var err error
+			if esquema, err = esquemaEfetivo(tx, *item.CategoriaId); err != nil {
+				return err
+			}
+			esquemas[*item.CategoriaId] = esquema
+		}
+		if err := conferirAtributos(esquema, item.Atributos); err != nil {
+			return fmt.Errorf("%w: item %s: %v", ErrAtributosItens, item.Codigo, err)
+		}
+	}
+	return nil
+}
+
+// conferirAtributos - Atributos conhecidos no esquema, do tipo certo e os
+// obrigatórios presentes; valores nulos são removidos
+func conferirAtributos(esquema models.EsquemaAtributos, atributos models.Atributos) error {
+	definicoes := make(map[string]models.DefinicaoAtributo, len(esquema))
+	for _, definicao := range esquema {
+		definicoes[definicao.Nome] = definicao
+	}
+	for nome, valor := range atributos {
+		definicao, ok := definicoes[nome]
+		if !ok {
+			return fmt.Errorf("%w: %s não está no esquema da categoria", ErrAtributos, nome)
+		}
+		if valor == nil {
+			delete(atributos, nome)
+			continue
+		}
+		if !valorAtributoValido(definicao, valor) {
+			return fmt.Errorf("%w: valor de %s deve ser %s", ErrAtributos, nome, descreverTipo(definicao))
+		}
+	}
+	for _, definicao := range esquema {
+		if _, ok := atributos[definicao.Nome]; definicao.Obrigatorio && !ok {
+			return fmt.Errorf("%w: %s é obrigatório", ErrAtributos, definicao.Nome)
+		}
+	}
+	return nil
+}
+
+func valorAtributoValido(definicao models.DefinicaoAtributo, valor any) bool {
+	switch definicao.Tipo {
+	case models.AtributoTexto:
+		texto, ok := valor.(string)
+		return ok && (texto != "" || !definicao.Obrigatorio)
+	case models.AtributoNumero:
+		_, ok := valor.(float64)
+		return ok
+	case models.AtributoBooleano:
+		_, ok := valor.(bool)
+		return ok
+	case models.AtributoEnum:
+		texto, _ := valor.(string)
+		for _, opcao := range definicao.Opcoes {
+			if texto == opcao {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func descreverTipo(definicao models.DefinicaoAtributo) string {
+	switch definicao.Tipo {
+	case models.AtributoNumero:
+		if definicao.Unidade != "" {
+			return "um número em " + definicao.Unidade
+		}
+		return "um número"
+	case models.AtributoBooleano:
+		return "true ou false"
+	case models.AtributoEnum:
+		return "um de " + strings.Join(definicao.Opcoes, ", ")
+	}
+	return "um texto"
+}
+
+// nomeAtributoValido - Letras minúsculas (com acento), dígitos e _, para o
+// nome poder ser usado nos filtros
+func nomeAtributoValido(nome string) bool {
+	for _, c := range nome {
+		if !unicode.IsLower(c) && !unicode.IsDigit(c) && c != '_' {
+			return false
+		}
+	}
+	return nome != ""
+}
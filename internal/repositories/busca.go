@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"encoding/json"
+
+	"myapi/internal/config"
+	"myapi/internal/decimal"
+	"myapi/internal/models"
+)
+
+// Facetas - Contagens sobre os itens filtrados, para refinar a busca
+type Facetas struct {
+	Categorias []FacetaCategoria `json:"categorias"`
+	Precos     []FacetaPreco     `json:"precos"`
+	Estoque    FacetaEstoque     `json:"estoque"`
+	Atributos  []FacetaAtributo  `json:"atributos"`
+}
+
+// FacetaCategoria - Itens na categoria, somando os das subcategorias
+type FacetaCategoria struct {
+	Id         uint   `json:"id"`
+	Nome       string `json:"nome"`
+	ParentId   *uint  `json:"parent_id"`
+	Quantidade int    `json:"quantidade"`
+}
+
+// FacetaPreco - Itens com preço em [De, Ate) na moeda
+type FacetaPreco struct {
+	Moeda      string          `json:"moeda"`
+	De         decimal.Decimal `json:"de"`
+	Ate        decimal.Decimal `json:"ate"`
+	Quantidade int             `json:"quantidade"`
+}
+
+// FacetaEstoque - Itens com e sem estoque (soma dos depósitos), sem produtos
+// com variantes nem kits
+type FacetaEstoque struct {
+	ComEstoque int `json:"com_estoque"`
+	SemEstoque int `json:"sem_estoque"`
+}
+
+// FacetaAtributo - Itens com o valor no atributo
+type FacetaAtributo struct {
+	Nome       string `json:"nome"`
+	Valor      any    `json:"valor"`
+	Quantidade int    `json:"quantidade"`
+}
+
+// itemFacetado - Linha da busca com facetas; apenas a primeira traz o JSON
+type itemFacetado struct {
+	models.Iten `gorm:"embedded"`
+	Facetas     *string
+}
+
+// consultaFacetas - Filtra os itens uma única vez (CTE materializada) e
+// calcula as facetas sobre o mesmo conjunto, na mesma instrução
+const consultaFacetas = `
+WITH filtrados AS MATERIALIZED (?),
+facetas AS (
+	SELECT json_build_object(
+		'categorias', COALESCE((
+			SELECT json_agg(json_build_object('id', c.id, 'nome', c.nome, 'parent_id', c.parent_id, 'quantidade', t.quantidade)
+				ORDER BY t.quantidade DESC, c.nome)
+			FROM (
+				SELECT cc.ancestral_id, count(*) AS quantidade
+				FROM filtrados f JOIN categorias_caminhos cc ON cc.descendente_id = f.categoria_id
+				GROUP BY cc.ancestral_id
+			) t JOIN categorias c ON c.id = t.ancestral_id
+		), '[]'),
+		'precos', COALESCE((
+			SELECT json_agg(json_build_object('moeda', t.moeda, 'de', t.de, 'ate', t.de + ?, 'quantidade', t.quantidade)
+				ORDER BY t.moeda, t.de)
+			FROM (
+				SELECT f.moeda, floor(f.preco / ?) * ? AS de, count(*) AS quantidade
+				FROM filtrados f GROUP BY 1, 2
+			) t
+		), '[]'),
+		'estoque', (
+			SELECT json_build_object(
+				'com_estoque', count(*) FILTER (WHERE f.quantidade > 0),
+				'sem_estoque', count(*) FILTER (WHERE f.quantidade <= 0))
+			FROM filtrados f
+			WHERE f.dimensoes = '[]' AND NOT EXISTS (SELECT 1 FROM componentes_kit k WHERE k.kit_id = f.id)
+		),
+		'atributos', COALESCE((
+			SELECT json_agg(json_build_object('nome', t.nome, 'valor', t.valor, 'quantidade', t.quantidade)
+				ORDER BY t.nome, t.quantidade DESC)
+			FROM (
+				SELECT a.key AS nome, a.value AS valor, count(*) AS quantidade,
+					row_number() OVER (PARTITION BY a.key ORDER BY count(*) DESC, a.value) AS posicao
+				FROM filtrados f, jsonb_each(f.atributos) a
+				GROUP BY a.key, a.value
+			) t WHERE t.posicao <= ?
+		), '[]')
+	) AS facetas
+)
+SELECT filtrados.*,
+	CASE WHEN row_number() OVER (ORDER BY filtrados.id) = 1 THEN facetas.facetas::text END AS facetas
+FROM filtrados CROSS JOIN facetas
+ORDER BY filtrados.id`
+
+// Buscar - Itens que atendem ao filtro e as facetas do resultado. Os preços
+// são agrupados em faixas da largura informada (zero = BUSCA_FAIXA_PRECO).
+func (r *ItemRepository) Buscar(filtro FiltroItens, faixaPreco decimal.Decimal) ([]models.Iten, *Facetas, error) {
+	cfg := config.LoadBuscaConfig()
+	if faixaPreco.Sign() <= 0 {
+		faixaPreco = cfg.FaixaPreco
+	}
+	db := config.Reader(r.ctx)
+	var linhas []itemFacetado
+	if err := db.Raw(consultaFacetas, consultaItens(db, filtro),
+		faixaPreco, faixaPreco, faixaPreco, cfg.ValoresPorAtributo).Scan(&linhas).Error; err != nil {
+		return nil, nil, err
+	}
+
+	itens := make([]models.Iten, len(linhas))
+	facetas := &Facetas{Categorias: []FacetaCategoria{}, Precos: []FacetaPreco{}, Atributos: []FacetaAtributo{}}
+	for i, linha := range linhas {
+		itens[i] = linha.Iten
+		if linha.Facetas != nil {
+			if err := json.Unmarshal([]byte(*linha.Facetas), facetas); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	return itens, facetas, nil
+}
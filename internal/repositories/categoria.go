@@ -1,43 +1,290 @@
 package repositories
 
 import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+
+	"myapi/internal/cache"
 	"myapi/internal/config"
 	"myapi/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrCategoriaCiclo     = errors.New("a categoria não pode ficar abaixo dela mesma ou de uma subcategoria")
+	ErrCategoriaComFilhas = errors.New("a categoria tem subcategorias")
 )
 
-type CategoriaRepository struct{}
+// lockCategorias - Serializa as alterações da árvore: sem ele, dois
+// movimentos cruzados (A abaixo de B e B abaixo de A) passam ambos pela
+// verificação de ciclo antes de qualquer um gravar os caminhos
+const lockCategorias int64 = 2002
 
-func NewCategoriaRepository() *CategoriaRepository {
-	return &CategoriaRepository{}
+type CategoriaRepository struct {
+	ctx context.Context
+}
+
+func NewCategoriaRepository(ctx context.Context) *CategoriaRepository {
+	return &CategoriaRepository{ctx: ctx}
 }
 
 func (r *CategoriaRepository) ListAll() ([]models.Categoria, error) {
 	var categorias []models.Categoria
-	if err := config.DB.Find(&categorias).Error; err != nil {
+	if err := config.Reader(r.ctx).Find(&categorias).Error; err != nil {
 		return nil, err
 	}
 	return categorias, nil
 }
 
 func (r *CategoriaRepository) GetByID(id int) (*models.Categoria, error) {
+	if !config.ReadsFromPrimary(r.ctx) {
+		if v, ok := cache.Categorias.Get(cache.Key(uint(id))); ok {
+			categoria := v.(models.Categoria)
+			return &categoria, nil
+		}
+	}
+	// Gravado só se não houve invalidação durante a leitura (ver ItemRepository.store)
+	generation := cache.Categorias.Generation()
 	var categoria models.Categoria
-	if err := config.DB.First(&categoria, id).Error; err != nil {
+	if err := config.Reader(r.ctx).First(&categoria, id).Error; err != nil {
 		return nil, err
 	}
+	cache.Categorias.SetIfCurrent(cache.Key(categoria.Id), categoria, generation)
 	return &categoria, nil
 }
 
+// Create - Cria a categoria na raiz ou abaixo de ParentId
 func (r *CategoriaRepository) Create(categoria *models.Categoria) (*models.Categoria, error) {
-	if err := config.DB.Create(categoria).Error; err != nil {
+	if err := validarEsquema(categoria.Atributos); err != nil {
+		return nil, err
+	}
+	err := config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		if categoria.ParentId != nil {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockCategorias).Error; err != nil {
+				return err
+			}
+			if err := tx.First(&models.Categoria{}, *categoria.ParentId).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Create(categoria).Error; err != nil {
+			return err
+		}
+		return tx.Exec(`
+			INSERT INTO categorias_caminhos (ancestral_id, descendente_id, profundidade)
+			SELECT ancestral_id, ?, profundidade + 1 FROM categorias_caminhos WHERE descendente_id = ?
+			UNION ALL SELECT ?, ?, 0`,
+			categoria.Id, categoria.ParentId, categoria.Id, categoria.Id).Error
+	})
+	if err != nil {
 		return nil, err
 	}
 	return categoria, nil
 }
 
-func (r *CategoriaRepository) Update(categoria *models.Categoria) error {
-	return config.DB.Save(categoria).Error
+// Update - Atualiza a categoria. Com moverPai (parent_id enviado), uma
+// mudança de ParentId move a subárvore; sem ele, a categoria fica onde está
+func (r *CategoriaRepository) Update(categoria *models.Categoria, moverPai bool) error {
+	if err := validarEsquema(categoria.Atributos); err != nil {
+		return err
+	}
+	err := config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		var atual models.Categoria
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&atual, categoria.Id).Error; err != nil {
+			return err
+		}
+		if !moverPai {
+			categoria.ParentId = atual.ParentId
+		}
+		moveu := !mesmoPai(atual.ParentId, categoria.ParentId)
+		if moveu {
+			if err := mover(tx, categoria.Id, categoria.ParentId); err != nil {
+				return err
+			}
+		}
+		if err := tx.Save(categoria).Error; err != nil {
+			return err
+		}
+		if moveu || !reflect.DeepEqual(atual.Atributos, categoria.Atributos) {
+			return revalidarItens(tx, categoria.Id)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return cache.Invalidate(config.Writer(r.ctx), cache.Categorias, categoria.Id)
 }
 
+// Esquema - Atributos que valem para os itens da categoria, incluindo os
+// herdados das categorias acima dela
+func (r *CategoriaRepository) Esquema(id int) (models.EsquemaAtributos, error) {
+	return esquemaEfetivo(config.Reader(r.ctx), uint(id))
+}
+
+// Mover - Coloca a categoria, com as subcategorias, abaixo de parentID
+// (nil = raiz)
+func (r *CategoriaRepository) Mover(id int, parentID *uint) (*models.Categoria, error) {
+	var categoria models.Categoria
+	err := config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&categoria, id).Error; err != nil {
+			return err
+		}
+		if mesmoPai(categoria.ParentId, parentID) {
+			return nil
+		}
+		if err := mover(tx, categoria.Id, parentID); err != nil {
+			return err
+		}
+		categoria.ParentId = parentID
+		if err := tx.Model(&categoria).Update("parent_id", parentID).Error; err != nil {
+			return err
+		}
+		return revalidarItens(tx, categoria.Id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := cache.Invalidate(config.Writer(r.ctx), cache.Categorias, categoria.Id); err != nil {
+		return nil, err
+	}
+	return &categoria, nil
+}
+
+// Delete - Remove uma categoria sem subcategorias
 func (r *CategoriaRepository) Delete(id int) error {
-	return config.DB.Delete(&models.Categoria{}, id).Error
+	err := config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		var filhas int64
+		if err := tx.Model(&models.Categoria{}).Where("parent_id = ?", id).Count(&filhas).Error; err != nil {
+			return err
+		}
+		if filhas > 0 {
+			return ErrCategoriaComFilhas
+		}
+		if err := tx.Where("descendente_id = ?", id).Delete(&models.CategoriaCaminho{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Categoria{}, id).Error
+	})
+	if err != nil {
+		return err
+	}
+	return cache.Invalidate(config.Writer(r.ctx), cache.Categorias, uint(id))
+}
+
+// Arvore - Árvore de categorias ordenada por nome; com raiz, apenas a
+// subárvore dela
+func (r *CategoriaRepository) Arvore(raiz *uint) ([]*models.CategoriaNo, error) {
+	var categorias []models.Categoria
+	q := config.Reader(r.ctx).Order("categorias.nome, categorias.id")
+	if raiz != nil {
+		q = q.Joins("JOIN categorias_caminhos ON categorias_caminhos.descendente_id = categorias.id").
+			Where("categorias_caminhos.ancestral_id = ?", *raiz)
+	}
+	if err := q.Find(&categorias).Error; err != nil {
+		return nil, err
+	}
+	if raiz != nil && len(categorias) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	nos := make(map[uint]*models.CategoriaNo, len(categorias))
+	for _, categoria := range categorias {
+		nos[categoria.Id] = &models.CategoriaNo{Categoria: categoria, Filhas: []*models.CategoriaNo{}}
+	}
+	raizes := []*models.CategoriaNo{}
+	for _, categoria := range categorias {
+		no := nos[categoria.Id]
+		// Na subárvore, o pai da raiz não está entre as categorias lidas
+		if pai, ok := nos[derefID(categoria.ParentId)]; ok {
+			pai.Filhas = append(pai.Filhas, no)
+		} else {
+			raizes = append(raizes, no)
+		}
+	}
+	sort.SliceStable(raizes, func(i, j int) bool { return raizes[i].Nome < raizes[j].Nome })
+	return raizes, nil
+}
+
+// Caminho - Categorias da raiz até a informada (breadcrumb)
+func (r *CategoriaRepository) Caminho(id int) ([]models.Categoria, error) {
+	var categorias []models.Categoria
+	if err := config.Reader(r.ctx).
+		Joins("JOIN categorias_caminhos ON categorias_caminhos.ancestral_id = categorias.id").
+		Where("categorias_caminhos.descendente_id = ?", id).
+		Order("categorias_caminhos.profundidade DESC").Find(&categorias).Error; err != nil {
+		return nil, err
+	}
+	if len(categorias) == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return categorias, nil
+}
+
+// Descendentes - Todas as subcategorias, das mais próximas às mais distantes
+func (r *CategoriaRepository) Descendentes(id int) ([]models.Categoria, error) {
+	if _, err := r.GetByID(id); err != nil {
+		return nil, err
+	}
+	var categorias []models.Categoria
+	if err := config.Reader(r.ctx).
+		Joins("JOIN categorias_caminhos ON categorias_caminhos.descendente_id = categorias.id").
+		Where("categorias_caminhos.ancestral_id = ? AND categorias_caminhos.profundidade > 0", id).
+		Order("categorias_caminhos.profundidade, categorias.nome").Find(&categorias).Error; err != nil {
+		return nil, err
+	}
+	return categorias, nil
+}
+
+// mover - Refaz os caminhos da subárvore de id para ficar abaixo de parentID:
+// remove os que ligam a subárvore aos ancestrais antigos e liga cada
+// ancestral novo a cada nó da subárvore
+func mover(tx *gorm.DB, id uint, parentID *uint) error {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockCategorias).Error; err != nil {
+		return err
+	}
+	if parentID != nil {
+		if err := tx.First(&models.Categoria{}, *parentID).Error; err != nil {
+			return err
+		}
+		var ciclo int64
+		if err := tx.Model(&models.CategoriaCaminho{}).
+			Where("ancestral_id = ? AND descendente_id = ?", id, *parentID).Count(&ciclo).Error; err != nil {
+			return err
+		}
+		if ciclo > 0 {
+			return ErrCategoriaCiclo
+		}
+	}
+	if err := tx.Exec(`
+		DELETE FROM categorias_caminhos
+		WHERE descendente_id IN (SELECT descendente_id FROM categorias_caminhos WHERE ancestral_id = ?)
+		  AND ancestral_id NOT IN (SELECT descendente_id FROM categorias_caminhos WHERE ancestral_id = ?)`,
+		id, id).Error; err != nil {
+		return err
+	}
+	if parentID == nil {
+		return nil
+	}
+	return tx.Exec(`
+		INSERT INTO categorias_caminhos (ancestral_id, descendente_id, profundidade)
+		SELECT acima.ancestral_id, abaixo.descendente_id, acima.profundidade + abaixo.profundidade + 1
+		FROM categorias_caminhos acima CROSS JOIN categorias_caminhos abaixo
+		WHERE acima.descendente_id = ? AND abaixo.ancestral_id = ?`,
+		*parentID, id).Error
+}
+
+func mesmoPai(a, b *uint) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+func derefID(id *uint) uint {
+	if id == nil {
+		return 0
+	}
+	return *id
 }
@@ -0,0 +1,47 @@
+package repositories
+
+import (
+	"context"
+
+	"myapi/internal/config"
+	"myapi/internal/models"
+)
+
+type ClienteRepository struct {
+	ctx context.Context
+}
+
+func NewClienteRepository(ctx context.Context) *ClienteRepository {
+	return &ClienteRepository{ctx: ctx}
+}
+
+func (r *ClienteRepository) ListAll() ([]models.Cliente, error) {
+	var clientes []models.Cliente
+	if err := config.Reader(r.ctx).Find(&clientes).Error; err != nil {
+		return nil, err
+	}
+	return clientes, nil
+}
+
+func (r *ClienteRepository) GetByID(id int) (*models.Cliente, error) {
+	var cliente models.Cliente
+	if err := config.Reader(r.ctx).First(&cliente, id).Error; err != nil {
+		return nil, err
+	}
+	return &cliente, nil
+}
+
+func (r *ClienteRepository) Create(cliente *models.Cliente) (*models.Cliente, error) {
+	if err := config.Writer(r.ctx).Create(cliente).Error; err != nil {
+		return nil, err
+	}
+	return cliente, nil
+}
+
+func (r *ClienteRepository) Update(cliente *models.Cliente) error {
+	return config.Writer(r.ctx).Save(cliente).Error
+}
+
+func (r *ClienteRepository) Delete(id int) error {
+	return config.Writer(r.ctx).Delete(&models.Cliente{}, id).Error
+}
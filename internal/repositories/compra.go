@@ -0,0 +1,228 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"myapi/internal/cache"
+	"myapi/internal/config"
+	"myapi/internal/models"
+	"myapi/internal/money"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrTransicaoInvalida  = errors.New("transição de status não permitida")
+	ErrPedidoNaoEditavel  = errors.New("apenas pedidos em rascunho podem ser alterados")
+	ErrRecebimentoExcede  = errors.New("quantidade recebida excede a pendente na linha")
+	ErrLinhaNaoEncontrada = errors.New("linha não pertence ao pedido")
+	ErrRecebimentoCusto   = errors.New("informe o custo do recebimento: a linha do pedido não tem custo e o item não tem custo médio no depósito")
+)
+
+type CompraRepository struct {
+	ctx context.Context
+}
+
+func NewCompraRepository(ctx context.Context) *CompraRepository {
+	return &CompraRepository{ctx: ctx}
+}
+
+// ListAll - Lista os pedidos de compra, opcionalmente filtrados por status
+func (r *CompraRepository) ListAll(status string) ([]models.PedidoCompra, error) {
+	var pedidos []models.PedidoCompra
+	q := config.Reader(r.ctx).Preload("Itens").Order("id")
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	if err := q.Find(&pedidos).Error; err != nil {
+		return nil, err
+	}
+	return pedidos, nil
+}
+
+func (r *CompraRepository) GetByID(id int) (*models.PedidoCompra, error) {
+	var pedido models.PedidoCompra
+	if err := config.Reader(r.ctx).Preload("Fornecedor").Preload("Itens").First(&pedido, id).Error; err != nil {
+		return nil, err
+	}
+	return &pedido, nil
+}
+
+// Create - Cria o pedido em rascunho
+func (r *CompraRepository) Create(pedido *models.PedidoCompra) (*models.PedidoCompra, error) {
+	pedido.Id = 0
+	pedido.Status = models.CompraRascunho
+	for i := range pedido.Itens {
+		if pedido.Itens[i].Quantidade <= 0 {
+			return nil, ErrQuantidadeInvalida
+		}
+		pedido.Itens[i].Id = 0
+		pedido.Itens[i].QuantidadeRecebida = 0
+	}
+	if err := config.Writer(r.ctx).Create(pedido).Error; err != nil {
+		return nil, err
+	}
+	return pedido, nil
+}
+
+// Update - Substitui os dados e as linhas de um pedido em rascunho
+func (r *CompraRepository) Update(pedido *models.PedidoCompra) error {
+	return config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		var atual models.PedidoCompra
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&atual, pedido.Id).Error; err != nil {
+			return err
+		}
+		if atual.Status != models.CompraRascunho {
+			return ErrPedidoNaoEditavel
+		}
+		if err := tx.Where("pedido_compra_id = ?", pedido.Id).Delete(&models.PedidoCompraItem{}).Error; err != nil {
+			return err
+		}
+		pedido.Status = models.CompraRascunho
+		pedido.CriadoEm = atual.CriadoEm
+		for i := range pedido.Itens {
+			if pedido.Itens[i].Quantidade <= 0 {
+				return ErrQuantidadeInvalida
+			}
+			pedido.Itens[i].Id = 0
+			pedido.Itens[i].PedidoCompraId = pedido.Id
+			pedido.Itens[i].QuantidadeRecebida = 0
+		}
+		return tx.Session(&gorm.Session{FullSaveAssociations: true}).Save(pedido).Error
+	})
+}
+
+// MudarStatus - Aplica uma transição manual de status (enviar, cancelar)
+func (r *CompraRepository) MudarStatus(id int, status string) (*models.PedidoCompra, error) {
+	var pedido models.PedidoCompra
+	err := config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&pedido, id).Error; err != nil {
+			return err
+		}
+		if !pedido.PodeMudarPara(status) {
+			return ErrTransicaoInvalida
+		}
+		pedido.Status = status
+		return tx.Model(&pedido).Update("status", status).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(id)
+}
+
+// LinhaRecebimento - Quantidade recebida de uma linha e o custo efetivo
+type LinhaRecebimento struct {
+	PedidoCompraItemId uint         `json:"pedido_compra_item_id"`
+	Quantidade         int          `json:"quantidade"`
+	CustoUnitario      *money.Money `json:"custo_unitario"`
+	LocalizacaoId      *uint        `json:"localizacao_id"`
+	LoteId             *uint        `json:"lote_id"`
+	Series             []string     `json:"series"`
+}
+
+// Receber - Registra o recebimento (parcial ou total) de linhas do pedido:
+// lança as entradas de estoque no depósito do pedido, grava o custo efetivo
+// e avança o status para parcialmente recebido ou recebido
+func (r *CompraRepository) Receber(id int, linhas []LinhaRecebimento) ([]models.RecebimentoCompra, error) {
+	var recebimentos []models.RecebimentoCompra
+	var itemIDs []uint
+	err := config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		var pedido models.PedidoCompra
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Itens").First(&pedido, id).Error; err != nil {
+			return err
+		}
+		if pedido.Status != models.CompraEnviado && pedido.Status != models.CompraParcialmenteRecebido {
+			return ErrTransicaoInvalida
+		}
+
+		itens := make(map[uint]*models.PedidoCompraItem, len(pedido.Itens))
+		for i := range pedido.Itens {
+			itens[pedido.Itens[i].Id] = &pedido.Itens[i]
+		}
+		for _, linha := range linhas {
+			item, ok := itens[linha.PedidoCompraItemId]
+			if !ok {
+				return ErrLinhaNaoEncontrada
+			}
+			if linha.Quantidade <= 0 {
+				return ErrQuantidadeInvalida
+			}
+			if item.QuantidadeRecebida+linha.Quantidade > item.Quantidade {
+				return ErrRecebimentoExcede
+			}
+
+			// Sem custo informado no recebimento, vale o custo do pedido; sem
+			// nenhum dos dois, a entrada usa o custo médio do saldo
+			custo := linha.CustoUnitario
+			if custo == nil && !item.CustoUnitario.IsZero() {
+				custoPedido := item.CustoUnitario
+				custo = &custoPedido
+			}
+			mov := models.Movimentacao{
+				ItemId:        item.ItemId,
+				CustoUnitario: custo,
+				DepositoId:    pedido.DepositoId,
+				LocalizacaoId: linha.LocalizacaoId,
+				LoteId:        linha.LoteId,
+				Tipo:          models.MovimentacaoEntrada,
+				Quantidade:    linha.Quantidade,
+				Referencia:    fmt.Sprintf("PC-%d", pedido.Id),
+				Series:        linha.Series,
+			}
+			if err := Movimentar(tx, &mov); err != nil {
+				return err
+			}
+			if mov.CustoUnitario == nil || custo == nil && mov.CustoUnitario.IsZero() {
+				return ErrRecebimentoCusto
+			}
+			item.QuantidadeRecebida += linha.Quantidade
+			if err := tx.Model(item).Update("quantidade_recebida", item.QuantidadeRecebida).Error; err != nil {
+				return err
+			}
+			recebimento := models.RecebimentoCompra{
+				PedidoCompraItemId: item.Id,
+				MovimentacaoId:     mov.Id,
+				Quantidade:         linha.Quantidade,
+				CustoUnitario:      *mov.CustoUnitario,
+			}
+			if err := tx.Create(&recebimento).Error; err != nil {
+				return err
+			}
+			recebimentos = append(recebimentos, recebimento)
+			itemIDs = append(itemIDs, item.ItemId)
+		}
+
+		status := models.CompraRecebido
+		for _, item := range pedido.Itens {
+			if item.QuantidadeRecebida < item.Quantidade {
+				status = models.CompraParcialmenteRecebido
+			}
+		}
+		return tx.Model(&pedido).Update("status", status).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, itemID := range itemIDs {
+		if err := cache.Invalidate(config.Writer(r.ctx), cache.Itens, itemID); err != nil {
+			return nil, err
+		}
+	}
+	return recebimentos, nil
+}
+
+// Recebimentos - Histórico de recebimentos das linhas do pedido
+func (r *CompraRepository) Recebimentos(id int) ([]models.RecebimentoCompra, error) {
+	var recebimentos []models.RecebimentoCompra
+	if err := config.Reader(r.ctx).
+		Joins("JOIN pedidos_compra_itens ON pedidos_compra_itens.id = recebimentos_compra.pedido_compra_item_id").
+		Where("pedidos_compra_itens.pedido_compra_id = ?", id).
+		Order("recebimentos_compra.id").Find(&recebimentos).Error; err != nil {
+		return nil, err
+	}
+	return recebimentos, nil
+}
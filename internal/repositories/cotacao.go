@@ -0,0 +1,98 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"myapi/internal/config"
+	"myapi/internal/decimal"
+	"myapi/internal/models"
+	"myapi/internal/money"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrCotacaoInvalida = errors.New("cotação inválida")
+	ErrSemCotacao      = errors.New("sem cotação para a moeda na data")
+	ErrMoedaInvalida   = errors.New("moeda deve ser um código ISO 4217 (ex: BRL, USD)")
+)
+
+type CotacaoRepository struct {
+	ctx context.Context
+}
+
+func NewCotacaoRepository(ctx context.Context) *CotacaoRepository {
+	return &CotacaoRepository{ctx: ctx}
+}
+
+// List - Cotações, da mais recente para a mais antiga, opcionalmente de uma moeda
+func (r *CotacaoRepository) List(moeda string) ([]models.Cotacao, error) {
+	query := config.Reader(r.ctx).Order("data DESC, moeda")
+	if moeda != "" {
+		query = query.Where("moeda = ?", moeda)
+	}
+	var cotacoes []models.Cotacao
+	if err := query.Find(&cotacoes).Error; err != nil {
+		return nil, err
+	}
+	return cotacoes, nil
+}
+
+// Salvar - Grava as cotações em uma transação; uma cotação já existente para
+// a moeda e a data tem a taxa substituída
+func (r *CotacaoRepository) Salvar(cotacoes []models.Cotacao) error {
+	for i := range cotacoes {
+		if err := validarCotacao(&cotacoes[i]); err != nil {
+			return err
+		}
+	}
+	if len(cotacoes) == 0 {
+		return nil
+	}
+	return config.Writer(r.ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "moeda"}, {Name: "data"}},
+		DoUpdates: clause.AssignmentColumns([]string{"taxa"}),
+	}).Create(&cotacoes).Error
+}
+
+func (r *CotacaoRepository) Delete(id int) error {
+	return config.Writer(r.ctx).Delete(&models.Cotacao{}, id).Error
+}
+
+// Taxa - Taxa da moeda em relação à moeda padrão na data: a cotação da data
+// ou a mais recente anterior. A moeda padrão tem taxa 1.
+func (r *CotacaoRepository) Taxa(moeda string, em time.Time) (decimal.Decimal, error) {
+	if moeda == money.MoedaPadrao() {
+		return decimal.NewFromInt(1), nil
+	}
+	var cotacao models.Cotacao
+	err := config.Reader(r.ctx).Where("moeda = ? AND data <= ?", moeda, em.Format(time.DateOnly)).
+		Order("data DESC").First(&cotacao).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return decimal.Decimal{}, fmt.Errorf("%w: %s em %s", ErrSemCotacao, moeda, em.Format(time.DateOnly))
+	}
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	return cotacao.Taxa, nil
+}
+
+// validarCotacao - Confere a moeda, a data e a taxa
+func validarCotacao(cotacao *models.Cotacao) error {
+	cotacao.Id = 0
+	switch {
+	case !money.CodigoValido(cotacao.Moeda):
+		return fmt.Errorf("%w: moeda %q", ErrCotacaoInvalida, cotacao.Moeda)
+	case cotacao.Moeda == money.MoedaPadrao():
+		return fmt.Errorf("%w: a moeda padrão (%s) tem taxa fixa 1", ErrCotacaoInvalida, cotacao.Moeda)
+	case cotacao.Data.IsZero():
+		return fmt.Errorf("%w: data obrigatória", ErrCotacaoInvalida)
+	case cotacao.Taxa.Sign() <= 0:
+		return fmt.Errorf("%w: taxa deve ser maior que zero", ErrCotacaoInvalida)
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"myapi/internal/config"
+	"myapi/internal/models"
+	"myapi/internal/money"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CustoRepository struct {
+	ctx context.Context
+}
+
+func NewCustoRepository(ctx context.Context) *CustoRepository {
+	return &CustoRepository{ctx: ctx}
+}
+
+// LinhaValorizacao - Quantidade e valor do estoque de uma categoria em um depósito
+type LinhaValorizacao struct {
+	CategoriaId *uint       `json:"categoria_id"`
+	DepositoId  uint        `json:"deposito_id"`
+	Quantidade  int         `json:"quantidade"`
+	Valor       money.Money `json:"valor"`
+}
+
+// Valorizacao - Estoque e valor por categoria e depósito no fim do dia
+// informado, somando as movimentações (que registram o custo de cada
+// entrada e saída) até essa data
+func (r *CustoRepository) Valorizacao(data time.Time) ([]LinhaValorizacao, error) {
+	var linhas []LinhaValorizacao
+	err := config.Reader(r.ctx).Table("movimentacoes").
+		Select("itens.categoria_id, movimentacoes.deposito_id, SUM(movimentacoes.quantidade) AS quantidade, SUM(movimentacoes.valor) AS valor").
+		Joins("LEFT JOIN itens ON itens.id = movimentacoes.item_id").
+		Where("movimentacoes.criado_em < ?", data.AddDate(0, 0, 1)).
+		Group("itens.categoria_id, movimentacoes.deposito_id").
+		Having("SUM(movimentacoes.quantidade) <> 0 OR SUM(movimentacoes.valor) <> 0").
+		Order("itens.categoria_id, movimentacoes.deposito_id").
+		Scan(&linhas).Error
+	return linhas, err
+}
+
+// LinhaCMV - Custo das mercadorias vendidas de um item no período
+type LinhaCMV struct {
+	ItemId     uint        `json:"item_id"`
+	Codigo     string      `json:"codigo"`
+	Nome       string      `json:"nome"`
+	Quantidade int         `json:"quantidade"`
+	Valor      money.Money `json:"valor"`
+}
+
+// CMV - Custo das saídas por item entre as datas (inclusive)
+func (r *CustoRepository) CMV(de, ate time.Time) ([]LinhaCMV, error) {
+	var linhas []LinhaCMV
+	err := config.Reader(r.ctx).Table("movimentacoes").
+		Select("movimentacoes.item_id, itens.codigo, itens.nome, -SUM(movimentacoes.quantidade) AS quantidade, -SUM(movimentacoes.valor) AS valor").
+		Joins("LEFT JOIN itens ON itens.id = movimentacoes.item_id").
+		Where("movimentacoes.tipo = ? AND movimentacoes.criado_em >= ? AND movimentacoes.criado_em < ?",
+			models.MovimentacaoSaida, de, ate.AddDate(0, 0, 1)).
+		Group("movimentacoes.item_id, itens.codigo, itens.nome").
+		Order("itens.codigo").
+		Scan(&linhas).Error
+	return linhas, err
+}
+
+// custear - Calcula o custo de uma movimentação já gravada e atualiza as
+// bases de custo do item no depósito. saldo é o saldo antes da movimentação.
+//
+// Entradas usam o custo unitário informado ou, sem ele, o custo médio atual
+// (Valor já preenchido, como nas transferências, prevalece). Saídas são
+// valorizadas pelo custo médio ou pelas camadas FIFO, conforme CUSTO_METODO.
+// O custo médio e as camadas são sempre mantidos, então trocar o método vale
+// a partir das próximas saídas.
+func custear(tx *gorm.DB, mov *models.Movimentacao, saldo *models.EstoqueDeposito) error {
+	var valorMedio, valorFifo money.Money
+	switch {
+	case mov.Quantidade > 0:
+		valor := mov.Valor
+		if valor.IsZero() {
+			valor = custoEntrada(mov, saldo).MulInt(int64(mov.Quantidade)).Arredondar(money.RegraPreco)
+		}
+		movID := mov.Id
+		camada := models.CamadaCusto{
+			ItemId:         mov.ItemId,
+			DepositoId:     mov.DepositoId,
+			MovimentacaoId: &movID,
+			Quantidade:     mov.Quantidade,
+			Restante:       mov.Quantidade,
+			Valor:          valor,
+			ValorRestante:  valor,
+		}
+		if err := tx.Create(&camada).Error; err != nil {
+			return err
+		}
+		valorMedio, valorFifo = valor, valor
+	case mov.Quantidade < 0:
+		quantidade := -mov.Quantidade
+		valorMedio = saldo.Valor.Proporcional(quantidade, saldo.Quantidade, money.RegraPreco).Neg()
+		consumido, err := consumirCamadas(tx, mov.ItemId, mov.DepositoId, quantidade)
+		if err != nil {
+			return err
+		}
+		valorFifo = consumido.Neg()
+	}
+
+	if err := tx.Model(&models.EstoqueDeposito{}).
+		Where("item_id = ? AND deposito_id = ?", mov.ItemId, mov.DepositoId).
+		Update("valor", gorm.Expr("valor + ?", valorMedio)).Error; err != nil {
+		return err
+	}
+	mov.Valor = valorMedio
+	if config.LoadEstoqueConfig().CustoMetodo == models.CustoFifo {
+		mov.Valor = valorFifo
+	}
+	if mov.Quantidade < 0 || mov.CustoUnitario == nil && mov.Quantidade != 0 {
+		unitario := mov.Valor.Abs().DivInt(int64(abs(mov.Quantidade)), money.RegraCusto)
+		mov.CustoUnitario = &unitario
+	}
+	return tx.Model(&models.Movimentacao{}).Where("id = ?", mov.Id).
+		Updates(map[string]any{"custo_unitario": mov.CustoUnitario, "valor": mov.Valor}).Error
+}
+
+// custoEntrada - Custo unitário de uma entrada
+func custoEntrada(mov *models.Movimentacao, saldo *models.EstoqueDeposito) money.Money {
+	if mov.CustoUnitario != nil {
+		return *mov.CustoUnitario
+	}
+	if saldo.Quantidade > 0 {
+		return saldo.Valor.DivInt(int64(saldo.Quantidade), money.RegraCusto)
+	}
+	return money.Money{}
+}
+
+// consumirCamadas - Baixa a quantidade das camadas mais antigas do item no
+// depósito e devolve o valor consumido
+func consumirCamadas(tx *gorm.DB, itemID, depositoID uint, quantidade int) (money.Money, error) {
+	var camadas []models.CamadaCusto
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("item_id = ? AND deposito_id = ? AND restante > 0", itemID, depositoID).
+		Order("criado_em, id").Find(&camadas).Error; err != nil {
+		return money.Money{}, err
+	}
+	var total money.Money
+	for _, camada := range camadas {
+		if quantidade == 0 {
+			break
+		}
+		baixa := min(camada.Restante, quantidade)
+		valor := camada.ValorRestante.Proporcional(baixa, camada.Restante, money.RegraPreco)
+		if err := tx.Model(&models.CamadaCusto{}).Where("id = ?", camada.Id).Updates(map[string]any{
+			"restante":       camada.Restante - baixa,
+			"valor_restante": camada.ValorRestante.Sub(valor),
+		}).Error; err != nil {
+			return money.Money{}, err
+		}
+		total = total.Add(valor)
+		quantidade -= baixa
+	}
+	return total, nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
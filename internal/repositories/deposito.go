@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"myapi/internal/config"
+	"myapi/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrDepositoComSaldo = errors.New("o depósito tem saldo ou reserva de itens e não pode ser removido")
+
+type DepositoRepository struct {
+	ctx context.Context
+}
+
+func NewDepositoRepository(ctx context.Context) *DepositoRepository {
+	return &DepositoRepository{ctx: ctx}
+}
+
+func (r *DepositoRepository) ListAll() ([]models.Deposito, error) {
+	var depositos []models.Deposito
+	if err := config.Reader(r.ctx).Find(&depositos).Error; err != nil {
+		return nil, err
+	}
+	return depositos, nil
+}
+
+func (r *DepositoRepository) GetByID(id int) (*models.Deposito, error) {
+	var deposito models.Deposito
+	if err := config.Reader(r.ctx).First(&deposito, id).Error; err != nil {
+		return nil, err
+	}
+	return &deposito, nil
+}
+
+func (r *DepositoRepository) Create(deposito *models.Deposito) (*models.Deposito, error) {
+	if err := config.Writer(r.ctx).Create(deposito).Error; err != nil {
+		return nil, err
+	}
+	return deposito, nil
+}
+
+func (r *DepositoRepository) Update(deposito *models.Deposito) error {
+	return config.Writer(r.ctx).Save(deposito).Error
+}
+
+// Delete - Remove o depósito e os seus saldos zerados. O bloqueio do
+// depósito espera as movimentações em andamento (lockSaldo)
+func (r *DepositoRepository) Delete(id int) error {
+	return config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		var deposito models.Deposito
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&deposito, id).Error; err != nil {
+			return err
+		}
+		var comSaldo int64
+		if err := tx.Model(&models.EstoqueDeposito{}).
+			Where("deposito_id = ? AND (quantidade <> 0 OR reservado <> 0)", id).Count(&comSaldo).Error; err != nil {
+			return err
+		}
+		if comSaldo > 0 {
+			return ErrDepositoComSaldo
+		}
+		if err := tx.Where("deposito_id = ?", id).Delete(&models.EstoqueDeposito{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&deposito).Error
+	})
+}
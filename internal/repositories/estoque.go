@@ -0,0 +1,239 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"myapi/internal/cache"
+	"myapi/internal/config"
+	"myapi/internal/models"
+	"myapi/internal/money"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrEstoqueInsuficiente = errors.New("estoque insuficiente")
+	ErrQuantidadeInvalida  = errors.New("quantidade deve ser maior que zero")
+	ErrMesmoDeposito       = errors.New("depósitos de origem e destino devem ser diferentes")
+)
+
+type EstoqueRepository struct {
+	ctx context.Context
+}
+
+func NewEstoqueRepository(ctx context.Context) *EstoqueRepository {
+	return &EstoqueRepository{ctx: ctx}
+}
+
+// ListByItem - Saldos do item em cada depósito
+func (r *EstoqueRepository) ListByItem(itemID int) ([]models.EstoqueDeposito, error) {
+	var saldos []models.EstoqueDeposito
+	if err := config.Reader(r.ctx).Preload("Deposito").Where("item_id = ?", itemID).
+		Order("deposito_id").Find(&saldos).Error; err != nil {
+		return nil, err
+	}
+	return saldos, nil
+}
+
+// ListByItens - Saldos dos itens em todos os depósitos
+func (r *EstoqueRepository) ListByItens(itemIDs []uint) ([]models.EstoqueDeposito, error) {
+	var saldos []models.EstoqueDeposito
+	if len(itemIDs) == 0 {
+		return saldos, nil
+	}
+	if err := config.Reader(r.ctx).Where("item_id IN ?", itemIDs).Find(&saldos).Error; err != nil {
+		return nil, err
+	}
+	return saldos, nil
+}
+
+// Movimentar - Registra uma entrada (quantidade positiva) ou saída (negativa)
+func (r *EstoqueRepository) Movimentar(mov *models.Movimentacao) error {
+	err := config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		return Movimentar(tx, mov)
+	})
+	if err != nil {
+		return err
+	}
+	return cache.Invalidate(config.Writer(r.ctx), cache.Itens, mov.ItemId)
+}
+
+// Transferir - Move a quantidade entre dois depósitos de forma atômica. Os
+// lotes consumidos na origem entram com o mesmo lote no destino; itens
+// serializados informam os números de série transferidos.
+func (r *EstoqueRepository) Transferir(itemID, origemID, destinoID uint, quantidade int, series []string) ([]models.Movimentacao, error) {
+	if quantidade <= 0 {
+		return nil, ErrQuantidadeInvalida
+	}
+	if origemID == destinoID {
+		return nil, ErrMesmoDeposito
+	}
+	referencia := fmt.Sprintf("TRF-%d", time.Now().UnixNano())
+	var movs []models.Movimentacao
+	err := config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		// Bloqueia os saldos sempre na mesma ordem para evitar deadlock entre
+		// transferências opostas
+		first, second := origemID, destinoID
+		if first > second {
+			first, second = second, first
+		}
+		for _, depositoID := range []uint{first, second} {
+			if _, err := lockSaldo(tx, itemID, depositoID); err != nil {
+				return err
+			}
+		}
+		saida := models.Movimentacao{ItemId: itemID, DepositoId: origemID, Tipo: models.MovimentacaoTransferencia, Quantidade: -quantidade, Referencia: referencia, Series: series}
+		if err := Movimentar(tx, &saida); err != nil {
+			return err
+		}
+		movs = append(movs, saida)
+
+		semLote := quantidade
+		entradas := make([]models.Movimentacao, 0, len(saida.Lotes)+1)
+		for _, l := range saida.Lotes {
+			loteID := l.LoteId
+			entradas = append(entradas, models.Movimentacao{LoteId: &loteID, Quantidade: -l.Quantidade})
+			semLote += l.Quantidade
+		}
+		if semLote > 0 {
+			entradas = append(entradas, models.Movimentacao{Quantidade: semLote})
+		}
+		// Os números de série acompanham as entradas na ordem informada, e o
+		// custo da saída é repartido entre elas sem sobras de arredondamento
+		restantes := saida.Series
+		valorRestante, quantidadeRestante := saida.Valor.Neg(), quantidade
+		for _, entrada := range entradas {
+			entrada.ItemId, entrada.DepositoId = itemID, destinoID
+			entrada.Tipo, entrada.Referencia = models.MovimentacaoTransferencia, referencia
+			entrada.CustoUnitario = saida.CustoUnitario
+			entrada.Valor = valorRestante.Proporcional(entrada.Quantidade, quantidadeRestante, money.RegraPreco)
+			valorRestante, quantidadeRestante = valorRestante.Sub(entrada.Valor), quantidadeRestante-entrada.Quantidade
+			if len(restantes) > 0 {
+				entrada.Series, restantes = restantes[:entrada.Quantidade], restantes[entrada.Quantidade:]
+			}
+			if err := Movimentar(tx, &entrada); err != nil {
+				return err
+			}
+			movs = append(movs, entrada)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return movs, nil
+}
+
+// Movimentar - Aplica uma movimentação dentro da transação tx: bloqueia o
+// saldo do item no depósito, impede saída maior que o disponível, ajusta as
+// posições e os lotes, atualiza o total do item, grava o registro da
+// movimentação com o seu custo e os números de série. Kits e produtos com
+// variantes não têm estoque próprio; a movimentação de uma variante também
+// atualiza o total do produto, bloqueado depois do saldo e antes da
+// variante (ver travarProduto).
+func Movimentar(tx *gorm.DB, mov *models.Movimentacao) error {
+	var item models.Iten
+	if err := tx.Select("id", "controla_lote", "serializado", "dimensoes", "produto_id").First(&item, mov.ItemId).Error; err != nil {
+		return err
+	}
+	if len(item.Dimensoes) > 0 {
+		return ErrItemProduto
+	}
+	kit, err := ehKit(tx, item.Id)
+	if err != nil {
+		return err
+	}
+	if kit {
+		return ErrItemKit
+	}
+
+	saldo, err := lockSaldo(tx, mov.ItemId, mov.DepositoId)
+	if err != nil {
+		return err
+	}
+	if err := travarProduto(tx, item.ProdutoId); err != nil {
+		return err
+	}
+	// Saídas não podem consumir estoque reservado
+	if mov.Quantidade < 0 && saldo.Disponivel()+mov.Quantidade < 0 {
+		return ErrEstoqueInsuficiente
+	}
+	if err := tx.Model(&models.EstoqueDeposito{}).
+		Where("item_id = ? AND deposito_id = ?", mov.ItemId, mov.DepositoId).
+		Update("quantidade", gorm.Expr("quantidade + ?", mov.Quantidade)).Error; err != nil {
+		return err
+	}
+	if err := ajustarLocalizacoes(tx, mov); err != nil {
+		return err
+	}
+	if err := ajustarLotes(tx, &item, mov); err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Iten{}).Where("id = ?", mov.ItemId).
+		Update("quantidade", gorm.Expr("quantidade + ?", mov.Quantidade)).Error; err != nil {
+		return err
+	}
+	if err := atualizarProduto(tx, mov); err != nil {
+		return err
+	}
+	if err := tx.Create(mov).Error; err != nil {
+		return err
+	}
+	if err := custear(tx, mov, saldo); err != nil {
+		return err
+	}
+	return registrarSeries(tx, &item, mov)
+}
+
+// atualizarProduto - Soma a movimentação de uma variante ao produto. O
+// produto é relido depois de o item ser bloqueado pela atualização, para não
+// perder uma variante ligada durante a movimentação (nesse caso quem a ligou
+// já terminou e liberou o produto); o aviso de invalidação do produto só sai
+// após o commit.
+func atualizarProduto(tx *gorm.DB, mov *models.Movimentacao) error {
+	var variante models.Iten
+	if err := tx.Select("id", "produto_id").First(&variante, mov.ItemId).Error; err != nil {
+		return err
+	}
+	if variante.ProdutoId == nil {
+		return nil
+	}
+	if err := somarAoProduto(tx, *variante.ProdutoId, mov.Quantidade); err != nil {
+		return err
+	}
+	return cache.Invalidate(tx, cache.Itens, *variante.ProdutoId)
+}
+
+// lockSaldo - Garante que a linha de saldo exista e a bloqueia até o fim da
+// transação. O depósito precisa existir e fica bloqueado contra remoção.
+func lockSaldo(tx *gorm.DB, itemID, depositoID uint) (*models.EstoqueDeposito, error) {
+	if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).Select("id").
+		First(&models.Deposito{}, depositoID).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.EstoqueDeposito{ItemId: itemID, DepositoId: depositoID}).Error; err != nil {
+		return nil, err
+	}
+	var saldo models.EstoqueDeposito
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("item_id = ? AND deposito_id = ?", itemID, depositoID).First(&saldo).Error; err != nil {
+		return nil, err
+	}
+	return &saldo, nil
+}
+
+// DepositoPadrao - Depósito usado quando a quantidade do item é alterada
+// diretamente (POST/PUT /api/itens), para manter o total igual à soma dos saldos
+func DepositoPadrao(tx *gorm.DB) (*models.Deposito, error) {
+	deposito := models.Deposito{Codigo: config.LoadEstoqueConfig().DepositoPadrao}
+	if err := tx.Where(models.Deposito{Codigo: deposito.Codigo}).
+		Attrs(models.Deposito{Nome: "Depósito padrão"}).FirstOrCreate(&deposito).Error; err != nil {
+		return nil, err
+	}
+	return &deposito, nil
+}
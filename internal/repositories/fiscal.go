@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"myapi/internal/config"
+	"myapi/internal/decimal"
+	"myapi/internal/fiscal"
+	"myapi/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrDadosFiscais = errors.New("dados fiscais inválidos")
+	ErrUFInvalida   = errors.New("UF inválida")
+)
+
+type AliquotaIcmsRepository struct {
+	ctx context.Context
+}
+
+func NewAliquotaIcmsRepository(ctx context.Context) *AliquotaIcmsRepository {
+	return &AliquotaIcmsRepository{ctx: ctx}
+}
+
+// ListAll - Alíquotas cadastradas; os pares de UFs ausentes usam a tabela padrão
+func (r *AliquotaIcmsRepository) ListAll() ([]models.AliquotaIcms, error) {
+	var aliquotas []models.AliquotaIcms
+	if err := config.Reader(r.ctx).Order("uf_origem, uf_destino").Find(&aliquotas).Error; err != nil {
+		return nil, err
+	}
+	return aliquotas, nil
+}
+
+// Save - Define a alíquota entre duas UFs
+func (r *AliquotaIcmsRepository) Save(aliquota *models.AliquotaIcms) error {
+	if !fiscal.UFValida(aliquota.UfOrigem) || !fiscal.UFValida(aliquota.UfDestino) {
+		return ErrUFInvalida
+	}
+	if err := validarAliquota("aliquota", &aliquota.Aliquota); err != nil {
+		return err
+	}
+	return config.Writer(r.ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "uf_origem"}, {Name: "uf_destino"}},
+		DoUpdates: clause.AssignmentColumns([]string{"aliquota"}),
+	}).Create(aliquota).Error
+}
+
+// Delete - Volta o par de UFs para a alíquota padrão
+func (r *AliquotaIcmsRepository) Delete(origem, destino string) error {
+	return config.Writer(r.ctx).Where("uf_origem = ? AND uf_destino = ?", origem, destino).
+		Delete(&models.AliquotaIcms{}).Error
+}
+
+// Buscar - Alíquota cadastrada entre as UFs; nil se o par usa a tabela padrão
+func (r *AliquotaIcmsRepository) Buscar(origem, destino string) (*decimal.Decimal, error) {
+	var aliquota models.AliquotaIcms
+	err := config.Reader(r.ctx).Where("uf_origem = ? AND uf_destino = ?", origem, destino).First(&aliquota).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &aliquota.Aliquota, nil
+}
+
+// validarFiscal - Normaliza os códigos e confere os dados fiscais do item;
+// campos vazios são aceitos para não bloquear itens ainda sem classificação
+func validarFiscal(dados *models.DadosFiscais) error {
+	dados.Ncm = fiscal.Normalizar(dados.Ncm)
+	dados.Cest = fiscal.Normalizar(dados.Cest)
+	dados.CfopEstadual = fiscal.Normalizar(dados.CfopEstadual)
+	dados.CfopInterestadual = fiscal.Normalizar(dados.CfopInterestadual)
+	switch {
+	case dados.Ncm != "" && !fiscal.NcmValido(dados.Ncm):
+		return fmt.Errorf("%w: NCM %s não encontrado na tabela", ErrDadosFiscais, dados.Ncm)
+	case dados.Cest != "" && !fiscal.CestValido(dados.Cest):
+		return fmt.Errorf("%w: CEST deve ter 7 dígitos", ErrDadosFiscais)
+	case dados.Cest != "" && dados.Ncm == "":
+		return fmt.Errorf("%w: CEST exige o NCM", ErrDadosFiscais)
+	case dados.CfopEstadual != "" && !fiscal.CfopValido(dados.CfopEstadual, "5"):
+		return fmt.Errorf("%w: CFOP estadual deve ter 4 dígitos e começar com 5", ErrDadosFiscais)
+	case dados.CfopInterestadual != "" && !fiscal.CfopValido(dados.CfopInterestadual, "6"):
+		return fmt.Errorf("%w: CFOP interestadual deve ter 4 dígitos e começar com 6", ErrDadosFiscais)
+	case !fiscal.OrigemValida(dados.Origem):
+		return fmt.Errorf("%w: origem deve estar entre 0 e 8", ErrDadosFiscais)
+	}
+	aliquotas := []struct {
+		nome  string
+		valor *decimal.Decimal
+	}{
+		{"aliquota_icms", dados.AliquotaIcms}, {"aliquota_ipi", dados.AliquotaIpi},
+		{"aliquota_pis", dados.AliquotaPis}, {"aliquota_cofins", dados.AliquotaCofins},
+	}
+	for _, aliquota := range aliquotas {
+		if err := validarAliquota(aliquota.nome, aliquota.valor); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validarAliquota - Percentual entre 0 e 100
+func validarAliquota(nome string, aliquota *decimal.Decimal) error {
+	if aliquota != nil && (aliquota.Sign() < 0 || aliquota.Cmp(cem) > 0) {
+		return fmt.Errorf("%w: %s deve estar entre 0 e 100", ErrDadosFiscais, nome)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"context"
+
+	"myapi/internal/config"
+	"myapi/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type FornecedorRepository struct {
+	ctx context.Context
+}
+
+func NewFornecedorRepository(ctx context.Context) *FornecedorRepository {
+	return &FornecedorRepository{ctx: ctx}
+}
+
+func (r *FornecedorRepository) ListAll() ([]models.Fornecedor, error) {
+	var fornecedores []models.Fornecedor
+	if err := config.Reader(r.ctx).Find(&fornecedores).Error; err != nil {
+		return nil, err
+	}
+	return fornecedores, nil
+}
+
+func (r *FornecedorRepository) GetByID(id int) (*models.Fornecedor, error) {
+	var fornecedor models.Fornecedor
+	if err := config.Reader(r.ctx).First(&fornecedor, id).Error; err != nil {
+		return nil, err
+	}
+	return &fornecedor, nil
+}
+
+func (r *FornecedorRepository) Create(fornecedor *models.Fornecedor) (*models.Fornecedor, error) {
+	if err := config.Writer(r.ctx).Create(fornecedor).Error; err != nil {
+		return nil, err
+	}
+	return fornecedor, nil
+}
+
+func (r *FornecedorRepository) Update(fornecedor *models.Fornecedor) error {
+	return config.Writer(r.ctx).Save(fornecedor).Error
+}
+
+func (r *FornecedorRepository) Delete(id int) error {
+	return config.Writer(r.ctx).Delete(&models.Fornecedor{}, id).Error
+}
+
+// GetByCnpj - Fornecedor pelo CNPJ, comparando apenas os dígitos
+func (r *FornecedorRepository) GetByCnpj(cnpj string) (*models.Fornecedor, error) {
+	var fornecedor models.Fornecedor
+	if err := config.Reader(r.ctx).Where("regexp_replace(cnpj, '[^0-9]', '', 'g') = ?", cnpj).
+		Order("id").First(&fornecedor).Error; err != nil {
+		return nil, err
+	}
+	return &fornecedor, nil
+}
+
+// Codigos - Códigos com que o fornecedor identifica os itens nas notas
+func (r *FornecedorRepository) Codigos(fornecedorID int) ([]models.ItemFornecedor, error) {
+	var vinculos []models.ItemFornecedor
+	if err := config.Reader(r.ctx).Where("fornecedor_id = ?", fornecedorID).Order("codigo").Find(&vinculos).Error; err != nil {
+		return nil, err
+	}
+	return vinculos, nil
+}
+
+// SaveCodigo - Associa o código do fornecedor a um item, substituindo a associação anterior
+func (r *FornecedorRepository) SaveCodigo(vinculo *models.ItemFornecedor) error {
+	return config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.Fornecedor{}, vinculo.FornecedorId).Error; err != nil {
+			return err
+		}
+		if err := tx.Select("id").First(&models.Iten{}, vinculo.ItemId).Error; err != nil {
+			return err
+		}
+		return salvarCodigoFornecedor(tx, vinculo)
+	})
+}
+
+// DeleteCodigo - Remove a associação de um código do fornecedor
+func (r *FornecedorRepository) DeleteCodigo(fornecedorID int, codigo string) error {
+	return config.Writer(r.ctx).Where("fornecedor_id = ? AND codigo = ?", fornecedorID, codigo).
+		Delete(&models.ItemFornecedor{}).Error
+}
+
+func salvarCodigoFornecedor(tx *gorm.DB, vinculo *models.ItemFornecedor) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "fornecedor_id"}, {Name: "codigo"}},
+		DoUpdates: clause.AssignmentColumns([]string{"item_id"}),
+	}).Create(vinculo).Error
+}
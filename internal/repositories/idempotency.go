@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"myapi/internal/config"
+	"myapi/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyRepository struct {
+	ctx context.Context
+}
+
+func NewIdempotencyRepository(ctx context.Context) *IdempotencyRepository {
+	return &IdempotencyRepository{ctx: ctx}
+}
+
+// Reserve - Grava a chave como em andamento, se ela ainda não existir ou
+// tiver expirado, com um único INSERT ... ON CONFLICT. Retorna nil quando a
+// reserva foi obtida; senão, o registro existente (concluído ou em andamento).
+func (r *IdempotencyRepository) Reserve(record *models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	for {
+		result := config.DB.WithContext(r.ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "key"}},
+			Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "idempotency_keys.expires_at <= ?", Vars: []any{time.Now()}}}},
+			UpdateAll: true,
+		}).Create(record)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected > 0 {
+			return nil, nil
+		}
+		stored, err := r.Get(record.Key)
+		if err != nil || stored != nil {
+			return stored, err
+		}
+		// A chave expirou entre o INSERT e a leitura; nova tentativa
+	}
+}
+
+// Release - Desfaz a reserva de uma execução que não terá a resposta guardada
+func (r *IdempotencyRepository) Release(key string) error {
+	return config.DB.WithContext(r.ctx).
+		Where("key = ? AND status_code = ?", key, models.IdempotencyPending).
+		Delete(&models.IdempotencyKey{}).Error
+}
+
+// Get - Busca uma chave ainda válida; retorna nil se não existir ou tiver expirado
+func (r *IdempotencyRepository) Get(key string) (*models.IdempotencyKey, error) {
+	var record models.IdempotencyKey
+	// Lido sempre no primário: a chave pode ter acabado de ser gravada
+	err := config.DB.WithContext(r.ctx).Where("key = ? AND expires_at > ?", key, time.Now()).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// Save - Grava (ou substitui uma chave expirada) a resposta de uma requisição
+func (r *IdempotencyRepository) Save(record *models.IdempotencyKey) error {
+	return config.Writer(r.ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(record).Error
+}
+
+// DeleteExpired - Remove as chaves expiradas
+func (r *IdempotencyRepository) DeleteExpired() error {
+	return config.Writer(r.ctx).Where("expires_at <= ?", time.Now()).Delete(&models.IdempotencyKey{}).Error
+}
@@ -1,51 +1,370 @@
 package repositories
 
 import (
+	"context"
+	"strings"
+	"time"
+
+	"myapi/internal/cache"
 	"myapi/internal/config"
+	"myapi/internal/decimal"
 	"myapi/internal/models"
+	"myapi/internal/money"
+	"myapi/internal/nfe"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-type ItemRepository struct{}
+type ItemRepository struct {
+	ctx context.Context
+}
 
-func NewItemRepository() *ItemRepository {
-	return &ItemRepository{}
+func NewItemRepository(ctx context.Context) *ItemRepository {
+	return &ItemRepository{ctx: ctx}
 }
 
 func (r *ItemRepository) ListAll() ([]models.Iten, error) {
 	var items []models.Iten
-	if err := config.DB.Find(&items).Error; err != nil {
+	if err := config.Reader(r.ctx).Find(&items).Error; err != nil {
 		return nil, err
 	}
 	return items, nil
 }
 
 func (r *ItemRepository) GetByID(id int) (*models.Iten, error) {
+	if item, ok := r.cached(uint(id)); ok {
+		return item, nil
+	}
+	generation := cache.Itens.Generation()
 	var item models.Iten
-	if err := config.DB.First(&item, id).Error; err != nil {
+	if err := config.Reader(r.ctx).First(&item, id).Error; err != nil {
 		return nil, err
 	}
+	r.store(item, generation)
 	return &item, nil
 }
 
 func (r *ItemRepository) GetByCode(code string) (*models.Iten, error) {
+	// O índice por código guarda apenas o ID; se o código do item mudou,
+	// a entrada é tratada como falha. As duas consultas contam como uma.
+	if !config.ReadsFromPrimary(r.ctx) {
+		if id, ok := cache.Itens.Peek("codigo:" + code); ok {
+			if v, ok := cache.Itens.Peek(cache.Key(id.(uint))); ok && v.(models.Iten).Codigo == code {
+				cache.Itens.Record(true)
+				item := v.(models.Iten)
+				return &item, nil
+			}
+		}
+		cache.Itens.Record(false)
+	}
+	generation := cache.Itens.Generation()
 	var item models.Iten
-	if err := config.DB.Where("codigo = ?", code).First(&item).Error; err != nil {
+	if err := config.Reader(r.ctx).Where("codigo = ?", code).First(&item).Error; err != nil {
 		return nil, err
 	}
+	r.store(item, generation)
 	return &item, nil
 }
 
+// FiltroItens - Filtros da listagem de itens; campos nulos não filtram
+type FiltroItens struct {
+	// Trecho do nome ou do código
+	Texto string
+	// Apenas itens com saldo positivo no depósito
+	DepositoId *int
+	// Itens da categoria e, com Subcategorias, de toda a subárvore dela
+	CategoriaId   *int
+	Subcategorias bool
+	// Faixa de preço, na moeda de cada item
+	PrecoMin *decimal.Decimal
+	PrecoMax *decimal.Decimal
+	// Com estoque (true) ou sem estoque (false) em todos os depósitos
+	EmEstoque *bool
+	// Condições sobre os atributos, todas obrigatórias
+	Atributos []FiltroAtributo
+}
+
+// List - Lista os itens que atendem ao filtro
+func (r *ItemRepository) List(filtro FiltroItens) ([]models.Iten, error) {
+	var items []models.Iten
+	if err := consultaItens(config.Reader(r.ctx), filtro).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// consultaItens - Consulta dos itens que atendem ao filtro
+func consultaItens(db *gorm.DB, filtro FiltroItens) *gorm.DB {
+	q := db.Model(&models.Iten{}).Select("itens.*")
+	if filtro.Texto != "" {
+		trecho := "%" + strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(filtro.Texto) + "%"
+		q = q.Where("(itens.nome ILIKE ? OR itens.codigo ILIKE ?)", trecho, trecho)
+	}
+	if filtro.DepositoId != nil {
+		q = q.Joins("JOIN estoque_depositos ON estoque_depositos.item_id = itens.id").
+			Where("estoque_depositos.deposito_id = ? AND estoque_depositos.quantidade > 0", *filtro.DepositoId)
+	}
+	if filtro.CategoriaId != nil {
+		if filtro.Subcategorias {
+			q = q.Where("itens.categoria_id IN (SELECT descendente_id FROM categorias_caminhos WHERE ancestral_id = ?)", *filtro.CategoriaId)
+		} else {
+			q = q.Where("itens.categoria_id = ?", *filtro.CategoriaId)
+		}
+	}
+	if filtro.PrecoMin != nil {
+		q = q.Where("itens.preco >= ?", *filtro.PrecoMin)
+	}
+	if filtro.PrecoMax != nil {
+		q = q.Where("itens.preco <= ?", *filtro.PrecoMax)
+	}
+	if filtro.EmEstoque != nil {
+		// O produto repete a soma das variantes, que já contam no estoque; o
+		// kit não tem estoque próprio (a disponibilidade vem dos componentes)
+		q = q.Where("itens.dimensoes = '[]' AND NOT EXISTS (SELECT 1 FROM componentes_kit WHERE componentes_kit.kit_id = itens.id)")
+		if *filtro.EmEstoque {
+			q = q.Where("itens.quantidade > 0")
+		} else {
+			q = q.Where("itens.quantidade <= 0")
+		}
+	}
+	for _, atributo := range filtro.Atributos {
+		q = filtrarAtributo(q, atributo)
+	}
+	return q
+}
+
+// Create - Cria o item; a quantidade informada entra no depósito padrão e o
+// preço, sem moeda, fica na moeda padrão
 func (r *ItemRepository) Create(item *models.Iten) (*models.Iten, error) {
-	if err := config.DB.Create(item).Error; err != nil {
+	if err := validarItem(item); err != nil {
+		return nil, err
+	}
+	// Produtos e variantes são definidos pelas rotas de variações
+	item.Dimensoes, item.ProdutoId, item.Variacao = nil, nil, nil
+	quantidade := item.Quantidade
+	err := config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		item.Quantidade = 0
+		if err := criarItem(tx, item, config.Autor(r.ctx)); err != nil {
+			return err
+		}
+		if quantidade == 0 {
+			return nil
+		}
+		deposito, err := DepositoPadrao(tx)
+		if err != nil {
+			return err
+		}
+		return Movimentar(tx, &models.Movimentacao{
+			ItemId: item.Id, DepositoId: deposito.Id, Tipo: models.MovimentacaoEntrada, Quantidade: quantidade,
+		})
+	})
+	item.Quantidade = quantidade
+	if err != nil {
+		return nil, err
+	}
+	// Não há entrada a remover; o aviso leva o item novo ao índice de
+	// sugestões de todas as instâncias
+	if err := cache.Invalidate(config.Writer(r.ctx), cache.Itens, item.Id); err != nil {
 		return nil, err
 	}
 	return item, nil
 }
 
+// Update - Atualiza o item; uma alteração de quantidade vira um ajuste de
+// estoque no depósito padrão, mantendo o total igual à soma dos saldos, e
+// uma alteração de preço entra no histórico de preços
 func (r *ItemRepository) Update(item *models.Iten) error {
-	return config.DB.Save(item).Error
+	if item.Id == 0 {
+		_, err := r.Create(item)
+		return err
+	}
+	if item.Moeda != "" && !money.CodigoValido(item.Moeda) {
+		return ErrMoedaInvalida
+	}
+	if item.Ean != "" && !nfe.GtinValido(item.Ean) {
+		return ErrEanInvalido
+	}
+	if err := validarFiscal(&item.Fiscal); err != nil {
+		return err
+	}
+	quantidade := item.Quantidade
+	err := config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		deposito, err := DepositoPadrao(tx)
+		if err != nil {
+			return err
+		}
+		if _, err := lockSaldo(tx, item.Id, deposito.Id); err != nil {
+			return err
+		}
+		// O ajuste de uma variante chega ao produto, bloqueado antes dela
+		var vinculo models.Iten
+		if err := tx.Select("id", "produto_id").First(&vinculo, item.Id).Error; err != nil {
+			return err
+		}
+		if err := travarProduto(tx, vinculo.ProdutoId); err != nil {
+			return err
+		}
+		var atual models.Iten
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&atual, item.Id).Error; err != nil {
+			return err
+		}
+		item.Quantidade = atual.Quantidade
+		item.Dimensoes, item.ProdutoId, item.Variacao = atual.Dimensoes, atual.ProdutoId, atual.Variacao
+		if item.Moeda == "" {
+			item.Moeda = atual.Moeda
+		}
+		if err := validarAtributos(tx, item); err != nil {
+			return err
+		}
+		if err := tx.Save(item).Error; err != nil {
+			return err
+		}
+		if item.Preco.Cmp(atual.Preco) != 0 || item.Moeda != atual.Moeda {
+			if err := registrarPreco(tx, &models.HistoricoPreco{
+				ItemId: item.Id, Preco: item.Preco, Moeda: item.Moeda, VigenteDe: time.Now(), Autor: config.Autor(r.ctx), Aplicado: true,
+			}); err != nil {
+				return err
+			}
+		}
+		if diff := quantidade - atual.Quantidade; diff != 0 {
+			return Movimentar(tx, &models.Movimentacao{
+				ItemId: item.Id, DepositoId: deposito.Id, Tipo: models.MovimentacaoAjuste, Quantidade: diff,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	item.Quantidade = quantidade
+	return cache.Invalidate(config.Writer(r.ctx), cache.Itens, item.Id)
 }
 
+// Delete - Remove o item, seus saldos, preços agendados, preços de tabela,
+// regras próprias e códigos de fornecedor; o histórico de movimentações e de
+// preços é mantido. Produtos só são removidos sem variantes; o estoque de
+// uma variante removida deixa de contar no produto.
 func (r *ItemRepository) Delete(id int) error {
-	return config.DB.Delete(&models.Iten{}, id).Error
+	var item models.Iten
+	err := config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("item_id = ?", id).Find(&[]models.EstoqueDeposito{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Select("id", "produto_id").First(&item, id).Error; err != nil {
+			return err
+		}
+		if err := travarProduto(tx, item.ProdutoId); err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "quantidade", "dimensoes", "produto_id").First(&item, id).Error; err != nil {
+			return err
+		}
+		// Mesmo lock das composições: um kit não passa a usar o item durante a remoção
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockComposicoes).Error; err != nil {
+			return err
+		}
+		var usos int64
+		if err := tx.Model(&models.ComponenteKit{}).Where("componente_id = ?", id).Count(&usos).Error; err != nil {
+			return err
+		}
+		if usos > 0 {
+			return ErrComponenteKit
+		}
+		if len(item.Dimensoes) > 0 {
+			var variantes int64
+			if err := tx.Model(&models.Iten{}).Where("produto_id = ?", id).Count(&variantes).Error; err != nil {
+				return err
+			}
+			if variantes > 0 {
+				return ErrProdutoComVariantes
+			}
+		}
+		if item.ProdutoId != nil {
+			if err := somarAoProduto(tx, *item.ProdutoId, -item.Quantidade); err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("item_id = ?", id).Delete(&models.EstoqueDeposito{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("item_id = ?", id).Delete(&models.EstoqueLote{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("kit_id = ?", id).Delete(&models.ComponenteKit{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("item_id = ? AND NOT aplicado", id).Delete(&models.HistoricoPreco{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("item_id = ?", id).Delete(&models.PrecoTabela{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("item_id = ?", id).Delete(&models.RegraPreco{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("item_id = ?", id).Delete(&models.ItemFornecedor{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Iten{}, id).Error
+	})
+	if err != nil {
+		return err
+	}
+	if item.ProdutoId != nil {
+		if err := cache.Invalidate(config.Writer(r.ctx), cache.Itens, *item.ProdutoId); err != nil {
+			return err
+		}
+	}
+	return cache.Invalidate(config.Writer(r.ctx), cache.Itens, uint(id))
+}
+
+// validarItem - Completa a moeda e confere moeda, EAN e dados fiscais de um item novo
+func validarItem(item *models.Iten) error {
+	if item.Moeda == "" {
+		item.Moeda = money.MoedaPadrao()
+	}
+	if !money.CodigoValido(item.Moeda) {
+		return ErrMoedaInvalida
+	}
+	if item.Ean != "" && !nfe.GtinValido(item.Ean) {
+		return ErrEanInvalido
+	}
+	return validarFiscal(&item.Fiscal)
+}
+
+// criarItem - Confere os atributos e grava o item, sem estoque, e o seu
+// primeiro preço no histórico, dentro da transação tx
+func criarItem(tx *gorm.DB, item *models.Iten, autor string) error {
+	if err := validarAtributos(tx, item); err != nil {
+		return err
+	}
+	if err := tx.Create(item).Error; err != nil {
+		return err
+	}
+	return registrarPreco(tx, &models.HistoricoPreco{
+		ItemId: item.Id, Preco: item.Preco, Moeda: item.Moeda, VigenteDe: time.Now(), Autor: autor, Aplicado: true,
+	})
+}
+
+// cached - Busca o item no cache, exceto quando a requisição exige leitura no primário
+func (r *ItemRepository) cached(id uint) (*models.Iten, bool) {
+	if config.ReadsFromPrimary(r.ctx) {
+		return nil, false
+	}
+	if v, ok := cache.Itens.Get(cache.Key(id)); ok {
+		item := v.(models.Iten)
+		return &item, true
+	}
+	return nil, false
+}
+
+// store - Grava o item lido, se não houve invalidação desde a marca tomada
+// antes da leitura; assim uma leitura concorrente a uma alteração não devolve
+// ao cache o valor que acabou de ser invalidado
+func (r *ItemRepository) store(item models.Iten, generation uint64) {
+	cache.Itens.SetIfCurrent(cache.Key(item.Id), item, generation)
+	cache.Itens.SetIfCurrent("codigo:"+item.Codigo, item.Id, generation)
 }
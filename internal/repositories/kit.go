@@ -0,0 +1,278 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"myapi/internal/config"
+	"myapi/internal/models"
+
+	"gorm.io/gorm"
+)
+
+var (
+	ErrItemKit       = errors.New("kits não têm estoque próprio; movimente os componentes")
+	ErrCicloKit      = errors.New("a composição criaria um ciclo entre kits")
+	ErrKitComEstoque = errors.New("zere o estoque do item antes de transformá-lo em kit")
+	ErrKitEmUso      = errors.New("kit presente em pedidos de venda em aberto")
+	ErrKitVariacao   = errors.New("produtos com variantes e variantes não podem ser kits")
+	ErrComponenteKit = errors.New("o item é componente de um kit; retire-o da composição antes de removê-lo")
+	ErrKitProduto    = errors.New("produtos com variantes não podem ser componentes; use as variantes")
+)
+
+// profundidadeMaximaKit - Limite de aninhamento ao explodir kits; a gravação
+// já impede ciclos, o limite só protege contra dados inconsistentes
+const profundidadeMaximaKit = 32
+
+// lockComposicoes - Serializa as alterações de composição para que duas
+// transações não criem um ciclo ao mesmo tempo
+const lockComposicoes int64 = 2001
+
+type KitRepository struct {
+	ctx context.Context
+}
+
+func NewKitRepository(ctx context.Context) *KitRepository {
+	return &KitRepository{ctx: ctx}
+}
+
+// Componentes - Componentes diretos do kit
+func (r *KitRepository) Componentes(kitID int) ([]models.ComponenteKit, error) {
+	var componentes []models.ComponenteKit
+	if err := config.Reader(r.ctx).Preload("Componente").Where("kit_id = ?", kitID).
+		Order("componente_id").Find(&componentes).Error; err != nil {
+		return nil, err
+	}
+	return componentes, nil
+}
+
+// Arvore - Relações kit → componente alcançáveis a partir do kit, por kit
+func (r *KitRepository) Arvore(kitID uint) (map[uint][]models.ComponenteKit, error) {
+	arvore := make(map[uint][]models.ComponenteKit)
+	visitados := map[uint]bool{kitID: true}
+	pendentes := []uint{kitID}
+	for nivel := 0; len(pendentes) > 0; nivel++ {
+		if nivel > profundidadeMaximaKit {
+			return nil, ErrCicloKit
+		}
+		var componentes []models.ComponenteKit
+		if err := config.Reader(r.ctx).Where("kit_id IN ?", pendentes).Find(&componentes).Error; err != nil {
+			return nil, err
+		}
+		pendentes = nil
+		for _, c := range componentes {
+			arvore[c.KitId] = append(arvore[c.KitId], c)
+			if !visitados[c.ComponenteId] {
+				visitados[c.ComponenteId] = true
+				pendentes = append(pendentes, c.ComponenteId)
+			}
+		}
+	}
+	return arvore, nil
+}
+
+// SaveComponentes - Define a composição do kit, substituindo a anterior.
+// Rejeita composições que façam o kit conter a si mesmo, direta ou
+// indiretamente.
+func (r *KitRepository) SaveComponentes(kitID uint, componentes []models.ComponenteKit) error {
+	return config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockComposicoes).Error; err != nil {
+			return err
+		}
+		var kit models.Iten
+		if err := tx.First(&kit, kitID).Error; err != nil {
+			return err
+		}
+		if kit.Quantidade != 0 {
+			return ErrKitComEstoque
+		}
+		if len(componentes) > 0 && (len(kit.Dimensoes) > 0 || kit.ProdutoId != nil) {
+			return ErrKitVariacao
+		}
+
+		var arestas []models.ComponenteKit
+		if err := tx.Find(&arestas).Error; err != nil {
+			return err
+		}
+		grafo := make(map[uint][]uint)
+		for _, a := range arestas {
+			if a.KitId != kitID {
+				grafo[a.KitId] = append(grafo[a.KitId], a.ComponenteId)
+			}
+		}
+		vistos := make(map[uint]bool, len(componentes))
+		for i := range componentes {
+			c := &componentes[i]
+			if c.Quantidade <= 0 {
+				return ErrQuantidadeInvalida
+			}
+			if vistos[c.ComponenteId] {
+				return fmt.Errorf("componente %d repetido", c.ComponenteId)
+			}
+			vistos[c.ComponenteId] = true
+			c.KitId = kitID
+			c.Componente = nil
+			grafo[kitID] = append(grafo[kitID], c.ComponenteId)
+		}
+		if len(vistos) > 0 {
+			var existentes int64
+			if err := tx.Model(&models.Iten{}).Where("id IN ?", grafo[kitID]).Count(&existentes).Error; err != nil {
+				return err
+			}
+			if int(existentes) != len(vistos) {
+				return gorm.ErrRecordNotFound
+			}
+			// Produtos não têm estoque próprio: o kit nunca teria disponível
+			var produtos []string
+			if err := tx.Model(&models.Iten{}).Where("id IN ? AND dimensoes <> '[]'", grafo[kitID]).
+				Order("codigo").Pluck("codigo", &produtos).Error; err != nil {
+				return err
+			}
+			if len(produtos) > 0 {
+				return fmt.Errorf("%w: %s", ErrKitProduto, strings.Join(produtos, ", "))
+			}
+		}
+		if caminho := encontrarCiclo(grafo, kitID); caminho != nil {
+			return fmt.Errorf("%w: %s", ErrCicloKit, formatarCaminho(tx, caminho))
+		}
+		if err := kitEmUso(tx, kitID, grafo); err != nil {
+			return err
+		}
+
+		if err := tx.Where("kit_id = ?", kitID).Delete(&models.ComponenteKit{}).Error; err != nil {
+			return err
+		}
+		if len(componentes) == 0 {
+			return nil
+		}
+		return tx.Create(&componentes).Error
+	})
+}
+
+// encontrarCiclo - Caminho de kitID de volta a ele mesmo, ou nil se não houver
+func encontrarCiclo(grafo map[uint][]uint, kitID uint) []uint {
+	visitados := make(map[uint]bool)
+	var caminho []uint
+	var visitar func(no uint) bool
+	visitar = func(no uint) bool {
+		caminho = append(caminho, no)
+		for _, proximo := range grafo[no] {
+			if proximo == kitID {
+				caminho = append(caminho, proximo)
+				return true
+			}
+			if !visitados[proximo] {
+				visitados[proximo] = true
+				if visitar(proximo) {
+					return true
+				}
+			}
+		}
+		caminho = caminho[:len(caminho)-1]
+		return false
+	}
+	if visitar(kitID) {
+		return caminho
+	}
+	return nil
+}
+
+func formatarCaminho(tx *gorm.DB, caminho []uint) string {
+	var itens []models.Iten
+	tx.Select("id", "codigo").Where("id IN ?", caminho).Find(&itens)
+	codigos := make(map[uint]string, len(itens))
+	for _, item := range itens {
+		codigos[item.Id] = item.Codigo
+	}
+	partes := make([]string, len(caminho))
+	for i, id := range caminho {
+		partes[i] = codigos[id]
+		if partes[i] == "" {
+			partes[i] = fmt.Sprint(id)
+		}
+	}
+	return strings.Join(partes, " → ")
+}
+
+// kitEmUso - Impede mudar a composição de um kit (ou de um kit que o contém)
+// enquanto houver pedidos com estoque reservado, pois a reserva foi feita
+// sobre a composição anterior
+func kitEmUso(tx *gorm.DB, kitID uint, grafo map[uint][]uint) error {
+	afetados := []uint{kitID}
+	vistos := map[uint]bool{kitID: true}
+	for i := 0; i < len(afetados); i++ {
+		for kit, componentes := range grafo {
+			if !vistos[kit] && slices.Contains(componentes, afetados[i]) {
+				vistos[kit] = true
+				afetados = append(afetados, kit)
+			}
+		}
+	}
+	var emUso int64
+	if err := tx.Model(&models.PedidoVendaItem{}).
+		Joins("JOIN pedidos_venda ON pedidos_venda.id = pedidos_venda_itens.pedido_venda_id").
+		Where("pedidos_venda_itens.item_id IN ? AND pedidos_venda.status IN ?",
+			afetados, []string{models.VendaReservado, models.VendaConfirmado}).
+		Count(&emUso).Error; err != nil {
+		return err
+	}
+	if emUso > 0 {
+		return ErrKitEmUso
+	}
+	return nil
+}
+
+// ehKit - Indica se o item tem componentes
+func ehKit(tx *gorm.DB, itemID uint) (bool, error) {
+	var n int64
+	err := tx.Model(&models.ComponenteKit{}).Where("kit_id = ?", itemID).Count(&n).Error
+	return n > 0, err
+}
+
+// explodir - Substitui as linhas de kits pelos seus componentes, em todos os
+// níveis, multiplicando as quantidades
+func explodir(tx *gorm.DB, linhas []models.PedidoVendaItem) ([]models.PedidoVendaItem, error) {
+	var resultado []models.PedidoVendaItem
+	pendentes := linhas
+	for nivel := 0; len(pendentes) > 0; nivel++ {
+		if nivel > profundidadeMaximaKit {
+			return nil, ErrCicloKit
+		}
+		ids := make([]uint, 0, len(pendentes))
+		for _, linha := range pendentes {
+			ids = append(ids, linha.ItemId)
+		}
+		var componentes []models.ComponenteKit
+		if err := tx.Preload("Componente").Where("kit_id IN ?", ids).Find(&componentes).Error; err != nil {
+			return nil, err
+		}
+		porKit := make(map[uint][]models.ComponenteKit)
+		for _, c := range componentes {
+			porKit[c.KitId] = append(porKit[c.KitId], c)
+		}
+
+		var proximos []models.PedidoVendaItem
+		for _, linha := range pendentes {
+			componentes, ok := porKit[linha.ItemId]
+			if !ok {
+				resultado = append(resultado, linha)
+				continue
+			}
+			for _, c := range componentes {
+				if c.Componente == nil {
+					return nil, fmt.Errorf("%w: componente %d do kit %d", ErrItemInexistente, c.ComponenteId, c.KitId)
+				}
+				proximos = append(proximos, models.PedidoVendaItem{
+					ItemId:     c.ComponenteId,
+					Codigo:     c.Componente.Codigo,
+					Quantidade: linha.Quantidade * c.Quantidade,
+				})
+			}
+		}
+		pendentes = proximos
+	}
+	return resultado, nil
+}
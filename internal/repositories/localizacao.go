@@ -0,0 +1,273 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"myapi/internal/config"
+	"myapi/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrLocalizacaoInvalida  = errors.New("localização não é uma posição deste depósito")
+	ErrPosicaoOcupada       = errors.New("posição ocupada por outro item")
+	ErrSemEstoqueLivre      = errors.New("quantidade maior que o estoque sem endereço no depósito")
+	ErrLocalizacaoEstoque   = errors.New("a localização ainda guarda estoque")
+	ErrLocalizacaoComFilhas = errors.New("a localização tem posições abaixo dela; remova-as antes")
+)
+
+type LocalizacaoRepository struct {
+	ctx context.Context
+}
+
+func NewLocalizacaoRepository(ctx context.Context) *LocalizacaoRepository {
+	return &LocalizacaoRepository{ctx: ctx}
+}
+
+func (r *LocalizacaoRepository) ListByDeposito(depositoID int) ([]models.Localizacao, error) {
+	var localizacoes []models.Localizacao
+	if err := config.Reader(r.ctx).Where("deposito_id = ?", depositoID).
+		Order("ordem, codigo").Find(&localizacoes).Error; err != nil {
+		return nil, err
+	}
+	return localizacoes, nil
+}
+
+func (r *LocalizacaoRepository) GetByID(id int) (*models.Localizacao, error) {
+	var localizacao models.Localizacao
+	if err := config.Reader(r.ctx).First(&localizacao, id).Error; err != nil {
+		return nil, err
+	}
+	return &localizacao, nil
+}
+
+func (r *LocalizacaoRepository) Create(localizacao *models.Localizacao) (*models.Localizacao, error) {
+	if err := config.Writer(r.ctx).Create(localizacao).Error; err != nil {
+		return nil, err
+	}
+	return localizacao, nil
+}
+
+// Delete - Remove uma localização sem posições abaixo dela e sem estoque,
+// junto com as regras de armazenagem que apontam para ela; os números de
+// série que passaram por ela ficam sem localização
+func (r *LocalizacaoRepository) Delete(id int) error {
+	return config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		var localizacao models.Localizacao
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&localizacao, id).Error; err != nil {
+			return err
+		}
+		var filhas int64
+		if err := tx.Model(&models.Localizacao{}).Where("parent_id = ?", id).Count(&filhas).Error; err != nil {
+			return err
+		}
+		if filhas > 0 {
+			return ErrLocalizacaoComFilhas
+		}
+		var comEstoque int64
+		if err := tx.Model(&models.EstoqueLocalizacao{}).
+			Where("localizacao_id = ? AND quantidade <> 0", id).Count(&comEstoque).Error; err != nil {
+			return err
+		}
+		if comEstoque > 0 {
+			return ErrLocalizacaoEstoque
+		}
+		if err := tx.Where("localizacao_id = ?", id).Delete(&models.EstoqueLocalizacao{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("localizacao_id = ?", id).Delete(&models.RegraArmazenagem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.NumeroSerie{}).Where("localizacao_id = ?", id).
+			Update("localizacao_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&localizacao).Error
+	})
+}
+
+// SaldosByDeposito - Saldos das posições de um depósito, opcionalmente
+// filtrados por itens
+func (r *LocalizacaoRepository) SaldosByDeposito(depositoID int, itemIDs ...uint) ([]models.EstoqueLocalizacao, error) {
+	var saldos []models.EstoqueLocalizacao
+	q := config.Reader(r.ctx).
+		Joins("JOIN localizacoes ON localizacoes.id = estoque_localizacoes.localizacao_id").
+		Where("localizacoes.deposito_id = ? AND estoque_localizacoes.quantidade > 0", depositoID)
+	if len(itemIDs) > 0 {
+		q = q.Where("estoque_localizacoes.item_id IN ?", itemIDs)
+	}
+	if err := q.Find(&saldos).Error; err != nil {
+		return nil, err
+	}
+	return saldos, nil
+}
+
+// GetRegra - Posição fixa do item no depósito, ou nil se não houver
+func (r *LocalizacaoRepository) GetRegra(itemID, depositoID uint) (*models.RegraArmazenagem, error) {
+	var regra models.RegraArmazenagem
+	err := config.Reader(r.ctx).Where("item_id = ? AND deposito_id = ?", itemID, depositoID).First(&regra).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &regra, nil
+}
+
+// SaveRegra - Define a posição fixa do item no depósito
+func (r *LocalizacaoRepository) SaveRegra(regra *models.RegraArmazenagem) error {
+	if _, err := posicaoDoDeposito(config.Writer(r.ctx), regra.LocalizacaoId, regra.DepositoId); err != nil {
+		return err
+	}
+	return config.Writer(r.ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(regra).Error
+}
+
+// Armazenar - Endereça na posição uma quantidade do estoque do depósito que
+// ainda não tem posição (putaway)
+func (r *LocalizacaoRepository) Armazenar(itemID, depositoID, localizacaoID uint, quantidade int) error {
+	if quantidade <= 0 {
+		return ErrQuantidadeInvalida
+	}
+	return config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := posicaoDoDeposito(tx, localizacaoID, depositoID); err != nil {
+			return err
+		}
+		saldo, err := lockSaldo(tx, itemID, depositoID)
+		if err != nil {
+			return err
+		}
+		enderecado, err := totalEnderecado(tx, itemID, depositoID)
+		if err != nil {
+			return err
+		}
+		if saldo.Quantidade-enderecado < quantidade {
+			return ErrSemEstoqueLivre
+		}
+		if err := posicaoLivre(tx, itemID, localizacaoID); err != nil {
+			return err
+		}
+		return somarPosicao(tx, itemID, localizacaoID, quantidade)
+	})
+}
+
+// ajustarLocalizacoes - Reflete nas posições uma movimentação já aplicada ao
+// saldo do depósito. Entradas com posição somam nela; saídas com posição
+// baixam dela. Saídas sem posição consomem primeiro o estoque sem endereço e,
+// se ele não bastar, as posições do item.
+func ajustarLocalizacoes(tx *gorm.DB, mov *models.Movimentacao) error {
+	if mov.LocalizacaoId != nil {
+		if _, err := posicaoDoDeposito(tx, *mov.LocalizacaoId, mov.DepositoId); err != nil {
+			return err
+		}
+		if mov.Quantidade > 0 {
+			if err := posicaoLivre(tx, mov.ItemId, *mov.LocalizacaoId); err != nil {
+				return err
+			}
+		}
+		return somarPosicao(tx, mov.ItemId, *mov.LocalizacaoId, mov.Quantidade)
+	}
+	if mov.Quantidade >= 0 {
+		return nil
+	}
+
+	var saldo models.EstoqueDeposito
+	if err := tx.Where("item_id = ? AND deposito_id = ?", mov.ItemId, mov.DepositoId).First(&saldo).Error; err != nil {
+		return err
+	}
+	enderecado, err := totalEnderecado(tx, mov.ItemId, mov.DepositoId)
+	if err != nil {
+		return err
+	}
+	excesso := enderecado - saldo.Quantidade
+	if excesso <= 0 {
+		return nil
+	}
+
+	var posicoes []models.EstoqueLocalizacao
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Joins("JOIN localizacoes ON localizacoes.id = estoque_localizacoes.localizacao_id").
+		Where("estoque_localizacoes.item_id = ? AND localizacoes.deposito_id = ? AND estoque_localizacoes.quantidade > 0",
+			mov.ItemId, mov.DepositoId).
+		Order("estoque_localizacoes.localizacao_id").Find(&posicoes).Error; err != nil {
+		return err
+	}
+	for _, p := range posicoes {
+		if excesso == 0 {
+			break
+		}
+		baixa := min(p.Quantidade, excesso)
+		if err := somarPosicao(tx, mov.ItemId, p.LocalizacaoId, -baixa); err != nil {
+			return err
+		}
+		excesso -= baixa
+	}
+	return nil
+}
+
+// posicaoLivre - Bloqueia a posição até o fim da transação (guardas
+// concorrentes de itens diferentes na mesma posição) e verifica que ela não
+// guarda outro item
+func posicaoLivre(tx *gorm.DB, itemID, localizacaoID uint) error {
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").
+		First(&models.Localizacao{}, localizacaoID).Error; err != nil {
+		return err
+	}
+	var outro int64
+	if err := tx.Model(&models.EstoqueLocalizacao{}).
+		Where("localizacao_id = ? AND item_id <> ? AND quantidade > 0", localizacaoID, itemID).
+		Count(&outro).Error; err != nil {
+		return err
+	}
+	if outro > 0 {
+		return ErrPosicaoOcupada
+	}
+	return nil
+}
+
+// somarPosicao - Soma (ou subtrai) a quantidade no saldo do item na posição
+func somarPosicao(tx *gorm.DB, itemID, localizacaoID uint, quantidade int) error {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.EstoqueLocalizacao{ItemId: itemID, LocalizacaoId: localizacaoID}).Error; err != nil {
+		return err
+	}
+	var saldo models.EstoqueLocalizacao
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("item_id = ? AND localizacao_id = ?", itemID, localizacaoID).First(&saldo).Error; err != nil {
+		return err
+	}
+	if saldo.Quantidade+quantidade < 0 {
+		return ErrEstoqueInsuficiente
+	}
+	return tx.Model(&saldo).Where("item_id = ? AND localizacao_id = ?", itemID, localizacaoID).
+		Update("quantidade", saldo.Quantidade+quantidade).Error
+}
+
+// totalEnderecado - Quantidade do item guardada em posições do depósito
+func totalEnderecado(tx *gorm.DB, itemID, depositoID uint) (int, error) {
+	var total int
+	err := tx.Model(&models.EstoqueLocalizacao{}).
+		Joins("JOIN localizacoes ON localizacoes.id = estoque_localizacoes.localizacao_id").
+		Where("estoque_localizacoes.item_id = ? AND localizacoes.deposito_id = ?", itemID, depositoID).
+		Select("COALESCE(SUM(estoque_localizacoes.quantidade), 0)").Scan(&total).Error
+	return total, err
+}
+
+// posicaoDoDeposito - Valida que a localização é uma posição do depósito
+func posicaoDoDeposito(tx *gorm.DB, localizacaoID, depositoID uint) (*models.Localizacao, error) {
+	var localizacao models.Localizacao
+	err := tx.First(&localizacao, localizacaoID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrLocalizacaoInvalida
+	}
+	if err != nil {
+		return nil, err
+	}
+	if localizacao.DepositoId != depositoID || localizacao.Tipo != models.LocalizacaoPosicao {
+		return nil, ErrLocalizacaoInvalida
+	}
+	return &localizacao, nil
+}
@@ -0,0 +1,211 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"myapi/internal/config"
+	"myapi/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrItemSemLote  = errors.New("item não controla lote")
+	ErrLoteInvalido = errors.New("lote não pertence ao item")
+	ErrLoteVencido  = errors.New("lote vencido não pode ser baixado")
+	ErrLoteEntrada  = errors.New("item controla lote: informe o lote da entrada")
+)
+
+type LoteRepository struct {
+	ctx context.Context
+}
+
+func NewLoteRepository(ctx context.Context) *LoteRepository {
+	return &LoteRepository{ctx: ctx}
+}
+
+// ListByItem - Lotes do item com os saldos por depósito, do que vence primeiro
+// ao que vence por último
+func (r *LoteRepository) ListByItem(itemID int) ([]models.Lote, error) {
+	var lotes []models.Lote
+	if err := config.Reader(r.ctx).Preload("Saldos", "quantidade > 0").Where("item_id = ?", itemID).
+		Order("validade NULLS LAST, id").Find(&lotes).Error; err != nil {
+		return nil, err
+	}
+	return lotes, nil
+}
+
+// Create - Cadastra um lote de um item com controle de lote
+func (r *LoteRepository) Create(lote *models.Lote) (*models.Lote, error) {
+	if err := criarLote(config.Writer(r.ctx), lote); err != nil {
+		return nil, err
+	}
+	return lote, nil
+}
+
+func criarLote(tx *gorm.DB, lote *models.Lote) error {
+	var item models.Iten
+	if err := tx.Select("id", "controla_lote").First(&item, lote.ItemId).Error; err != nil {
+		return err
+	}
+	if !item.ControlaLote {
+		return ErrItemSemLote
+	}
+	lote.Id = 0
+	return tx.Create(lote).Error
+}
+
+// loteEntrada - Lote do item com o número informado; sem ele, cadastra o
+// lote com as datas recebidas. Um lote já cadastrado mantém as suas datas.
+func loteEntrada(tx *gorm.DB, lote *models.Lote) error {
+	err := tx.Where("item_id = ? AND numero = ?", lote.ItemId, lote.Numero).First(lote).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return criarLote(tx, lote)
+	}
+	return err
+}
+
+// LoteVencendo - Saldo de um lote que vence até a data consultada
+type LoteVencendo struct {
+	ItemId     uint      `json:"item_id"`
+	Codigo     string    `json:"codigo"`
+	Nome       string    `json:"nome"`
+	LoteId     uint      `json:"lote_id"`
+	Numero     string    `json:"numero"`
+	Validade   time.Time `json:"validade"`
+	DepositoId uint      `json:"deposito_id"`
+	Quantidade int       `json:"quantidade"`
+	Vencido    bool      `json:"vencido"`
+}
+
+// ListVencendo - Saldos de lotes com validade até a data informada,
+// incluindo os já vencidos
+func (r *LoteRepository) ListVencendo(ate time.Time) ([]LoteVencendo, error) {
+	var lotes []LoteVencendo
+	err := config.Reader(r.ctx).Table("estoque_lotes").
+		Select(`itens.id AS item_id, itens.codigo, itens.nome, lotes.id AS lote_id, lotes.numero, lotes.validade,
+			estoque_lotes.deposito_id, estoque_lotes.quantidade, lotes.validade < CURRENT_DATE AS vencido`).
+		Joins("JOIN lotes ON lotes.id = estoque_lotes.lote_id").
+		Joins("JOIN itens ON itens.id = estoque_lotes.item_id").
+		Where("estoque_lotes.quantidade > 0 AND lotes.validade <= ?", ate).
+		Order("lotes.validade, itens.codigo, estoque_lotes.deposito_id").
+		Scan(&lotes).Error
+	return lotes, err
+}
+
+// ajustarLotes - Reflete nos lotes uma movimentação já aplicada ao saldo do
+// depósito. Com lote informado, lança nele. Entradas precisam de lote, exceto
+// as de transferência, que levam para o destino o estoque sem lote (anterior
+// ao controle de lote) da origem. Saídas sem lote baixam os lotes por FEFO
+// (validade mais próxima primeiro) e, só quando eles não bastam, o estoque
+// sem lote. Lotes vencidos só podem ser baixados por ajuste.
+func ajustarLotes(tx *gorm.DB, item *models.Iten, mov *models.Movimentacao) error {
+	if !item.ControlaLote {
+		if mov.LoteId != nil {
+			return ErrItemSemLote
+		}
+		return nil
+	}
+	baixaVencido := mov.Tipo == models.MovimentacaoAjuste
+	agora := time.Now()
+
+	if mov.LoteId != nil {
+		var lote models.Lote
+		err := tx.First(&lote, *mov.LoteId).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && lote.ItemId != mov.ItemId) {
+			return ErrLoteInvalido
+		}
+		if err != nil {
+			return err
+		}
+		if mov.Quantidade < 0 && !baixaVencido && lote.Vencido(agora) {
+			return fmt.Errorf("%w: %s", ErrLoteVencido, lote.Numero)
+		}
+		if err := somarLote(tx, mov.ItemId, lote.Id, mov.DepositoId, mov.Quantidade); err != nil {
+			return err
+		}
+		mov.Lotes = []models.MovimentacaoLote{{LoteId: lote.Id, Quantidade: mov.Quantidade}}
+		return nil
+	}
+	if mov.Quantidade > 0 && mov.Tipo != models.MovimentacaoTransferencia {
+		return ErrLoteEntrada
+	}
+	if mov.Quantidade >= 0 {
+		return nil
+	}
+
+	var saldo models.EstoqueDeposito
+	if err := tx.Where("item_id = ? AND deposito_id = ?", mov.ItemId, mov.DepositoId).First(&saldo).Error; err != nil {
+		return err
+	}
+	var lotes []models.EstoqueLote
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Table: clause.Table{Name: "estoque_lotes"}}).
+		Preload("Lote").
+		Joins("JOIN lotes ON lotes.id = estoque_lotes.lote_id").
+		Where("estoque_lotes.item_id = ? AND estoque_lotes.deposito_id = ? AND estoque_lotes.quantidade > 0",
+			mov.ItemId, mov.DepositoId).
+		Order("lotes.validade NULLS LAST, lotes.id").Find(&lotes).Error; err != nil {
+		return err
+	}
+	emLotes := 0
+	for _, l := range lotes {
+		emLotes += l.Quantidade
+	}
+	// O saldo já reflete a saída: antes dela, o que não estava em lotes era
+	// estoque sem lote
+	falta := -mov.Quantidade
+	semLote := saldo.Quantidade + falta - emLotes
+	for _, l := range lotes {
+		if falta == 0 {
+			break
+		}
+		if !baixaVencido && l.Lote.Vencido(agora) {
+			continue
+		}
+		baixa := min(l.Quantidade, falta)
+		if err := somarLote(tx, mov.ItemId, l.LoteId, mov.DepositoId, -baixa); err != nil {
+			return err
+		}
+		mov.Lotes = append(mov.Lotes, models.MovimentacaoLote{LoteId: l.LoteId, Quantidade: -baixa})
+		falta -= baixa
+	}
+	if falta > semLote {
+		return ErrLoteVencido
+	}
+	return nil
+}
+
+// quantidadeVencida - Saldo do item no depósito em lotes vencidos, que não
+// pode ser reservado nem vendido
+func quantidadeVencida(tx *gorm.DB, itemID, depositoID uint) (int, error) {
+	agora := time.Now()
+	hoje := time.Date(agora.Year(), agora.Month(), agora.Day(), 0, 0, 0, 0, time.UTC)
+	var total int
+	err := tx.Model(&models.EstoqueLote{}).
+		Joins("JOIN lotes ON lotes.id = estoque_lotes.lote_id").
+		Where("estoque_lotes.item_id = ? AND estoque_lotes.deposito_id = ? AND lotes.validade < ?", itemID, depositoID, hoje).
+		Select("COALESCE(SUM(estoque_lotes.quantidade), 0)").Scan(&total).Error
+	return total, err
+}
+
+// somarLote - Soma (ou subtrai) a quantidade no saldo do lote no depósito
+func somarLote(tx *gorm.DB, itemID, loteID, depositoID uint, quantidade int) error {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.EstoqueLote{LoteId: loteID, DepositoId: depositoID, ItemId: itemID}).Error; err != nil {
+		return err
+	}
+	var saldo models.EstoqueLote
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("lote_id = ? AND deposito_id = ?", loteID, depositoID).First(&saldo).Error; err != nil {
+		return err
+	}
+	if saldo.Quantidade+quantidade < 0 {
+		return ErrEstoqueInsuficiente
+	}
+	return tx.Model(&models.EstoqueLote{}).Where("lote_id = ? AND deposito_id = ?", loteID, depositoID).
+		Update("quantidade", saldo.Quantidade+quantidade).Error
+}
@@ -0,0 +1,432 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"myapi/internal/cache"
+	"myapi/internal/config"
+	"myapi/internal/decimal"
+	"myapi/internal/fiscal"
+	"myapi/internal/models"
+	"myapi/internal/money"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrEanInvalido          = errors.New("EAN inválido")
+	ErrNfeJaImportada       = errors.New("NF-e já importada e confirmada")
+	ErrNfeConfirmada        = errors.New("importação de NF-e já confirmada")
+	ErrLinhaNfe             = errors.New("linha não pertence à nota")
+	ErrQuantidadeFracionada = errors.New("quantidade fracionada; o estoque é controlado em unidades inteiras")
+	ErrLotesNfe             = errors.New("lotes da nota não somam a quantidade da linha")
+)
+
+type NfeRepository struct {
+	ctx context.Context
+}
+
+func NewNfeRepository(ctx context.Context) *NfeRepository {
+	return &NfeRepository{ctx: ctx}
+}
+
+// ListAll - Importações de NF-e, opcionalmente filtradas por status
+func (r *NfeRepository) ListAll(status string) ([]models.ImportacaoNfe, error) {
+	var importacoes []models.ImportacaoNfe
+	q := config.Reader(r.ctx).Order("id DESC")
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	if err := q.Find(&importacoes).Error; err != nil {
+		return nil, err
+	}
+	return importacoes, nil
+}
+
+// GetByID - Importação com as linhas; as linhas pendentes sem item trazem o
+// cadastro proposto
+func (r *NfeRepository) GetByID(id int) (*models.ImportacaoNfe, error) {
+	var importacao models.ImportacaoNfe
+	if err := config.Reader(r.ctx).Preload("Linhas", func(db *gorm.DB) *gorm.DB {
+		return db.Order("numero")
+	}).Preload("Linhas.Lotes", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).First(&importacao, id).Error; err != nil {
+		return nil, err
+	}
+	propor(&importacao)
+	return &importacao, nil
+}
+
+// Corresponder - Procura o item de cada linha pelo código do fornecedor,
+// pelo EAN e pelo código do item, nessa ordem
+func (r *NfeRepository) Corresponder(fornecedorID *uint, linhas []models.ImportacaoNfeLinha) error {
+	db := config.Reader(r.ctx)
+	var codigos, eans []string
+	for _, linha := range linhas {
+		codigos = append(codigos, linha.Codigo)
+		if linha.Ean != "" {
+			eans = append(eans, linha.Ean)
+		}
+	}
+
+	porFornecedor := map[string]uint{}
+	if fornecedorID != nil {
+		var vinculos []models.ItemFornecedor
+		if err := db.Where("fornecedor_id = ? AND codigo IN ?", *fornecedorID, codigos).Find(&vinculos).Error; err != nil {
+			return err
+		}
+		for _, vinculo := range vinculos {
+			porFornecedor[vinculo.Codigo] = vinculo.ItemId
+		}
+	}
+	var itens []models.Iten
+	q := db.Select("id", "codigo", "ean").Where("codigo IN ?", codigos)
+	if len(eans) > 0 {
+		q = q.Or("ean IN ?", eans)
+	}
+	if err := q.Order("id").Find(&itens).Error; err != nil {
+		return err
+	}
+	porEan, porCodigo := map[string]uint{}, map[string]uint{}
+	for _, item := range itens {
+		if _, ok := porEan[item.Ean]; !ok && item.Ean != "" {
+			porEan[item.Ean] = item.Id
+		}
+		porCodigo[item.Codigo] = item.Id
+	}
+
+	for i := range linhas {
+		linha := &linhas[i]
+		if id, ok := porFornecedor[linha.Codigo]; ok {
+			linha.ItemId, linha.Correspondencia = &id, models.CorrespondenciaFornecedor
+		} else if id, ok := porEan[linha.Ean]; ok && linha.Ean != "" {
+			linha.ItemId, linha.Correspondencia = &id, models.CorrespondenciaEan
+		} else if id, ok := porCodigo[linha.Codigo]; ok {
+			linha.ItemId, linha.Correspondencia = &id, models.CorrespondenciaCodigo
+		}
+	}
+	return nil
+}
+
+// Registrar - Grava a importação pendente. Uma nota já pendente é
+// substituída (com nova correspondência); uma já confirmada é recusada.
+func (r *NfeRepository) Registrar(importacao *models.ImportacaoNfe) error {
+	importacao.Status = models.NfePendente
+	err := config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		var existente models.ImportacaoNfe
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("chave = ?", importacao.Chave).First(&existente).Error
+		switch {
+		case err == nil && existente.Status == models.NfeConfirmada:
+			return ErrNfeJaImportada
+		case err == nil:
+			if err := excluirLinhas(tx, existente.Id); err != nil {
+				return err
+			}
+			if err := tx.Delete(&existente).Error; err != nil {
+				return err
+			}
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+		return tx.Create(importacao).Error
+	})
+	if err != nil {
+		return err
+	}
+	propor(importacao)
+	return nil
+}
+
+// Delete - Descarta uma importação pendente
+func (r *NfeRepository) Delete(id int) error {
+	return config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		var importacao models.ImportacaoNfe
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&importacao, id).Error; err != nil {
+			return err
+		}
+		if importacao.Status != models.NfePendente {
+			return ErrNfeConfirmada
+		}
+		if err := excluirLinhas(tx, importacao.Id); err != nil {
+			return err
+		}
+		return tx.Delete(&importacao).Error
+	})
+}
+
+// excluirLinhas - Apaga as linhas da importação e os seus lotes
+func excluirLinhas(tx *gorm.DB, importacaoID uint) error {
+	if err := tx.Where("importacao_nfe_linha_id IN (?)",
+		tx.Model(&models.ImportacaoNfeLinha{}).Select("id").Where("importacao_nfe_id = ?", importacaoID),
+	).Delete(&models.ImportacaoNfeLote{}).Error; err != nil {
+		return err
+	}
+	return tx.Where("importacao_nfe_id = ?", importacaoID).Delete(&models.ImportacaoNfeLinha{}).Error
+}
+
+// LinhaConfirmacao - Decisão sobre uma linha na confirmação: usar um item
+// existente (ItemId), cadastrar o Item informado no lugar da proposta ou
+// Ignorar a linha. Linhas sem decisão usam o item encontrado ou a proposta.
+// LoteId substitui os lotes da nota; Series são os números de série das
+// unidades, para itens serializados.
+type LinhaConfirmacao struct {
+	Numero  int          `json:"numero"`
+	ItemId  *uint        `json:"item_id"`
+	Item    *models.Iten `json:"item"`
+	Ignorar bool         `json:"ignorar"`
+	LoteId  *uint        `json:"lote_id"`
+	Series  []string     `json:"series"`
+}
+
+// Confirmar - Cadastra os itens das linhas sem correspondência, lança as
+// entradas de estoque no depósito (sem ele, o padrão) pelo custo unitário
+// da nota e grava os códigos do fornecedor para as próximas importações
+func (r *NfeRepository) Confirmar(id int, depositoID *uint, linhas []LinhaConfirmacao) (*models.ImportacaoNfe, error) {
+	var itemIDs []uint
+	err := config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		var importacao models.ImportacaoNfe
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Linhas", func(db *gorm.DB) *gorm.DB {
+			return db.Order("numero")
+		}).Preload("Linhas.Lotes", func(db *gorm.DB) *gorm.DB {
+			return db.Order("id")
+		}).First(&importacao, id).Error; err != nil {
+			return err
+		}
+		if importacao.Status != models.NfePendente {
+			return ErrNfeConfirmada
+		}
+		deposito, err := depositoEntrada(tx, depositoID)
+		if err != nil {
+			return err
+		}
+
+		decisoes := make(map[int]LinhaConfirmacao, len(linhas))
+		for _, decisao := range linhas {
+			decisoes[decisao.Numero] = decisao
+		}
+		numeros := make(map[int]bool, len(importacao.Linhas))
+		for _, linha := range importacao.Linhas {
+			numeros[linha.Numero] = true
+		}
+		for numero := range decisoes {
+			if !numeros[numero] {
+				return fmt.Errorf("%w: linha %d", ErrLinhaNfe, numero)
+			}
+		}
+
+		// Linhas repetidas do mesmo produto sem cadastro geram um único item
+		criados := map[string]uint{}
+		autor := config.Autor(r.ctx)
+		for i := range importacao.Linhas {
+			linha := &importacao.Linhas[i]
+			decisao := decisoes[linha.Numero]
+			if decisao.Ignorar {
+				linha.Ignorada = true
+				if err := tx.Omit(clause.Associations).Save(linha).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			quantidade, ok := inteiro(linha.Quantidade)
+			if !ok || quantidade <= 0 {
+				return fmt.Errorf("%w: linha %d (%s %s)", ErrQuantidadeFracionada, linha.Numero, linha.Quantidade, linha.Unidade)
+			}
+
+			switch {
+			case decisao.ItemId != nil:
+				linha.ItemId, linha.Correspondencia = decisao.ItemId, models.CorrespondenciaManual
+			case decisao.Item != nil:
+				if err := cadastrarItem(tx, decisao.Item, autor, linha.Numero); err != nil {
+					return err
+				}
+				linha.ItemId, linha.Correspondencia = &decisao.Item.Id, models.CorrespondenciaCadastro
+			case linha.ItemId != nil:
+			case criados[linha.Codigo] != 0:
+				id := criados[linha.Codigo]
+				linha.ItemId, linha.Correspondencia = &id, models.CorrespondenciaCadastro
+			default:
+				item := proporItem(linha)
+				if err := cadastrarItem(tx, item, autor, linha.Numero); err != nil {
+					return err
+				}
+				criados[linha.Codigo] = item.Id
+				linha.ItemId, linha.Correspondencia = &item.Id, models.CorrespondenciaCadastro
+			}
+
+			if err := lancarEntradas(tx, linha, decisao, quantidade, deposito.Id, "NFE-"+importacao.Chave); err != nil {
+				return err
+			}
+			if err := tx.Omit(clause.Associations).Save(linha).Error; err != nil {
+				return err
+			}
+			if importacao.FornecedorId != nil && linha.Codigo != "" {
+				if err := salvarCodigoFornecedor(tx, &models.ItemFornecedor{
+					FornecedorId: *importacao.FornecedorId, Codigo: linha.Codigo, ItemId: *linha.ItemId,
+				}); err != nil {
+					return err
+				}
+			}
+			itemIDs = append(itemIDs, *linha.ItemId)
+		}
+
+		agora := time.Now()
+		return tx.Model(&importacao).Updates(map[string]any{
+			"status": models.NfeConfirmada, "deposito_id": deposito.Id, "confirmado_em": agora,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, itemID := range itemIDs {
+		if err := cache.Invalidate(config.Writer(r.ctx), cache.Itens, itemID); err != nil {
+			return nil, err
+		}
+	}
+	return r.GetByID(id)
+}
+
+// lancarEntradas - Lança a entrada da linha pelo custo unitário da nota. Com
+// o lote informado na decisão, ou se a nota não traz lotes ou o item não
+// controla lote, é uma única movimentação; senão, uma por lote da nota,
+// cadastrando os lotes que o item ainda não tem. Os números de série são
+// consumidos na ordem dos lotes.
+func lancarEntradas(tx *gorm.DB, linha *models.ImportacaoNfeLinha, decisao LinhaConfirmacao, quantidade int, depositoID uint, referencia string) error {
+	custo := linha.CustoUnitario.Arredondar(money.RegraCusto)
+	entrada := func(quantidade int, loteID *uint, series []string) (uint, error) {
+		mov := models.Movimentacao{
+			ItemId:        *linha.ItemId,
+			DepositoId:    depositoID,
+			LoteId:        loteID,
+			Tipo:          models.MovimentacaoEntrada,
+			Quantidade:    quantidade,
+			CustoUnitario: &custo,
+			Referencia:    referencia,
+			Series:        series,
+		}
+		if err := Movimentar(tx, &mov); err != nil {
+			return 0, fmt.Errorf("linha %d: %w", linha.Numero, err)
+		}
+		if linha.MovimentacaoId == nil {
+			linha.MovimentacaoId = &mov.Id
+		}
+		return mov.Id, nil
+	}
+
+	var item models.Iten
+	if err := tx.Select("id", "controla_lote").First(&item, *linha.ItemId).Error; err != nil {
+		return err
+	}
+	if decisao.LoteId != nil || len(linha.Lotes) == 0 || !item.ControlaLote {
+		_, err := entrada(quantidade, decisao.LoteId, decisao.Series)
+		return err
+	}
+
+	partes := make([]int, len(linha.Lotes))
+	total := 0
+	for i, lote := range linha.Lotes {
+		parte, ok := inteiro(lote.Quantidade)
+		if !ok || parte <= 0 {
+			return fmt.Errorf("%w: lote %s da linha %d (%s)", ErrQuantidadeFracionada, lote.Numero, linha.Numero, lote.Quantidade)
+		}
+		partes[i] = parte
+		total += parte
+	}
+	if total != quantidade {
+		return fmt.Errorf("%w: linha %d com %d unidades e %d nos lotes", ErrLotesNfe, linha.Numero, quantidade, total)
+	}
+	series := decisao.Series
+	if len(series) > quantidade {
+		return fmt.Errorf("linha %d: %w: %d informados para %d unidades", linha.Numero, ErrSeriesQuantidade, len(series), quantidade)
+	}
+	for i := range linha.Lotes {
+		nota := &linha.Lotes[i]
+		fabricacao, validade := nota.Fabricacao, nota.Validade
+		lote := models.Lote{ItemId: item.Id, Numero: nota.Numero, Fabricacao: &fabricacao, Validade: &validade}
+		if err := loteEntrada(tx, &lote); err != nil {
+			return fmt.Errorf("linha %d: %w", linha.Numero, err)
+		}
+		n := min(partes[i], len(series))
+		movID, err := entrada(partes[i], &lote.Id, series[:n])
+		if err != nil {
+			return err
+		}
+		series = series[n:]
+		nota.LoteId, nota.MovimentacaoId = &lote.Id, &movID
+		if err := tx.Save(nota).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// depositoEntrada - Depósito informado ou, sem ele, o padrão
+func depositoEntrada(tx *gorm.DB, depositoID *uint) (*models.Deposito, error) {
+	if depositoID == nil {
+		return DepositoPadrao(tx)
+	}
+	var deposito models.Deposito
+	if err := tx.First(&deposito, *depositoID).Error; err != nil {
+		return nil, err
+	}
+	return &deposito, nil
+}
+
+// cadastrarItem - Cria, sem estoque, o item de uma linha da nota
+func cadastrarItem(tx *gorm.DB, item *models.Iten, autor string, numero int) error {
+	item.Id = 0
+	item.Quantidade = 0
+	if err := validarItem(item); err != nil {
+		return fmt.Errorf("linha %d: %w", numero, err)
+	}
+	return criarItem(tx, item, autor)
+}
+
+// propor - Preenche a proposta de cadastro das linhas pendentes sem item
+func propor(importacao *models.ImportacaoNfe) {
+	if importacao.Status != models.NfePendente {
+		return
+	}
+	for i := range importacao.Linhas {
+		if importacao.Linhas[i].ItemId == nil {
+			importacao.Linhas[i].Proposta = proporItem(&importacao.Linhas[i])
+		}
+	}
+}
+
+// proporItem - Item sugerido a partir da linha: nome, código, EAN e dados
+// fiscais da nota; linhas com lotes propõem item com controle de lote. O
+// preço de venda fica zerado para ser definido depois; NCM fora da tabela
+// carregada não é proposto.
+func proporItem(linha *models.ImportacaoNfeLinha) *models.Iten {
+	item := &models.Iten{
+		Nome:         linha.Descricao,
+		Codigo:       linha.Codigo,
+		Ean:          linha.Ean,
+		Moeda:        money.MoedaPadrao(),
+		ControlaLote: len(linha.Lotes) > 0,
+	}
+	if fiscal.OrigemValida(linha.Origem) {
+		item.Fiscal.Origem = linha.Origem
+	}
+	if fiscal.NcmValido(linha.Ncm) {
+		item.Fiscal.Ncm = linha.Ncm
+		if fiscal.CestValido(linha.Cest) {
+			item.Fiscal.Cest = linha.Cest
+		}
+	}
+	return item
+}
+
+// inteiro - Valor como inteiro, se não tiver casas decimais significativas
+func inteiro(d decimal.Decimal) (int, bool) {
+	truncado := d.Round(0, decimal.Truncar)
+	if truncado.Cmp(d) != 0 {
+		return 0, false
+	}
+	return int(truncado.Float64()), true
+}
@@ -0,0 +1,238 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"myapi/internal/cache"
+	"myapi/internal/config"
+	"myapi/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrPrecoNegativo   = errors.New("preço não pode ser negativo")
+	ErrPrecoRetroativo = errors.New("a vigência de um novo preço não pode começar no passado")
+	ErrPrecoAplicado   = errors.New("apenas preços agendados que ainda não entraram em vigor podem ser cancelados")
+	ErrPrecoVigencia   = errors.New("vigente_ate deve ser posterior a vigente_de")
+)
+
+type PrecoRepository struct {
+	ctx context.Context
+}
+
+func NewPrecoRepository(ctx context.Context) *PrecoRepository {
+	return &PrecoRepository{ctx: ctx}
+}
+
+// Historico - Linha do tempo de preços do item, incluindo os agendados
+func (r *PrecoRepository) Historico(itemID int) ([]models.HistoricoPreco, error) {
+	var precos []models.HistoricoPreco
+	if err := config.Reader(r.ctx).Where("item_id = ?", itemID).
+		Order("vigente_de, id").Find(&precos).Error; err != nil {
+		return nil, err
+	}
+	return precos, nil
+}
+
+// VigenteEm - Preço do item no instante informado
+func (r *PrecoRepository) VigenteEm(itemID int, em time.Time) (*models.HistoricoPreco, error) {
+	var preco models.HistoricoPreco
+	if err := config.Reader(r.ctx).
+		Where("item_id = ? AND vigente_de <= ? AND (vigente_ate IS NULL OR vigente_ate > ?)", itemID, em, em).
+		Order("vigente_de DESC").First(&preco).Error; err != nil {
+		return nil, err
+	}
+	return &preco, nil
+}
+
+// Agendar - Registra um preço, na moeda do item, a partir de VigenteDe (zero
+// = agora). Um preço que já está em vigor é aplicado ao item na hora; os
+// futuros ficam para a rotina de agendamento. Com VigenteAte (promoção), um
+// segundo preço agendado para esse instante devolve o item ao preço que
+// estaria em vigor sem a promoção.
+func (r *PrecoRepository) Agendar(preco *models.HistoricoPreco) error {
+	if preco.Preco.Sign() < 0 {
+		return ErrPrecoNegativo
+	}
+	agora := time.Now()
+	if preco.VigenteDe.IsZero() {
+		preco.VigenteDe = agora
+	} else if preco.VigenteDe.Before(agora) {
+		return ErrPrecoRetroativo
+	}
+	ate := preco.VigenteAte
+	if ate != nil && !ate.After(preco.VigenteDe) {
+		return ErrPrecoVigencia
+	}
+	preco.Id = 0
+	preco.VigenteAte = nil
+	preco.Autor = config.Autor(r.ctx)
+	preco.Aplicado = !preco.VigenteDe.After(agora)
+
+	err := config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		var item models.Iten
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "preco", "moeda").First(&item, preco.ItemId).Error; err != nil {
+			return err
+		}
+		preco.Moeda = item.Moeda
+		var retorno *models.HistoricoPreco
+		if ate != nil {
+			// Lido antes de a promoção entrar na linha do tempo
+			var err error
+			if retorno, err = precoSemPromocao(tx, &item, *ate); err != nil {
+				return err
+			}
+			retorno.Autor = preco.Autor
+		}
+		if err := registrarPreco(tx, preco); err != nil {
+			return err
+		}
+		if retorno != nil {
+			if err := registrarPreco(tx, retorno); err != nil {
+				return err
+			}
+			// A promoção passou a terminar no retorno (ou antes, em outro agendado)
+			if err := tx.First(preco, preco.Id).Error; err != nil {
+				return err
+			}
+		}
+		if !preco.Aplicado {
+			return nil
+		}
+		return tx.Model(&models.Iten{}).Where("id = ?", preco.ItemId).Update("preco", preco.Preco).Error
+	})
+	if err != nil || !preco.Aplicado {
+		return err
+	}
+	return cache.Invalidate(config.Writer(r.ctx), cache.Itens, preco.ItemId)
+}
+
+// Cancelar - Remove um preço agendado; o período anterior volta a se estender
+// até o preço seguinte
+func (r *PrecoRepository) Cancelar(itemID, precoID int) error {
+	return config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		var item models.Iten
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&item, itemID).Error; err != nil {
+			return err
+		}
+		var preco models.HistoricoPreco
+		if err := tx.Where("item_id = ?", itemID).First(&preco, precoID).Error; err != nil {
+			return err
+		}
+		if preco.Aplicado {
+			return ErrPrecoAplicado
+		}
+		if err := tx.Delete(&preco).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.HistoricoPreco{}).
+			Where("item_id = ? AND vigente_ate = ?", itemID, preco.VigenteDe).
+			Update("vigente_ate", preco.VigenteAte).Error
+	})
+}
+
+// AplicarAgendados - Copia para os itens os preços agendados cuja vigência
+// começou e devolve quantos foram aplicados. Preços que já foram substituídos
+// por outro mais recente são apenas marcados como aplicados.
+func (r *PrecoRepository) AplicarAgendados() (int, error) {
+	agora := time.Now()
+	var ids []uint
+	if err := config.Writer(r.ctx).Model(&models.HistoricoPreco{}).
+		Where("NOT aplicado AND vigente_de <= ?", agora).
+		Order("vigente_de, id").Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+
+	aplicados := 0
+	for _, id := range ids {
+		var preco models.HistoricoPreco
+		aplicou := false
+		err := config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.First(&preco, id).Error; err != nil {
+				return err
+			}
+			var item models.Iten
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&item, preco.ItemId).Error; err != nil {
+				return err
+			}
+			// Relê com o item bloqueado: um preço pode ter sido aplicado ou
+			// substituído enquanto a lista era montada
+			if err := tx.First(&preco, id).Error; err != nil || preco.Aplicado {
+				return err
+			}
+			if preco.VigenteAte == nil || preco.VigenteAte.After(agora) {
+				if err := tx.Model(&models.Iten{}).Where("id = ?", preco.ItemId).Update("preco", preco.Preco).Error; err != nil {
+					return err
+				}
+				aplicou = true
+			}
+			return tx.Model(&preco).Update("aplicado", true).Error
+		})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return aplicados, err
+		}
+		if !aplicou {
+			continue
+		}
+		aplicados++
+		if err := cache.Invalidate(config.Writer(r.ctx), cache.Itens, preco.ItemId); err != nil {
+			return aplicados, err
+		}
+	}
+	return aplicados, nil
+}
+
+// precoSemPromocao - Preço que vale em ate pela linha do tempo atual (ou o
+// do item, se ela não o cobrir), agendado para ate para encerrar uma promoção
+func precoSemPromocao(tx *gorm.DB, item *models.Iten, ate time.Time) (*models.HistoricoPreco, error) {
+	retorno := &models.HistoricoPreco{ItemId: item.Id, Preco: item.Preco, Moeda: item.Moeda, VigenteDe: ate}
+	var vigente models.HistoricoPreco
+	err := tx.Where("item_id = ? AND vigente_de <= ? AND (vigente_ate IS NULL OR vigente_ate > ?)", item.Id, ate, ate).
+		Order("vigente_de DESC").First(&vigente).Error
+	switch {
+	case err == nil:
+		retorno.Preco, retorno.Moeda = vigente.Preco, vigente.Moeda
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+	return retorno, nil
+}
+
+// registrarPreco - Insere o preço na linha do tempo do item: o período que
+// ele interrompe passa a terminar no seu início, e ele vale até o próximo
+// preço. Um agendamento para o mesmo instante substitui o anterior.
+func registrarPreco(tx *gorm.DB, preco *models.HistoricoPreco) error {
+	// O Postgres guarda microssegundos; truncar mantém os limites dos
+	// períodos iguais aos lidos do banco
+	preco.VigenteDe = preco.VigenteDe.Truncate(time.Microsecond)
+	if err := tx.Where("item_id = ? AND vigente_de = ? AND NOT aplicado", preco.ItemId, preco.VigenteDe).
+		Delete(&models.HistoricoPreco{}).Error; err != nil {
+		return err
+	}
+
+	var proximo models.HistoricoPreco
+	err := tx.Where("item_id = ? AND vigente_de > ?", preco.ItemId, preco.VigenteDe).
+		Order("vigente_de").First(&proximo).Error
+	switch {
+	case err == nil:
+		preco.VigenteAte = &proximo.VigenteDe
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		preco.VigenteAte = nil
+	default:
+		return err
+	}
+
+	if err := tx.Model(&models.HistoricoPreco{}).
+		Where("item_id = ? AND vigente_de < ? AND (vigente_ate IS NULL OR vigente_ate > ?)", preco.ItemId, preco.VigenteDe, preco.VigenteDe).
+		Update("vigente_ate", preco.VigenteDe).Error; err != nil {
+		return err
+	}
+	return tx.Create(preco).Error
+}
@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"myapi/internal/config"
+)
+
+type RateLimitRepository struct {
+	ctx context.Context
+}
+
+func NewRateLimitRepository(ctx context.Context) *RateLimitRepository {
+	return &RateLimitRepository{ctx: ctx}
+}
+
+// Take - Reabastece o bucket pelo tempo decorrido e tenta consumir um token,
+// tudo em um único UPSERT atômico. Retorna se a requisição foi permitida e
+// quantos tokens restaram.
+func (r *RateLimitRepository) Take(key string, capacity int, perSecond float64) (bool, float64, error) {
+	var result struct {
+		Tokens  float64
+		Allowed bool
+	}
+	refill := "LEAST(?, rate_limit_buckets.tokens + EXTRACT(EPOCH FROM (now() - rate_limit_buckets.updated_at)) * ?)"
+	err := config.DB.WithContext(r.ctx).Raw(`
+		INSERT INTO rate_limit_buckets (key, tokens, allowed, updated_at)
+		VALUES (?, ?, true, now())
+		ON CONFLICT (key) DO UPDATE SET
+			tokens = CASE WHEN `+refill+` >= 1 THEN `+refill+` - 1 ELSE `+refill+` END,
+			allowed = `+refill+` >= 1,
+			updated_at = now()
+		RETURNING tokens, allowed`,
+		key, float64(capacity-1),
+		capacity, perSecond, capacity, perSecond, capacity, perSecond, capacity, perSecond,
+	).Scan(&result).Error
+	if err != nil {
+		return false, 0, err
+	}
+	return result.Allowed, result.Tokens, nil
+}
+
+// IncrementQuota - Soma uma requisição ao consumo do dia da API key e
+// retorna o total acumulado
+func (r *RateLimitRepository) IncrementQuota(apiKey string, day time.Time) (int64, error) {
+	var total int64
+	err := config.DB.WithContext(r.ctx).Raw(`
+		INSERT INTO api_key_quotas (api_key, dia, total) VALUES (?, ?, 1)
+		ON CONFLICT (api_key, dia) DO UPDATE SET total = api_key_quotas.total + 1
+		RETURNING total`,
+		apiKey, day.Format("2006-01-02"),
+	).Scan(&total).Error
+	return total, err
+}
+
+// DeleteStale - Remove os buckets sem uso desde before e as cotas dos dias
+// anteriores ao de before
+func (r *RateLimitRepository) DeleteStale(before time.Time) error {
+	db := config.DB.WithContext(r.ctx)
+	if err := db.Exec("DELETE FROM rate_limit_buckets WHERE updated_at < ?", before).Error; err != nil {
+		return err
+	}
+	return db.Exec("DELETE FROM api_key_quotas WHERE dia < ?", before.UTC().Format("2006-01-02")).Error
+}
@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"myapi/internal/config"
+	"myapi/internal/decimal"
+	"myapi/internal/models"
+	"myapi/internal/money"
+
+	"gorm.io/gorm"
+)
+
+var ErrRegraInvalida = errors.New("regra de preço inválida")
+
+var cem = decimal.NewFromInt(100)
+
+type RegraPrecoRepository struct {
+	ctx context.Context
+}
+
+func NewRegraPrecoRepository(ctx context.Context) *RegraPrecoRepository {
+	return &RegraPrecoRepository{ctx: ctx}
+}
+
+func (r *RegraPrecoRepository) ListAll() ([]models.RegraPreco, error) {
+	var regras []models.RegraPreco
+	if err := config.Reader(r.ctx).Order("id").Find(&regras).Error; err != nil {
+		return nil, err
+	}
+	return regras, nil
+}
+
+func (r *RegraPrecoRepository) GetByID(id int) (*models.RegraPreco, error) {
+	var regra models.RegraPreco
+	if err := config.Reader(r.ctx).First(&regra, id).Error; err != nil {
+		return nil, err
+	}
+	return &regra, nil
+}
+
+func (r *RegraPrecoRepository) Create(regra *models.RegraPreco) (*models.RegraPreco, error) {
+	regra.Id = 0
+	if err := r.save(regra); err != nil {
+		return nil, err
+	}
+	return regra, nil
+}
+
+func (r *RegraPrecoRepository) Update(regra *models.RegraPreco) error {
+	if regra.Id == 0 {
+		_, err := r.Create(regra)
+		return err
+	}
+	return r.save(regra)
+}
+
+func (r *RegraPrecoRepository) Delete(id int) error {
+	return config.Writer(r.ctx).Delete(&models.RegraPreco{}, id).Error
+}
+
+// Aplicaveis - Regras que valem para o item, a categoria dele ou qualquer
+// categoria acima dela (zero = sem categoria), a tabela (zero = sem tabela),
+// a quantidade e o instante informados, das mais específicas para as mais
+// gerais: item, categorias da mais próxima para a mais distante e gerais
+func (r *RegraPrecoRepository) Aplicaveis(itemID, categoriaID, tabelaID uint, quantidade int, em time.Time) ([]models.RegraPreco, error) {
+	db := config.Reader(r.ctx)
+	ancestrais := db.Table("categorias_caminhos").
+		Select("categorias.codigo, categorias_caminhos.profundidade").
+		Joins("JOIN categorias ON categorias.id = categorias_caminhos.ancestral_id").
+		Where("categorias_caminhos.descendente_id = ?", categoriaID)
+	query := db.Select("regras_preco.*").
+		Joins("LEFT JOIN (?) ancestrais ON ancestrais.codigo = regras_preco.categoria_codigo", ancestrais).
+		Where("regras_preco.item_id = ? OR (regras_preco.item_id IS NULL AND (regras_preco.categoria_codigo = '' OR ancestrais.codigo IS NOT NULL))", itemID).
+		Where("regras_preco.quantidade_minima <= ?", quantidade).
+		Where("(regras_preco.vigente_de IS NULL OR regras_preco.vigente_de <= ?) AND (regras_preco.vigente_ate IS NULL OR regras_preco.vigente_ate > ?)", em, em)
+	if tabelaID == 0 {
+		query = query.Where("regras_preco.tabela_preco_id IS NULL")
+	} else {
+		query = query.Where("regras_preco.tabela_preco_id IS NULL OR regras_preco.tabela_preco_id = ?", tabelaID)
+	}
+
+	var regras []models.RegraPreco
+	if err := query.Order("regras_preco.item_id IS NULL, regras_preco.categoria_codigo = '', ancestrais.profundidade, regras_preco.quantidade_minima DESC, regras_preco.id").
+		Find(&regras).Error; err != nil {
+		return nil, err
+	}
+	return regras, nil
+}
+
+// save - Valida e grava a regra
+func (r *RegraPrecoRepository) save(regra *models.RegraPreco) error {
+	if err := validarRegra(regra); err != nil {
+		return err
+	}
+	return config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		// Sem moeda, o preço fixo vale na moeda do item ou, nas regras de
+		// categoria e gerais, na moeda padrão
+		moeda := money.MoedaPadrao()
+		if regra.ItemId != nil {
+			var item models.Iten
+			if err := tx.Select("id", "moeda").First(&item, *regra.ItemId).Error; err != nil {
+				return err
+			}
+			moeda = item.Moeda
+		}
+		if regra.Preco != nil {
+			if regra.Moeda == "" {
+				regra.Moeda = moeda
+			}
+			preco := regra.Preco.EmMoeda(regra.Moeda)
+			regra.Preco = &preco
+		}
+		if regra.CategoriaCodigo != "" {
+			if err := tx.Where("codigo = ?", regra.CategoriaCodigo).First(&models.Categoria{}).Error; err != nil {
+				return err
+			}
+		}
+		if regra.TabelaPrecoId != nil {
+			if err := tx.First(&models.TabelaPreco{}, *regra.TabelaPrecoId).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Save(regra).Error
+	})
+}
+
+// validarRegra - Confere o alcance, o ajuste e a vigência da regra
+func validarRegra(regra *models.RegraPreco) error {
+	if regra.QuantidadeMinima < 1 {
+		regra.QuantidadeMinima = 1
+	}
+	switch {
+	case regra.ItemId != nil && regra.CategoriaCodigo != "":
+		return fmt.Errorf("%w: informe item_id ou categoria_codigo, não os dois", ErrRegraInvalida)
+	case (regra.Percentual == nil) == (regra.Preco == nil):
+		return fmt.Errorf("%w: informe percentual ou preco", ErrRegraInvalida)
+	case regra.Percentual != nil && (regra.Percentual.Sign() <= 0 || regra.Percentual.Cmp(cem) > 0):
+		return fmt.Errorf("%w: percentual deve estar entre 0 e 100", ErrRegraInvalida)
+	case regra.Preco != nil && regra.Preco.Sign() < 0:
+		return fmt.Errorf("%w: preço não pode ser negativo", ErrRegraInvalida)
+	case regra.Preco == nil && regra.Moeda != "":
+		return fmt.Errorf("%w: moeda vale apenas para preço fixo", ErrRegraInvalida)
+	case regra.Moeda != "" && !money.CodigoValido(regra.Moeda):
+		return ErrMoedaInvalida
+	case regra.VigenteDe != nil && regra.VigenteAte != nil && !regra.VigenteAte.After(*regra.VigenteDe):
+		return fmt.Errorf("%w: vigente_ate deve ser posterior a vigente_de", ErrRegraInvalida)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"myapi/internal/config"
+	"myapi/internal/models"
+
+	"gorm.io/gorm/clause"
+)
+
+type ReposicaoRepository struct {
+	ctx context.Context
+}
+
+func NewReposicaoRepository(ctx context.Context) *ReposicaoRepository {
+	return &ReposicaoRepository{ctx: ctx}
+}
+
+func (r *ReposicaoRepository) GetParametro(itemID int) (*models.ParametroReposicao, error) {
+	var parametro models.ParametroReposicao
+	if err := config.Reader(r.ctx).First(&parametro, "item_id = ?", itemID).Error; err != nil {
+		return nil, err
+	}
+	return &parametro, nil
+}
+
+func (r *ReposicaoRepository) SaveParametro(parametro *models.ParametroReposicao) error {
+	return config.Writer(r.ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "item_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"fornecedor_id", "minimo", "maximo", "ponto_pedido", "lead_time_dias"}),
+	}).Create(parametro).Error
+}
+
+// SetAbaixo - Registra se o item está abaixo do ponto de pedido
+func (r *ReposicaoRepository) SetAbaixo(itemID uint, abaixo bool) error {
+	return config.Writer(r.ctx).Model(&models.ParametroReposicao{}).
+		Where("item_id = ?", itemID).Update("abaixo", abaixo).Error
+}
+
+// PosicaoEstoque - Situação de um item com parâmetros de reposição
+type PosicaoEstoque struct {
+	models.ParametroReposicao
+	Codigo     string `json:"codigo"`
+	Nome       string `json:"nome"`
+	Quantidade int    `json:"quantidade"`
+	Reservado  int    `json:"reservado"`
+	EmPedido   int    `json:"em_pedido"`
+	Consumo    int    `json:"consumo"`
+}
+
+// ListPosicoes - Estoque físico, reservado, em pedidos de compra abertos e o
+// consumo (saídas) desde a data informada, de cada item com parâmetros
+func (r *ReposicaoRepository) ListPosicoes(consumoDesde time.Time) ([]PosicaoEstoque, error) {
+	var posicoes []PosicaoEstoque
+	err := config.Writer(r.ctx).Raw(`
+		SELECT p.*, i.codigo, i.nome, i.quantidade,
+			COALESCE((SELECT SUM(e.reservado) FROM estoque_depositos e WHERE e.item_id = i.id), 0) AS reservado,
+			COALESCE((SELECT SUM(l.quantidade - l.quantidade_recebida)
+				FROM pedidos_compra_itens l JOIN pedidos_compra pc ON pc.id = l.pedido_compra_id
+				WHERE l.item_id = i.id AND pc.status IN ?), 0) AS em_pedido,
+			COALESCE((SELECT -SUM(m.quantidade) FROM movimentacoes m
+				WHERE m.item_id = i.id AND m.tipo = ? AND m.criado_em >= ?), 0) AS consumo
+		FROM parametros_reposicao p
+		JOIN itens i ON i.id = p.item_id
+		ORDER BY p.fornecedor_id, i.codigo`,
+		[]string{models.CompraEnviado, models.CompraParcialmenteRecebido},
+		models.MovimentacaoSaida, consumoDesde,
+	).Scan(&posicoes).Error
+	return posicoes, err
+}
+
+func (r *ReposicaoRepository) CreateNotificacao(notificacao *models.Notificacao) error {
+	return config.Writer(r.ctx).Create(notificacao).Error
+}
+
+// ListNotificacoes - Notificações mais recentes, opcionalmente só as não lidas
+func (r *ReposicaoRepository) ListNotificacoes(naoLidas bool) ([]models.Notificacao, error) {
+	var notificacoes []models.Notificacao
+	q := config.Reader(r.ctx).Order("id DESC").Limit(200)
+	if naoLidas {
+		q = q.Where("lida = ?", false)
+	}
+	if err := q.Find(&notificacoes).Error; err != nil {
+		return nil, err
+	}
+	return notificacoes, nil
+}
+
+func (r *ReposicaoRepository) MarcarLida(id int) error {
+	return config.Writer(r.ctx).Model(&models.Notificacao{}).Where("id = ?", id).Update("lida", true).Error
+}
@@ -0,0 +1,191 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"myapi/internal/config"
+	"myapi/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrItemNaoSerializado = errors.New("item não é serializado")
+	ErrSeriesQuantidade   = errors.New("itens serializados exigem um número de série por unidade")
+	ErrSerieRepetida      = errors.New("número de série repetido na movimentação")
+	ErrSerieEmEstoque     = errors.New("número de série já está em estoque")
+	ErrSerieIndisponivel  = errors.New("número de série não está em estoque neste depósito")
+	ErrSemEstoqueSemSerie = errors.New("quantidade maior que o estoque sem número de série no depósito")
+)
+
+type SerieRepository struct {
+	ctx context.Context
+}
+
+func NewSerieRepository(ctx context.Context) *SerieRepository {
+	return &SerieRepository{ctx: ctx}
+}
+
+// ListByItem - Números de série do item, opcionalmente filtrados por status
+func (r *SerieRepository) ListByItem(itemID int, status string) ([]models.NumeroSerie, error) {
+	var series []models.NumeroSerie
+	q := config.Reader(r.ctx).Where("item_id = ?", itemID).Order("numero")
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	if err := q.Find(&series).Error; err != nil {
+		return nil, err
+	}
+	return series, nil
+}
+
+// Registrar - Cadastra os números de série de unidades que já estavam no
+// depósito antes de o item passar a ser serializado, sem gerar movimentação
+func (r *SerieRepository) Registrar(itemID, depositoID uint, series []string) ([]models.NumeroSerie, error) {
+	var registradas []models.NumeroSerie
+	err := config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		var item models.Iten
+		if err := tx.Select("id", "serializado").First(&item, itemID).Error; err != nil {
+			return err
+		}
+		if !item.Serializado {
+			return ErrItemNaoSerializado
+		}
+		saldo, err := lockSaldo(tx, itemID, depositoID)
+		if err != nil {
+			return err
+		}
+		var comSerie int64
+		if err := tx.Model(&models.NumeroSerie{}).
+			Where("item_id = ? AND deposito_id = ? AND status = ?", itemID, depositoID, models.SerieEmEstoque).
+			Count(&comSerie).Error; err != nil {
+			return err
+		}
+		if int(comSerie)+len(series) > saldo.Quantidade {
+			return ErrSemEstoqueSemSerie
+		}
+
+		vistos := make(map[string]bool, len(series))
+		for _, numero := range series {
+			if vistos[numero] {
+				return fmt.Errorf("%w: %s", ErrSerieRepetida, numero)
+			}
+			vistos[numero] = true
+			var serie models.NumeroSerie
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("item_id = ? AND numero = ?", itemID, numero).First(&serie).Error
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			if serie.Status == models.SerieEmEstoque {
+				return fmt.Errorf("%w: %s", ErrSerieEmEstoque, numero)
+			}
+			deposito := depositoID
+			serie.ItemId, serie.Numero = itemID, numero
+			serie.Status, serie.DepositoId, serie.LocalizacaoId = models.SerieEmEstoque, &deposito, nil
+			if err := tx.Save(&serie).Error; err != nil {
+				return err
+			}
+			registradas = append(registradas, serie)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return registradas, nil
+}
+
+// HistoricoSerie - Situação atual de uma unidade e todas as suas movimentações
+type HistoricoSerie struct {
+	models.NumeroSerie
+	Codigo        string                `json:"codigo"`
+	Nome          string                `json:"nome"`
+	Movimentacoes []models.Movimentacao `json:"movimentacoes"`
+}
+
+// Historico - Unidades com o número de série informado (o mesmo número pode
+// existir em itens diferentes), com as movimentações em ordem cronológica
+func (r *SerieRepository) Historico(numero string) ([]HistoricoSerie, error) {
+	var series []models.NumeroSerie
+	if err := config.Reader(r.ctx).Where("numero = ?", numero).Order("item_id").Find(&series).Error; err != nil {
+		return nil, err
+	}
+	historicos := make([]HistoricoSerie, 0, len(series))
+	for _, serie := range series {
+		var item models.Iten
+		if err := config.Reader(r.ctx).Select("codigo", "nome").First(&item, serie.ItemId).Error; err != nil &&
+			!errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		h := HistoricoSerie{NumeroSerie: serie, Codigo: item.Codigo, Nome: item.Nome}
+		if err := config.Reader(r.ctx).
+			Joins("JOIN movimentacoes_series ON movimentacoes_series.movimentacao_id = movimentacoes.id").
+			Where("movimentacoes_series.numero_serie_id = ?", serie.Id).
+			Order("movimentacoes.criado_em, movimentacoes.id").Find(&h.Movimentacoes).Error; err != nil {
+			return nil, err
+		}
+		historicos = append(historicos, h)
+	}
+	return historicos, nil
+}
+
+// registrarSeries - Valida e aplica os números de série de uma movimentação
+// já gravada. Itens serializados exigem exatamente um número por unidade:
+// na entrada a unidade é criada (ou volta ao estoque, numa devolução) no
+// depósito; na saída precisa estar em estoque no depósito de origem.
+func registrarSeries(tx *gorm.DB, item *models.Iten, mov *models.Movimentacao) error {
+	if !item.Serializado {
+		if len(mov.Series) > 0 {
+			return ErrItemNaoSerializado
+		}
+		return nil
+	}
+	quantidade := abs(mov.Quantidade)
+	if len(mov.Series) != quantidade {
+		return fmt.Errorf("%w: %d informados para %d unidades", ErrSeriesQuantidade, len(mov.Series), quantidade)
+	}
+
+	vistos := make(map[string]bool, len(mov.Series))
+	for _, numero := range mov.Series {
+		if vistos[numero] {
+			return fmt.Errorf("%w: %s", ErrSerieRepetida, numero)
+		}
+		vistos[numero] = true
+
+		var serie models.NumeroSerie
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("item_id = ? AND numero = ?", mov.ItemId, numero).First(&serie).Error
+		encontrada := err == nil
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if mov.Quantidade > 0 {
+			if encontrada && serie.Status == models.SerieEmEstoque {
+				return fmt.Errorf("%w: %s", ErrSerieEmEstoque, numero)
+			}
+			depositoID := mov.DepositoId
+			serie.ItemId, serie.Numero = mov.ItemId, numero
+			serie.Status = models.SerieEmEstoque
+			serie.DepositoId, serie.LocalizacaoId = &depositoID, mov.LocalizacaoId
+		} else {
+			if !encontrada || serie.Status != models.SerieEmEstoque ||
+				serie.DepositoId == nil || *serie.DepositoId != mov.DepositoId {
+				return fmt.Errorf("%w: %s", ErrSerieIndisponivel, numero)
+			}
+			serie.Status = models.SerieBaixada
+			serie.DepositoId, serie.LocalizacaoId = nil, nil
+		}
+		if err := tx.Save(&serie).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&models.MovimentacaoSerie{MovimentacaoId: mov.Id, NumeroSerieId: serie.Id}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
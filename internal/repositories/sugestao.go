@@ -0,0 +1,45 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"myapi/internal/config"
+	"myapi/internal/models"
+	"myapi/internal/sugestao"
+)
+
+type SugestaoRepository struct {
+	ctx context.Context
+}
+
+func NewSugestaoRepository(ctx context.Context) *SugestaoRepository {
+	return &SugestaoRepository{ctx: ctx}
+}
+
+// Entradas - Itens para o índice de sugestões, com a popularidade (unidades
+// em pedidos atendidos nos últimos dias); sem ids, todos os itens
+func (r *SugestaoRepository) Entradas(ids []uint, dias int) ([]sugestao.Entrada, error) {
+	db := config.Reader(r.ctx)
+	vendidos := db.Model(&models.PedidoVendaItem{}).
+		Select("pedidos_venda_itens.item_id, SUM(pedidos_venda_itens.quantidade) AS vendido").
+		Joins("JOIN pedidos_venda ON pedidos_venda.id = pedidos_venda_itens.pedido_venda_id").
+		Where("pedidos_venda.status = ? AND pedidos_venda.atualizado_em >= ?",
+			models.VendaAtendido, time.Now().AddDate(0, 0, -dias)).
+		Group("pedidos_venda_itens.item_id")
+	if len(ids) > 0 {
+		vendidos = vendidos.Where("pedidos_venda_itens.item_id IN ?", ids)
+	}
+
+	q := db.Model(&models.Iten{}).
+		Select("itens.id, itens.codigo, itens.nome, itens.preco, itens.moeda, itens.quantidade, COALESCE(vendidos.vendido, 0) AS popularidade").
+		Joins("LEFT JOIN (?) vendidos ON vendidos.item_id = itens.id", vendidos)
+	if len(ids) > 0 {
+		q = q.Where("itens.id IN ?", ids)
+	}
+	var entradas []sugestao.Entrada
+	if err := q.Scan(&entradas).Error; err != nil {
+		return nil, err
+	}
+	return entradas, nil
+}
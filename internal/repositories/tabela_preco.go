@@ -0,0 +1,134 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"myapi/internal/config"
+	"myapi/internal/models"
+	"myapi/internal/money"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrTabelaEmUso = errors.New("tabela de preços vinculada a clientes ou regras")
+
+type TabelaPrecoRepository struct {
+	ctx context.Context
+}
+
+func NewTabelaPrecoRepository(ctx context.Context) *TabelaPrecoRepository {
+	return &TabelaPrecoRepository{ctx: ctx}
+}
+
+func (r *TabelaPrecoRepository) ListAll() ([]models.TabelaPreco, error) {
+	var tabelas []models.TabelaPreco
+	if err := config.Reader(r.ctx).Order("codigo").Find(&tabelas).Error; err != nil {
+		return nil, err
+	}
+	return tabelas, nil
+}
+
+func (r *TabelaPrecoRepository) GetByID(id int) (*models.TabelaPreco, error) {
+	var tabela models.TabelaPreco
+	if err := config.Reader(r.ctx).First(&tabela, id).Error; err != nil {
+		return nil, err
+	}
+	return &tabela, nil
+}
+
+func (r *TabelaPrecoRepository) GetByCodigo(codigo string) (*models.TabelaPreco, error) {
+	var tabela models.TabelaPreco
+	if err := config.Reader(r.ctx).Where("codigo = ?", codigo).First(&tabela).Error; err != nil {
+		return nil, err
+	}
+	return &tabela, nil
+}
+
+func (r *TabelaPrecoRepository) Create(tabela *models.TabelaPreco) (*models.TabelaPreco, error) {
+	if err := config.Writer(r.ctx).Create(tabela).Error; err != nil {
+		return nil, err
+	}
+	return tabela, nil
+}
+
+func (r *TabelaPrecoRepository) Update(tabela *models.TabelaPreco) error {
+	return config.Writer(r.ctx).Save(tabela).Error
+}
+
+// Delete - Remove a tabela e seus preços; tabelas usadas por clientes ou
+// regras precisam ser desvinculadas antes
+func (r *TabelaPrecoRepository) Delete(id int) error {
+	return config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		var clientes, regras int64
+		if err := tx.Model(&models.Cliente{}).Where("tabela_preco_id = ?", id).Count(&clientes).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.RegraPreco{}).Where("tabela_preco_id = ?", id).Count(&regras).Error; err != nil {
+			return err
+		}
+		if clientes > 0 || regras > 0 {
+			return ErrTabelaEmUso
+		}
+		if err := tx.Where("tabela_preco_id = ?", id).Delete(&models.PrecoTabela{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.TabelaPreco{}, id).Error
+	})
+}
+
+// ListPrecos - Preços dos itens na tabela
+func (r *TabelaPrecoRepository) ListPrecos(tabelaID int) ([]models.PrecoTabela, error) {
+	var precos []models.PrecoTabela
+	if err := config.Reader(r.ctx).Where("tabela_preco_id = ?", tabelaID).
+		Order("item_id").Find(&precos).Error; err != nil {
+		return nil, err
+	}
+	return precos, nil
+}
+
+// PrecoItem - Preço do item na tabela; gorm.ErrRecordNotFound se a tabela
+// não tem preço próprio para o item
+func (r *TabelaPrecoRepository) PrecoItem(tabelaID, itemID uint) (*models.PrecoTabela, error) {
+	var preco models.PrecoTabela
+	if err := config.Reader(r.ctx).Where("tabela_preco_id = ? AND item_id = ?", tabelaID, itemID).
+		First(&preco).Error; err != nil {
+		return nil, err
+	}
+	return &preco, nil
+}
+
+// SavePreco - Define o preço de um item na tabela, na moeda informada ou,
+// sem ela, na do item
+func (r *TabelaPrecoRepository) SavePreco(preco *models.PrecoTabela) error {
+	if preco.Preco.Sign() < 0 {
+		return ErrPrecoNegativo
+	}
+	if preco.Moeda != "" && !money.CodigoValido(preco.Moeda) {
+		return ErrMoedaInvalida
+	}
+	return config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&models.TabelaPreco{}, preco.TabelaPrecoId).Error; err != nil {
+			return err
+		}
+		var item models.Iten
+		if err := tx.Select("id", "moeda").First(&item, preco.ItemId).Error; err != nil {
+			return err
+		}
+		if preco.Moeda == "" {
+			preco.Moeda = item.Moeda
+		}
+		preco.Preco = preco.Preco.EmMoeda(preco.Moeda)
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tabela_preco_id"}, {Name: "item_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"preco", "moeda"}),
+		}).Create(preco).Error
+	})
+}
+
+// DeletePreco - Remove o preço próprio do item; ele volta a usar o preço padrão
+func (r *TabelaPrecoRepository) DeletePreco(tabelaID, itemID int) error {
+	return config.Writer(r.ctx).Where("tabela_preco_id = ? AND item_id = ?", tabelaID, itemID).
+		Delete(&models.PrecoTabela{}).Error
+}
@@ -0,0 +1,400 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"myapi/internal/cache"
+	"myapi/internal/config"
+	"myapi/internal/models"
+	"myapi/internal/money"
+	"myapi/internal/sugestao"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrItemProduto         = errors.New("produtos com variantes não têm estoque próprio; use uma das variantes")
+	ErrNaoEhProduto        = errors.New("item não é um produto com variantes")
+	ErrDimensoes           = errors.New("dimensões de variação inválidas")
+	ErrProdutoComEstoque   = errors.New("zere o estoque do item antes de transformá-lo em produto com variantes")
+	ErrProdutoComVariantes = errors.New("o produto tem variantes")
+	ErrVariante            = errors.New("variante inválida")
+)
+
+// combinacoesMaximas - Limite de combinações das dimensões de um produto
+const combinacoesMaximas = 1000
+
+type VariacaoRepository struct {
+	ctx context.Context
+}
+
+func NewVariacaoRepository(ctx context.Context) *VariacaoRepository {
+	return &VariacaoRepository{ctx: ctx}
+}
+
+// VarianteMatriz - Combinação informada na criação pela matriz. Código, EAN
+// e preço vazios vêm do produto; com ItemId, um item existente passa a ser
+// a variante da combinação.
+type VarianteMatriz struct {
+	Variacao models.Variacao `json:"variacao"`
+	ItemId   *uint           `json:"item_id"`
+	Codigo   string          `json:"codigo"`
+	Ean      string          `json:"ean"`
+	Preco    *money.Money    `json:"preco"`
+}
+
+// CelulaMatriz - Combinação das dimensões; Item é a variante, ou nil se ela
+// ainda não existe, e Codigo e Nome os que ela tem ou terá
+type CelulaMatriz struct {
+	Variacao models.Variacao `json:"variacao"`
+	Codigo   string          `json:"codigo"`
+	Nome     string          `json:"nome"`
+	Item     *models.Iten    `json:"item"`
+}
+
+// MatrizVariantes - Todas as combinações das dimensões do produto
+type MatrizVariantes struct {
+	ProdutoId uint                     `json:"produto_id"`
+	Dimensoes models.DimensoesVariacao `json:"dimensoes"`
+	Celulas   []CelulaMatriz           `json:"celulas"`
+}
+
+// Variantes - Variantes do produto
+func (r *VariacaoRepository) Variantes(produtoID uint) ([]models.Iten, error) {
+	var variantes []models.Iten
+	if err := config.Reader(r.ctx).Where("produto_id = ?", produtoID).
+		Order("codigo").Find(&variantes).Error; err != nil {
+		return nil, err
+	}
+	return variantes, nil
+}
+
+// Matriz - Combinações das dimensões do produto, com as variantes existentes
+func (r *VariacaoRepository) Matriz(produtoID uint) (*MatrizVariantes, error) {
+	db := config.Reader(r.ctx)
+	var produto models.Iten
+	if err := db.First(&produto, produtoID).Error; err != nil {
+		return nil, err
+	}
+	if len(produto.Dimensoes) == 0 {
+		return nil, ErrNaoEhProduto
+	}
+	var variantes []models.Iten
+	if err := db.Where("produto_id = ?", produtoID).Find(&variantes).Error; err != nil {
+		return nil, err
+	}
+	return montarMatriz(&produto, variantes), nil
+}
+
+// SaveDimensoes - Define as dimensões do produto. Um item comum só vira
+// produto sem estoque e sem ser kit ou variante; as variantes existentes
+// precisam continuar sendo combinações das novas dimensões. Sem dimensões,
+// o produto (já sem variantes) volta a ser um item comum.
+func (r *VariacaoRepository) SaveDimensoes(produtoID uint, dimensoes models.DimensoesVariacao) error {
+	if err := validarDimensoes(dimensoes); err != nil {
+		return err
+	}
+	err := config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		var produto models.Iten
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&produto, produtoID).Error; err != nil {
+			return err
+		}
+		if produto.ProdutoId != nil {
+			return fmt.Errorf("%w: %s é uma variante", ErrDimensoes, produto.Codigo)
+		}
+		if len(dimensoes) > 0 && len(produto.Dimensoes) == 0 {
+			if produto.Quantidade != 0 {
+				return ErrProdutoComEstoque
+			}
+			// Mesma trava de SaveComponentes, para o item não entrar num kit
+			// enquanto vira produto
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockComposicoes).Error; err != nil {
+				return err
+			}
+			kit, err := ehKit(tx, produto.Id)
+			if err != nil {
+				return err
+			}
+			if kit {
+				return fmt.Errorf("%w: kits não têm variantes", ErrDimensoes)
+			}
+			var componente int64
+			if err := tx.Model(&models.ComponenteKit{}).Where("componente_id = ?", produto.Id).Count(&componente).Error; err != nil {
+				return err
+			}
+			if componente > 0 {
+				return fmt.Errorf("%w: componentes de kit não têm variantes", ErrDimensoes)
+			}
+		}
+
+		var variantes []models.Iten
+		if err := tx.Where("produto_id = ?", produtoID).Find(&variantes).Error; err != nil {
+			return err
+		}
+		for _, variante := range variantes {
+			if !combinacaoValida(dimensoes, variante.Variacao) {
+				return fmt.Errorf("%w: %s não é uma combinação das novas dimensões", ErrProdutoComVariantes, variante.Codigo)
+			}
+		}
+		return tx.Model(&produto).Update("dimensoes", dimensoes).Error
+	})
+	if err != nil {
+		return err
+	}
+	return cache.Invalidate(config.Writer(r.ctx), cache.Itens, produtoID)
+}
+
+// CriarVariantes - Cria as combinações que ainda não têm variante (ou,
+// com apenasInformadas, só as informadas), copiando do produto os dados
+// que não vierem na combinação. Devolve a matriz atualizada.
+func (r *VariacaoRepository) CriarVariantes(produtoID uint, informadas []VarianteMatriz, apenasInformadas bool) (*MatrizVariantes, error) {
+	var produto models.Iten
+	var variantes []models.Iten
+	var alterados []uint
+	err := config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&produto, produtoID).Error; err != nil {
+			return err
+		}
+		if len(produto.Dimensoes) == 0 {
+			return ErrNaoEhProduto
+		}
+		if err := tx.Where("produto_id = ?", produtoID).Find(&variantes).Error; err != nil {
+			return err
+		}
+		existentes := make(map[string]bool, len(variantes))
+		for _, variante := range variantes {
+			existentes[chaveVariacao(produto.Dimensoes, variante.Variacao)] = true
+		}
+		porChave := make(map[string]VarianteMatriz, len(informadas))
+		for _, informada := range informadas {
+			chave := chaveVariacao(produto.Dimensoes, informada.Variacao)
+			descricao := strings.Join(produto.Dimensoes.Valores(informada.Variacao), "/")
+			switch {
+			case !combinacaoValida(produto.Dimensoes, informada.Variacao):
+				return fmt.Errorf("%w: %v não é uma combinação das dimensões do produto", ErrVariante, informada.Variacao)
+			case existentes[chave]:
+				return fmt.Errorf("%w: a combinação %s já tem variante", ErrVariante, descricao)
+			}
+			if _, ok := porChave[chave]; ok {
+				return fmt.Errorf("%w: combinação %s repetida", ErrVariante, descricao)
+			}
+			porChave[chave] = informada
+		}
+
+		for _, variacao := range produto.Dimensoes.Combinacoes() {
+			chave := chaveVariacao(produto.Dimensoes, variacao)
+			informada, ok := porChave[chave]
+			if existentes[chave] || (apenasInformadas && !ok) {
+				continue
+			}
+			variante, err := criarVariante(tx, &produto, variacao, informada, config.Autor(r.ctx))
+			if err != nil {
+				return err
+			}
+			variantes = append(variantes, *variante)
+			alterados = append(alterados, variante.Id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Os avisos dos itens novos levam as variantes ao índice de sugestões
+	for _, id := range append(alterados, produtoID) {
+		if err := cache.Invalidate(config.Writer(r.ctx), cache.Itens, id); err != nil {
+			return nil, err
+		}
+	}
+	return montarMatriz(&produto, variantes), nil
+}
+
+// criarVariante - Cadastra a variante a partir do produto ou, com ItemId,
+// liga o item existente à combinação
+func criarVariante(tx *gorm.DB, produto *models.Iten, variacao models.Variacao, informada VarianteMatriz, autor string) (*models.Iten, error) {
+	if informada.ItemId != nil {
+		return vincularVariante(tx, produto, variacao, *informada.ItemId)
+	}
+	variante := models.Iten{
+		Nome:         nomeVariante(produto, variacao),
+		Codigo:       informada.Codigo,
+		Ean:          informada.Ean,
+		Descricao:    produto.Descricao,
+		Preco:        produto.Preco,
+		Moeda:        produto.Moeda,
+		CategoriaId:  produto.CategoriaId,
+		ControlaLote: produto.ControlaLote,
+		Serializado:  produto.Serializado,
+		Fiscal:       produto.Fiscal,
+		Atributos:    maps.Clone(produto.Atributos),
+		ProdutoId:    &produto.Id,
+		Variacao:     variacao,
+	}
+	if variante.Codigo == "" {
+		variante.Codigo = codigoVariante(produto, variacao)
+	}
+	if informada.Preco != nil {
+		if informada.Preco.Sign() < 0 {
+			return nil, ErrPrecoNegativo
+		}
+		variante.Preco = *informada.Preco
+	}
+	if err := validarItem(&variante); err != nil {
+		return nil, err
+	}
+	var emUso int64
+	if err := tx.Model(&models.Iten{}).Where("codigo = ?", variante.Codigo).Count(&emUso).Error; err != nil {
+		return nil, err
+	}
+	if emUso > 0 {
+		return nil, fmt.Errorf("%w: o código %s já está em uso", ErrVariante, variante.Codigo)
+	}
+	if err := criarItem(tx, &variante, autor); err != nil {
+		return nil, err
+	}
+	return &variante, nil
+}
+
+// vincularVariante - Transforma um item comum em variante do produto; o
+// estoque dele passa a contar no produto
+func vincularVariante(tx *gorm.DB, produto *models.Iten, variacao models.Variacao, itemID uint) (*models.Iten, error) {
+	var item models.Iten
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&item, itemID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: item %d não encontrado", ErrVariante, itemID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case len(item.Dimensoes) > 0:
+		return nil, fmt.Errorf("%w: %s é um produto com variantes", ErrVariante, item.Codigo)
+	case item.ProdutoId != nil:
+		return nil, fmt.Errorf("%w: %s já é uma variante", ErrVariante, item.Codigo)
+	}
+	kit, err := ehKit(tx, item.Id)
+	if err != nil {
+		return nil, err
+	}
+	if kit {
+		return nil, fmt.Errorf("%w: o kit %s não pode ser variante", ErrVariante, item.Codigo)
+	}
+
+	item.ProdutoId, item.Variacao = &produto.Id, variacao
+	if err := tx.Model(&item).Updates(map[string]any{"produto_id": produto.Id, "variacao": variacao}).Error; err != nil {
+		return nil, err
+	}
+	if err := somarAoProduto(tx, produto.Id, item.Quantidade); err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// travarProduto - Bloqueia o produto de uma variante antes de a variante ser
+// alterada. Quem mexe nos dois segue a mesma ordem para não haver deadlock:
+// saldos do item, produto e, por último, a variante (vincularVariante,
+// Movimentar, Update e Delete de itens)
+func travarProduto(tx *gorm.DB, produtoID *uint) error {
+	if produtoID == nil {
+		return nil
+	}
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&models.Iten{}, *produtoID).Error
+}
+
+// somarAoProduto - Mantém a quantidade do produto igual à soma das variantes
+func somarAoProduto(tx *gorm.DB, produtoID uint, quantidade int) error {
+	if quantidade == 0 {
+		return nil
+	}
+	return tx.Model(&models.Iten{}).Where("id = ?", produtoID).
+		Update("quantidade", gorm.Expr("quantidade + ?", quantidade)).Error
+}
+
+// montarMatriz - Células de todas as combinações, na ordem das dimensões
+func montarMatriz(produto *models.Iten, variantes []models.Iten) *MatrizVariantes {
+	porChave := make(map[string]*models.Iten, len(variantes))
+	for i := range variantes {
+		porChave[chaveVariacao(produto.Dimensoes, variantes[i].Variacao)] = &variantes[i]
+	}
+	matriz := &MatrizVariantes{ProdutoId: produto.Id, Dimensoes: produto.Dimensoes, Celulas: []CelulaMatriz{}}
+	for _, variacao := range produto.Dimensoes.Combinacoes() {
+		celula := CelulaMatriz{Variacao: variacao}
+		if variante, ok := porChave[chaveVariacao(produto.Dimensoes, variacao)]; ok {
+			celula.Item, celula.Codigo, celula.Nome = variante, variante.Codigo, variante.Nome
+		} else {
+			celula.Codigo, celula.Nome = codigoVariante(produto, variacao), nomeVariante(produto, variacao)
+		}
+		matriz.Celulas = append(matriz.Celulas, celula)
+	}
+	return matriz
+}
+
+// codigoVariante - Código sugerido: o do produto seguido dos valores, sem
+// acentos e em maiúsculas ("FON040-IN-EAR")
+func codigoVariante(produto *models.Iten, variacao models.Variacao) string {
+	partes := []string{produto.Codigo}
+	for _, valor := range produto.Dimensoes.Valores(variacao) {
+		partes = append(partes, strings.ToUpper(strings.ReplaceAll(sugestao.Normalizar(valor), " ", "-")))
+	}
+	return strings.Join(partes, "-")
+}
+
+// nomeVariante - Nome do produto seguido dos valores ("Fone de Ouvido In-Ear")
+func nomeVariante(produto *models.Iten, variacao models.Variacao) string {
+	return strings.Join(append([]string{produto.Nome}, produto.Dimensoes.Valores(variacao)...), " ")
+}
+
+// chaveVariacao - Identifica a combinação, independente da ordem do mapa
+func chaveVariacao(dimensoes models.DimensoesVariacao, variacao models.Variacao) string {
+	return strings.Join(dimensoes.Valores(variacao), "\x00")
+}
+
+// combinacaoValida - Um valor conhecido para cada dimensão e nada além delas
+func combinacaoValida(dimensoes models.DimensoesVariacao, variacao models.Variacao) bool {
+	if len(variacao) != len(dimensoes) {
+		return false
+	}
+	for _, dimensao := range dimensoes {
+		if !slices.Contains(dimensao.Valores, variacao[dimensao.Nome]) {
+			return false
+		}
+	}
+	return true
+}
+
+// validarDimensoes - Nomes válidos e únicos, valores únicos e não vazios e
+// um número limitado de combinações
+func validarDimensoes(dimensoes models.DimensoesVariacao) error {
+	nomes := map[string]bool{}
+	combinacoes := 1
+	for i := range dimensoes {
+		dimensao := &dimensoes[i]
+		switch {
+		case !nomeAtributoValido(dimensao.Nome):
+			return fmt.Errorf("%w: nome %q deve ter apenas letras minúsculas, dígitos e _", ErrDimensoes, dimensao.Nome)
+		case nomes[dimensao.Nome]:
+			return fmt.Errorf("%w: dimensão %s repetida", ErrDimensoes, dimensao.Nome)
+		case len(dimensao.Valores) == 0:
+			return fmt.Errorf("%w: a dimensão %s precisa de valores", ErrDimensoes, dimensao.Nome)
+		}
+		nomes[dimensao.Nome] = true
+		valores := map[string]bool{}
+		for j, valor := range dimensao.Valores {
+			valor = strings.TrimSpace(valor)
+			if valor == "" || valores[valor] {
+				return fmt.Errorf("%w: os valores de %s devem ser únicos e não vazios", ErrDimensoes, dimensao.Nome)
+			}
+			valores[valor] = true
+			dimensao.Valores[j] = valor
+		}
+		if combinacoes *= len(dimensao.Valores); combinacoes > combinacoesMaximas {
+			return fmt.Errorf("%w: mais de %d combinações", ErrDimensoes, combinacoesMaximas)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"myapi/internal/cache"
+	"myapi/internal/config"
+	"myapi/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrPedidoSemItens  = errors.New("o pedido precisa de ao menos um item")
+	ErrItemInexistente = errors.New("item não encontrado")
+	ErrReservaExpirada = errors.New("a reserva do pedido expirou")
+)
+
+type VendaRepository struct {
+	ctx context.Context
+}
+
+func NewVendaRepository(ctx context.Context) *VendaRepository {
+	return &VendaRepository{ctx: ctx}
+}
+
+// ListAll - Lista os pedidos de venda, opcionalmente filtrados por status
+func (r *VendaRepository) ListAll(status string) ([]models.PedidoVenda, error) {
+	var pedidos []models.PedidoVenda
+	q := config.Reader(r.ctx).Preload("Itens").Order("id")
+	if status != "" {
+		q = q.Where("status = ?", status)
+	}
+	if err := q.Find(&pedidos).Error; err != nil {
+		return nil, err
+	}
+	return pedidos, nil
+}
+
+func (r *VendaRepository) GetByID(id int) (*models.PedidoVenda, error) {
+	var pedido models.PedidoVenda
+	if err := config.Reader(r.ctx).Preload("Cliente").Preload("Itens").First(&pedido, id).Error; err != nil {
+		return nil, err
+	}
+	return &pedido, nil
+}
+
+// Create - Cria o pedido reservando o estoque das linhas no depósito. O
+// preço de cada linha é copiado do item neste momento. Os saldos são
+// bloqueados em ordem de item, então dois pedidos concorrentes nunca
+// reservam a mesma última unidade.
+func (r *VendaRepository) Create(pedido *models.PedidoVenda, ttl time.Duration) (*models.PedidoVenda, error) {
+	if len(pedido.Itens) == 0 {
+		return nil, ErrPedidoSemItens
+	}
+	err := config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		for i := range pedido.Itens {
+			linha := &pedido.Itens[i]
+			if linha.Quantidade <= 0 {
+				return ErrQuantidadeInvalida
+			}
+			var item models.Iten
+			if err := tx.Where("codigo = ?", linha.Codigo).First(&item).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("%w: %s", ErrItemInexistente, linha.Codigo)
+				}
+				return err
+			}
+			if len(item.Dimensoes) > 0 {
+				return fmt.Errorf("%w: %s", ErrItemProduto, linha.Codigo)
+			}
+			linha.Id = 0
+			linha.ItemId = item.Id
+			linha.PrecoUnitario = item.Preco
+			linha.Moeda = item.Moeda
+		}
+
+		if err := reservar(tx, pedido.DepositoId, pedido.Itens, 1); err != nil {
+			return err
+		}
+
+		expiraEm := time.Now().Add(ttl)
+		pedido.Id = 0
+		pedido.Status = models.VendaReservado
+		pedido.ExpiraEm = &expiraEm
+		return tx.Create(pedido).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pedido, nil
+}
+
+// Confirmar - Confirma o pedido; a reserva deixa de expirar
+func (r *VendaRepository) Confirmar(id int) (*models.PedidoVenda, error) {
+	return r.transicao(id, func(tx *gorm.DB, pedido *models.PedidoVenda) error {
+		if pedido.Status != models.VendaReservado {
+			return ErrTransicaoInvalida
+		}
+		if reservaExpirada(pedido) {
+			return ErrReservaExpirada
+		}
+		pedido.Status = models.VendaConfirmado
+		pedido.ExpiraEm = nil
+		return tx.Model(pedido).Select("status", "expira_em").Updates(pedido).Error
+	})
+}
+
+// Cancelar - Cancela o pedido liberando a reserva
+func (r *VendaRepository) Cancelar(id int) (*models.PedidoVenda, error) {
+	return r.transicao(id, func(tx *gorm.DB, pedido *models.PedidoVenda) error {
+		return liberar(tx, pedido, models.VendaCancelado)
+	})
+}
+
+// Atender - Baixa o estoque reservado com movimentações de saída e marca o
+// pedido como atendido. Para itens serializados, series traz por código os
+// números de série que saem, consumidos na ordem das linhas. Linhas de kit
+// baixam os componentes.
+func (r *VendaRepository) Atender(id int, series map[string][]string) (*models.PedidoVenda, error) {
+	var baixadas []models.PedidoVendaItem
+	pedido, err := r.transicao(id, func(tx *gorm.DB, pedido *models.PedidoVenda) error {
+		if pedido.Status != models.VendaConfirmado && pedido.Status != models.VendaReservado {
+			return ErrTransicaoInvalida
+		}
+		if reservaExpirada(pedido) {
+			return ErrReservaExpirada
+		}
+		if err := reservar(tx, pedido.DepositoId, pedido.Itens, -1); err != nil {
+			return err
+		}
+		var err error
+		if baixadas, err = explodir(tx, pedido.Itens); err != nil {
+			return err
+		}
+		for _, linha := range baixadas {
+			mov := models.Movimentacao{
+				ItemId:     linha.ItemId,
+				DepositoId: pedido.DepositoId,
+				Tipo:       models.MovimentacaoSaida,
+				Quantidade: -linha.Quantidade,
+				Referencia: fmt.Sprintf("PV-%d", pedido.Id),
+			}
+			if restantes := series[linha.Codigo]; len(restantes) > 0 {
+				n := min(linha.Quantidade, len(restantes))
+				mov.Series, series[linha.Codigo] = restantes[:n], restantes[n:]
+			}
+			if err := Movimentar(tx, &mov); err != nil {
+				return err
+			}
+		}
+		pedido.Status = models.VendaAtendido
+		pedido.ExpiraEm = nil
+		return tx.Model(pedido).Select("status", "expira_em").Updates(pedido).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, linha := range baixadas {
+		if err := cache.Invalidate(config.Writer(r.ctx), cache.Itens, linha.ItemId); err != nil {
+			return nil, err
+		}
+	}
+	return pedido, nil
+}
+
+// reservaExpirada - Reserva vencida que ExpirarReservas ainda não liberou
+func reservaExpirada(pedido *models.PedidoVenda) bool {
+	return pedido.Status == models.VendaReservado && pedido.ExpiraEm != nil && pedido.ExpiraEm.Before(time.Now())
+}
+
+// ExpirarReservas - Libera as reservas dos pedidos não confirmados dentro do
+// prazo. Pedidos bloqueados por outra transação ficam para a próxima execução.
+func (r *VendaRepository) ExpirarReservas() (int, error) {
+	var ids []uint
+	if err := config.Writer(r.ctx).Model(&models.PedidoVenda{}).
+		Where("status = ? AND expira_em < ?", models.VendaReservado, time.Now()).
+		Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	expirados := 0
+	for _, id := range ids {
+		err := config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+			var pedido models.PedidoVenda
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+				Preload("Itens").Where("status = ? AND expira_em < ?", models.VendaReservado, time.Now()).
+				First(&pedido, id).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			expirados++
+			return liberar(tx, &pedido, models.VendaExpirado)
+		})
+		if err != nil {
+			return expirados, err
+		}
+	}
+	return expirados, nil
+}
+
+// transicao - Executa fn com o pedido bloqueado e devolve o pedido atualizado
+func (r *VendaRepository) transicao(id int, fn func(tx *gorm.DB, pedido *models.PedidoVenda) error) (*models.PedidoVenda, error) {
+	var pedido models.PedidoVenda
+	err := config.Writer(r.ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Itens").First(&pedido, id).Error; err != nil {
+			return err
+		}
+		return fn(tx, &pedido)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pedido, nil
+}
+
+// liberar - Devolve ao disponível o estoque reservado pelo pedido
+func liberar(tx *gorm.DB, pedido *models.PedidoVenda, status string) error {
+	if !pedido.Reservado() {
+		return ErrTransicaoInvalida
+	}
+	if err := reservar(tx, pedido.DepositoId, pedido.Itens, -1); err != nil {
+		return err
+	}
+	pedido.Status = status
+	pedido.ExpiraEm = nil
+	return tx.Model(pedido).Select("status", "expira_em").Updates(pedido).Error
+}
+
+// reservar - Soma (sinal 1) ou devolve (sinal -1) as quantidades das linhas
+// à reserva do depósito. Ao reservar, exige que haja estoque disponível, sem
+// contar os lotes vencidos. Kits reservam os seus componentes.
+func reservar(tx *gorm.DB, depositoID uint, linhas []models.PedidoVendaItem, sinal int) error {
+	linhas, err := explodir(tx, linhas)
+	if err != nil {
+		return err
+	}
+	porItem := make(map[uint]int)
+	var itemIDs []uint
+	for _, linha := range linhas {
+		if _, ok := porItem[linha.ItemId]; !ok {
+			itemIDs = append(itemIDs, linha.ItemId)
+		}
+		porItem[linha.ItemId] += linha.Quantidade
+	}
+	// Ordem fixa de bloqueio para evitar deadlock entre pedidos
+	sort.Slice(itemIDs, func(i, j int) bool { return itemIDs[i] < itemIDs[j] })
+
+	for _, itemID := range itemIDs {
+		saldo, err := lockSaldo(tx, itemID, depositoID)
+		if err != nil {
+			return err
+		}
+		quantidade := porItem[itemID] * sinal
+		if sinal > 0 {
+			vencido, err := quantidadeVencida(tx, itemID, depositoID)
+			if err != nil {
+				return err
+			}
+			if saldo.Disponivel()-vencido < quantidade {
+				return fmt.Errorf("%w: item %d", ErrEstoqueInsuficiente, itemID)
+			}
+		}
+		if err := tx.Model(&models.EstoqueDeposito{}).
+			Where("item_id = ? AND deposito_id = ?", itemID, depositoID).
+			Update("reservado", gorm.Expr("reservado + ?", quantidade)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"myapi/internal/config"
+	"myapi/internal/handlers"
+	"myapi/internal/middleware"
+
+	"github.com/gorilla/mux"
+)
+
+func AdminRoutes(r *mux.Router) {
+	admin := r.PathPrefix("/admin").Subrouter()
+	admin.Use(middleware.Admin(config.LoadAdminConfig().Token))
+	admin.HandleFunc("/cache/flush", handlers.FlushCache).Methods("POST")
+}
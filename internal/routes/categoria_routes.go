@@ -12,4 +12,9 @@ func CategoriaRoutes(r *mux.Router) {
 	r.HandleFunc("/categorias/create", handlers.CreateCategoriaHandler).Methods("POST")
 	r.HandleFunc("/categorias/update", handlers.UpdateCategoriaHandler).Methods("PUT")
 	r.HandleFunc("/categorias/delete", handlers.DeleteCategoriaHandler).Methods("DELETE")
+	r.HandleFunc("/categorias/arvore", handlers.ArvoreCategoriasHandler).Methods("GET")
+	r.HandleFunc("/categorias/caminho", handlers.CaminhoCategoriaHandler).Methods("GET")
+	r.HandleFunc("/categorias/descendentes", handlers.DescendentesCategoriaHandler).Methods("GET")
+	r.HandleFunc("/categorias/atributos", handlers.AtributosCategoriaHandler).Methods("GET")
+	r.HandleFunc("/categorias/mover", handlers.MoverCategoriaHandler).Methods("POST")
 }
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"myapi/internal/handlers"
+
+	"github.com/gorilla/mux"
+)
+
+func CompraRoutes(r *mux.Router) {
+	r.HandleFunc("/api/fornecedores", handlers.ListFornecedores).Methods("GET")
+	r.HandleFunc("/api/fornecedores/{id}", handlers.GetFornecedor).Methods("GET")
+	r.HandleFunc("/api/fornecedores", handlers.CreateFornecedor).Methods("POST")
+	r.HandleFunc("/api/fornecedores", handlers.UpdateFornecedor).Methods("PUT")
+	r.HandleFunc("/api/fornecedores/{id}", handlers.DeleteFornecedor).Methods("DELETE")
+	r.HandleFunc("/api/fornecedores/{id}/codigos", handlers.ListCodigosFornecedor).Methods("GET")
+	r.HandleFunc("/api/fornecedores/{id}/codigos", handlers.SaveCodigoFornecedor).Methods("PUT")
+	r.HandleFunc("/api/fornecedores/{id}/codigos/{codigo}", handlers.DeleteCodigoFornecedor).Methods("DELETE")
+
+	r.HandleFunc("/api/compras", handlers.ListCompras).Methods("GET")
+	r.HandleFunc("/api/compras/{id}", handlers.GetCompra).Methods("GET")
+	r.HandleFunc("/api/compras", handlers.CreateCompra).Methods("POST")
+	r.HandleFunc("/api/compras/{id}", handlers.UpdateCompra).Methods("PUT")
+	r.HandleFunc("/api/compras/{id}/enviar", handlers.EnviarCompra).Methods("POST")
+	r.HandleFunc("/api/compras/{id}/cancelar", handlers.CancelarCompra).Methods("POST")
+	r.HandleFunc("/api/compras/{id}/recebimentos", handlers.ListRecebimentos).Methods("GET")
+	r.HandleFunc("/api/compras/{id}/recebimentos", handlers.CreateRecebimento).Methods("POST")
+}
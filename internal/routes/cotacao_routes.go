@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"myapi/internal/handlers"
+
+	"github.com/gorilla/mux"
+)
+
+func CotacaoRoutes(r *mux.Router) {
+	r.HandleFunc("/api/cotacoes", handlers.ListCotacoes).Methods("GET")
+	r.HandleFunc("/api/cotacoes", handlers.SaveCotacao).Methods("POST")
+	r.HandleFunc("/api/cotacoes/importar", handlers.ImportarCotacoes).Methods("POST")
+	r.HandleFunc("/api/cotacoes/{id}", handlers.DeleteCotacao).Methods("DELETE")
+}
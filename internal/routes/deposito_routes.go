@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"myapi/internal/handlers"
+
+	"github.com/gorilla/mux"
+)
+
+func DepositoRoutes(r *mux.Router) {
+	r.HandleFunc("/api/depositos", handlers.ListDepositos).Methods("GET")
+	r.HandleFunc("/api/depositos/{id}", handlers.GetDeposito).Methods("GET")
+	r.HandleFunc("/api/depositos", handlers.CreateDeposito).Methods("POST")
+	r.HandleFunc("/api/depositos", handlers.UpdateDeposito).Methods("PUT")
+	r.HandleFunc("/api/depositos/{id}", handlers.DeleteDeposito).Methods("DELETE")
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"myapi/internal/handlers"
+
+	"github.com/gorilla/mux"
+)
+
+func EstoqueRoutes(r *mux.Router) {
+	r.HandleFunc("/api/itens/{id}/estoque", handlers.GetEstoqueItem).Methods("GET")
+	r.HandleFunc("/api/estoque/movimentacoes", handlers.CreateMovimentacao).Methods("POST")
+	r.HandleFunc("/api/estoque/transferencias", handlers.CreateTransferencia).Methods("POST")
+}
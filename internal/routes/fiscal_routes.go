@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"myapi/internal/handlers"
+
+	"github.com/gorilla/mux"
+)
+
+func FiscalRoutes(r *mux.Router) {
+	r.HandleFunc("/api/fiscal/impostos", handlers.CalcularImpostos).Methods("POST")
+	r.HandleFunc("/api/fiscal/ncm/{ncm}", handlers.GetNcm).Methods("GET")
+	r.HandleFunc("/api/fiscal/aliquotas-icms", handlers.ListAliquotasIcms).Methods("GET")
+	r.HandleFunc("/api/fiscal/aliquotas-icms", handlers.SaveAliquotaIcms).Methods("PUT")
+	r.HandleFunc("/api/fiscal/aliquotas-icms/{origem}/{destino}", handlers.DeleteAliquotaIcms).Methods("DELETE")
+}
@@ -8,6 +8,10 @@ import (
 
 func ItemRoutes(r *mux.Router) {
 	r.HandleFunc("/api/itens", handlers.ListItens).Methods("GET")
+	// /api/v1 é o caminho pedido pelos clientes da sugestão; /api segue as
+	// demais rotas de itens
+	r.HandleFunc("/api/v1/itens/suggest", handlers.SugerirItens).Methods("GET")
+	r.HandleFunc("/api/itens/suggest", handlers.SugerirItens).Methods("GET")
 	r.HandleFunc("/api/itens/{id}", handlers.GetItem).Methods("GET")
 	r.HandleFunc("/api/itens/codigo/{codigo}", handlers.GetItemByCode).Methods("GET")
 	r.HandleFunc("/api/itens", handlers.CreateItem).Methods("POST")
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"myapi/internal/handlers"
+
+	"github.com/gorilla/mux"
+)
+
+func KitRoutes(r *mux.Router) {
+	r.HandleFunc("/api/itens/{id}/kit", handlers.GetKit).Methods("GET")
+	r.HandleFunc("/api/itens/{id}/kit", handlers.SaveKit).Methods("PUT")
+	r.HandleFunc("/api/itens/{id}/kit", handlers.DeleteKit).Methods("DELETE")
+}
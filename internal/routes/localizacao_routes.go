@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"myapi/internal/handlers"
+
+	"github.com/gorilla/mux"
+)
+
+func LocalizacaoRoutes(r *mux.Router) {
+	r.HandleFunc("/api/depositos/{id}/localizacoes", handlers.ListLocalizacoes).Methods("GET")
+	r.HandleFunc("/api/localizacoes", handlers.CreateLocalizacao).Methods("POST")
+	r.HandleFunc("/api/localizacoes/{id}", handlers.DeleteLocalizacao).Methods("DELETE")
+	r.HandleFunc("/api/armazenagem/regras", handlers.SaveRegraArmazenagem).Methods("PUT")
+	r.HandleFunc("/api/armazenagem", handlers.CreateArmazenagem).Methods("POST")
+	r.HandleFunc("/api/depositos/{id}/separacao", handlers.CreateListaSeparacao).Methods("POST")
+}
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"myapi/internal/handlers"
+
+	"github.com/gorilla/mux"
+)
+
+func LoteRoutes(r *mux.Router) {
+	r.HandleFunc("/api/itens/{id}/lotes", handlers.ListLotesItem).Methods("GET")
+	r.HandleFunc("/api/itens/{id}/lotes", handlers.CreateLote).Methods("POST")
+	r.HandleFunc("/api/lotes/vencendo", handlers.ListLotesVencendo).Methods("GET")
+}
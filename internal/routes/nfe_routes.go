@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"myapi/internal/handlers"
+
+	"github.com/gorilla/mux"
+)
+
+func NfeRoutes(r *mux.Router) {
+	r.HandleFunc("/api/nfe/importacoes", handlers.ListImportacoesNfe).Methods("GET")
+	r.HandleFunc("/api/nfe/importacoes", handlers.ImportarNfe).Methods("POST")
+	r.HandleFunc("/api/nfe/importacoes/{id}", handlers.GetImportacaoNfe).Methods("GET")
+	r.HandleFunc("/api/nfe/importacoes/{id}", handlers.DeleteImportacaoNfe).Methods("DELETE")
+	r.HandleFunc("/api/nfe/importacoes/{id}/confirmar", handlers.ConfirmarImportacaoNfe).Methods("POST")
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"myapi/internal/handlers"
+
+	"github.com/gorilla/mux"
+)
+
+func PrecificacaoRoutes(r *mux.Router) {
+	r.HandleFunc("/api/tabelas-preco", handlers.ListTabelasPreco).Methods("GET")
+	r.HandleFunc("/api/tabelas-preco/{id}", handlers.GetTabelaPreco).Methods("GET")
+	r.HandleFunc("/api/tabelas-preco", handlers.CreateTabelaPreco).Methods("POST")
+	r.HandleFunc("/api/tabelas-preco", handlers.UpdateTabelaPreco).Methods("PUT")
+	r.HandleFunc("/api/tabelas-preco/{id}", handlers.DeleteTabelaPreco).Methods("DELETE")
+	r.HandleFunc("/api/tabelas-preco/{id}/itens", handlers.ListPrecosTabela).Methods("GET")
+	r.HandleFunc("/api/tabelas-preco/{id}/itens/{itemId}", handlers.SavePrecoTabela).Methods("PUT")
+	r.HandleFunc("/api/tabelas-preco/{id}/itens/{itemId}", handlers.DeletePrecoTabela).Methods("DELETE")
+
+	r.HandleFunc("/api/regras-preco", handlers.ListRegrasPreco).Methods("GET")
+	r.HandleFunc("/api/regras-preco/{id}", handlers.GetRegraPreco).Methods("GET")
+	r.HandleFunc("/api/regras-preco", handlers.CreateRegraPreco).Methods("POST")
+	r.HandleFunc("/api/regras-preco", handlers.UpdateRegraPreco).Methods("PUT")
+	r.HandleFunc("/api/regras-preco/{id}", handlers.DeleteRegraPreco).Methods("DELETE")
+
+	r.HandleFunc("/api/itens/{id}/precificacao", handlers.GetPrecificacao).Methods("GET")
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"myapi/internal/handlers"
+
+	"github.com/gorilla/mux"
+)
+
+func PrecoRoutes(r *mux.Router) {
+	// /api/v1 é o caminho pedido pelos clientes de preços; /api segue as
+	// demais rotas de itens. Os dois atendem igual.
+	for _, prefixo := range []string{"/api/v1", "/api"} {
+		r.HandleFunc(prefixo+"/itens/{id}/precos", handlers.ListPrecosItem).Methods("GET")
+		r.HandleFunc(prefixo+"/itens/{id}/precos/vigente", handlers.GetPrecoVigente).Methods("GET")
+		r.HandleFunc(prefixo+"/itens/{id}/precos", handlers.AgendarPreco).Methods("POST")
+		r.HandleFunc(prefixo+"/itens/{id}/precos/{precoId}", handlers.CancelarPreco).Methods("DELETE")
+	}
+}
@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"myapi/internal/handlers"
+
+	"github.com/gorilla/mux"
+)
+
+func RelatorioRoutes(r *mux.Router) {
+	r.HandleFunc("/api/relatorios/valorizacao", handlers.GetValorizacao).Methods("GET")
+	r.HandleFunc("/api/relatorios/cmv", handlers.GetCMV).Methods("GET")
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"myapi/internal/handlers"
+
+	"github.com/gorilla/mux"
+)
+
+func ReposicaoRoutes(r *mux.Router) {
+	r.HandleFunc("/api/itens/{id}/reposicao", handlers.GetReposicaoItem).Methods("GET")
+	r.HandleFunc("/api/itens/{id}/reposicao", handlers.SaveReposicaoItem).Methods("PUT")
+	r.HandleFunc("/api/reposicao/sugestoes", handlers.ListSugestoesCompra).Methods("GET")
+	r.HandleFunc("/api/notificacoes", handlers.ListNotificacoes).Methods("GET")
+	r.HandleFunc("/api/notificacoes/{id}/lida", handlers.MarcarNotificacaoLida).Methods("POST")
+}
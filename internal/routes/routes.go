@@ -1,6 +1,9 @@
 package routes
 
 import (
+	"expvar"
+
+	"myapi/internal/config"
 	"myapi/internal/handlers"
 	"myapi/internal/middleware"
 
@@ -13,17 +16,55 @@ func SetupRoutes() *mux.Router {
 
 	// Global Middleware
 	r.Use(middleware.JsonContentType)
+	r.Use(middleware.ApiKey(config.LoadApiKeys()))
+	r.Use(middleware.Autor)
+	rateLimitCfg := config.LoadRateLimitConfig()
+	r.Use(middleware.RateLimit(rateLimitCfg, middleware.NewRateLimitStore(rateLimitCfg)))
+	r.Use(middleware.Consistency)
+	r.Use(middleware.Idempotency(config.LoadIdempotencyConfig()))
 
-	// Item Routes
+	// Item, Kit, Variacao, Preco, Precificacao e Cotacao Routes
 	ItemRoutes(r)
+	KitRoutes(r)
+	VariacaoRoutes(r)
+	PrecoRoutes(r)
+	PrecificacaoRoutes(r)
+	CotacaoRoutes(r)
 
 	// Categoria Routes
 	CategoriaRoutes(r)
 
+	// Deposito, Estoque, Localizacao, Lote e Serie Routes
+	DepositoRoutes(r)
+	EstoqueRoutes(r)
+	LocalizacaoRoutes(r)
+	LoteRoutes(r)
+	SerieRoutes(r)
+
+	// Fornecedor, Compra e NF-e Routes
+	CompraRoutes(r)
+	NfeRoutes(r)
+	ReposicaoRoutes(r)
+
+	// Cliente e Venda Routes
+	VendaRoutes(r)
+
+	// Relatorio Routes
+	RelatorioRoutes(r)
+
+	// Fiscal Routes
+	FiscalRoutes(r)
+
+	// Admin Routes
+	AdminRoutes(r)
+
 	// Swagger and Docs (Not using JsonContentType middleware explicitly here, 
 	// but r.Use applies to all sub-routes unless bypassed)
 	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
 	r.HandleFunc("/docs", handlers.ScalarHandler).Methods("GET")
 
+	// Métricas (pool de conexões etc.)
+	r.Handle("/debug/vars", expvar.Handler()).Methods("GET")
+
 	return r
 }
\ No newline at end of file
@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"myapi/internal/handlers"
+
+	"github.com/gorilla/mux"
+)
+
+func SerieRoutes(r *mux.Router) {
+	r.HandleFunc("/api/itens/{id}/series", handlers.ListSeriesItem).Methods("GET")
+	r.HandleFunc("/api/itens/{id}/series", handlers.RegistrarSeries).Methods("POST")
+	r.HandleFunc("/api/series/{numero}", handlers.GetSerie).Methods("GET")
+}
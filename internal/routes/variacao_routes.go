@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"myapi/internal/handlers"
+
+	"github.com/gorilla/mux"
+)
+
+func VariacaoRoutes(r *mux.Router) {
+	r.HandleFunc("/api/itens/{id}/variacoes", handlers.GetVariacoes).Methods("GET")
+	r.HandleFunc("/api/itens/{id}/variacoes", handlers.SaveVariacoes).Methods("PUT")
+	r.HandleFunc("/api/itens/{id}/variacoes", handlers.DeleteVariacoes).Methods("DELETE")
+	r.HandleFunc("/api/itens/{id}/variacoes/matriz", handlers.GetMatrizVariacoes).Methods("GET")
+	r.HandleFunc("/api/itens/{id}/variacoes/matriz", handlers.CriarMatrizVariacoes).Methods("POST")
+}
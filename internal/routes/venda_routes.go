@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"myapi/internal/handlers"
+
+	"github.com/gorilla/mux"
+)
+
+func VendaRoutes(r *mux.Router) {
+	r.HandleFunc("/api/clientes", handlers.ListClientes).Methods("GET")
+	r.HandleFunc("/api/clientes/{id}", handlers.GetCliente).Methods("GET")
+	r.HandleFunc("/api/clientes", handlers.CreateCliente).Methods("POST")
+	r.HandleFunc("/api/clientes", handlers.UpdateCliente).Methods("PUT")
+	r.HandleFunc("/api/clientes/{id}", handlers.DeleteCliente).Methods("DELETE")
+
+	r.HandleFunc("/api/vendas", handlers.ListVendas).Methods("GET")
+	r.HandleFunc("/api/vendas/{id}", handlers.GetVenda).Methods("GET")
+	r.HandleFunc("/api/vendas", handlers.CreateVenda).Methods("POST")
+	r.HandleFunc("/api/vendas/{id}/confirmar", handlers.ConfirmarVenda).Methods("POST")
+	r.HandleFunc("/api/vendas/{id}/atender", handlers.AtenderVenda).Methods("POST")
+	r.HandleFunc("/api/vendas/{id}/cancelar", handlers.CancelarVenda).Methods("POST")
+}
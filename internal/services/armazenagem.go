@@ -0,0 +1,264 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sort"
+
+	"myapi/internal/models"
+	"myapi/internal/repositories"
+)
+
+var (
+	ErrSemPosicaoLivre    = errors.New("nenhuma posição livre no depósito")
+	ErrHierarquiaInvalida = errors.New("hierarquia inválida: corredor > prateleira > posição, no mesmo depósito")
+)
+
+// pais - Tipo de localização que pode conter cada tipo ("" = raiz)
+var pais = map[string]string{
+	models.LocalizacaoCorredor:   "",
+	models.LocalizacaoPrateleira: models.LocalizacaoCorredor,
+	models.LocalizacaoPosicao:    models.LocalizacaoPrateleira,
+}
+
+// ValidarLocalizacao - Confere o tipo da localização e se o pai pertence ao
+// mesmo depósito e está no nível imediatamente acima
+func ValidarLocalizacao(ctx context.Context, localizacao *models.Localizacao) error {
+	tipoPai, ok := pais[localizacao.Tipo]
+	if !ok {
+		return ErrHierarquiaInvalida
+	}
+	if localizacao.ParentId == nil {
+		if tipoPai != "" {
+			return ErrHierarquiaInvalida
+		}
+		return nil
+	}
+	pai, err := repositories.NewLocalizacaoRepository(ctx).GetByID(int(*localizacao.ParentId))
+	if err != nil || pai.Tipo != tipoPai || pai.DepositoId != localizacao.DepositoId {
+		return ErrHierarquiaInvalida
+	}
+	return nil
+}
+
+// ArvoreLocalizacoes - Monta a árvore de localizações a partir da lista plana
+func ArvoreLocalizacoes(localizacoes []models.Localizacao) []models.Localizacao {
+	filhos := make(map[uint][]models.Localizacao)
+	for _, l := range localizacoes {
+		if l.ParentId != nil {
+			filhos[*l.ParentId] = append(filhos[*l.ParentId], l)
+		}
+	}
+	var montar func(l models.Localizacao) models.Localizacao
+	montar = func(l models.Localizacao) models.Localizacao {
+		for _, filho := range filhos[l.Id] {
+			l.Filhos = append(l.Filhos, montar(filho))
+		}
+		return l
+	}
+	arvore := []models.Localizacao{}
+	for _, l := range localizacoes {
+		if l.ParentId == nil {
+			arvore = append(arvore, montar(l))
+		}
+	}
+	return arvore
+}
+
+// PercursoPosicoes - Sequência de percurso das posições de um depósito.
+// Segue a hierarquia corredor > prateleira > posição pela Ordem de cada nó,
+// em serpentina: os corredores de índice ímpar são percorridos no sentido
+// inverso, para o separador não voltar ao início a cada corredor.
+func PercursoPosicoes(localizacoes []models.Localizacao) map[uint]int {
+	filhos := make(map[uint][]models.Localizacao)
+	var raizes []models.Localizacao
+	for _, l := range localizacoes {
+		if l.ParentId == nil {
+			raizes = append(raizes, l)
+		} else {
+			filhos[*l.ParentId] = append(filhos[*l.ParentId], l)
+		}
+	}
+
+	ordenar := func(nos []models.Localizacao, inverso bool) {
+		sort.SliceStable(nos, func(i, j int) bool {
+			if nos[i].Ordem != nos[j].Ordem {
+				return (nos[i].Ordem < nos[j].Ordem) != inverso
+			}
+			return (nos[i].Codigo < nos[j].Codigo) != inverso
+		})
+	}
+
+	sequencia := make(map[uint]int)
+	var visitar func(no models.Localizacao, inverso bool)
+	visitar = func(no models.Localizacao, inverso bool) {
+		if no.Tipo == models.LocalizacaoPosicao {
+			sequencia[no.Id] = len(sequencia)
+			return
+		}
+		nos := filhos[no.Id]
+		ordenar(nos, inverso)
+		for _, filho := range nos {
+			visitar(filho, inverso)
+		}
+	}
+
+	ordenar(raizes, false)
+	for i, raiz := range raizes {
+		visitar(raiz, i%2 == 1)
+	}
+	return sequencia
+}
+
+// EscolherPosicao - Define a posição de armazenagem do item: a posição fixa
+// da regra do item ou, sem regra, a posição mais próxima no percurso que
+// esteja vazia ou que já guarde o mesmo item
+func EscolherPosicao(ctx context.Context, itemID, depositoID uint) (uint, error) {
+	repository := repositories.NewLocalizacaoRepository(ctx)
+	regra, err := repository.GetRegra(itemID, depositoID)
+	if err != nil {
+		return 0, err
+	}
+	if regra != nil {
+		return regra.LocalizacaoId, nil
+	}
+
+	localizacoes, err := repository.ListByDeposito(int(depositoID))
+	if err != nil {
+		return 0, err
+	}
+	saldos, err := repository.SaldosByDeposito(int(depositoID))
+	if err != nil {
+		return 0, err
+	}
+	ocupadas := make(map[uint]bool)
+	for _, s := range saldos {
+		if s.ItemId != itemID {
+			ocupadas[s.LocalizacaoId] = true
+		}
+	}
+
+	percurso := PercursoPosicoes(localizacoes)
+	melhor, melhorSeq := uint(0), -1
+	for id, seq := range percurso {
+		if !ocupadas[id] && (melhorSeq == -1 || seq < melhorSeq) {
+			melhor, melhorSeq = id, seq
+		}
+	}
+	if melhorSeq == -1 {
+		return 0, ErrSemPosicaoLivre
+	}
+	return melhor, nil
+}
+
+// ItemSeparacao - Item e quantidade pedidos para separação
+type ItemSeparacao struct {
+	Codigo     string `json:"codigo"`
+	Quantidade int    `json:"quantidade"`
+}
+
+// LinhaSeparacao - Parada do separador: pegar Quantidade do item na posição
+type LinhaSeparacao struct {
+	Sequencia     int    `json:"sequencia"`
+	LocalizacaoId uint   `json:"localizacao_id"`
+	Localizacao   string `json:"localizacao"`
+	ItemId        uint   `json:"item_id"`
+	Codigo        string `json:"codigo"`
+	Quantidade    int    `json:"quantidade"`
+}
+
+// ListaSeparacao - Paradas em ordem de percurso e o que não pôde ser atendido
+type ListaSeparacao struct {
+	Linhas    []LinhaSeparacao `json:"linhas"`
+	Faltantes []ItemSeparacao  `json:"faltantes"`
+}
+
+// GerarListaSeparacao - Monta a lista de separação de um depósito: cada item
+// é retirado das suas posições na ordem do percurso e as paradas de todos os
+// itens são ordenadas pelo caminho do separador
+func GerarListaSeparacao(ctx context.Context, depositoID int, pedidos []ItemSeparacao) (*ListaSeparacao, error) {
+	for _, pedido := range pedidos {
+		if pedido.Quantidade <= 0 {
+			return nil, repositories.ErrQuantidadeInvalida
+		}
+	}
+	lista := &ListaSeparacao{Linhas: []LinhaSeparacao{}, Faltantes: []ItemSeparacao{}}
+
+	itemRepository := repositories.NewItemRepository(ctx)
+	itens := make(map[string]*models.Iten)
+	var itemIDs []uint
+	for _, pedido := range pedidos {
+		if _, ok := itens[pedido.Codigo]; ok {
+			continue
+		}
+		item, err := itemRepository.GetByCode(pedido.Codigo)
+		if err != nil {
+			continue
+		}
+		itens[pedido.Codigo] = item
+		itemIDs = append(itemIDs, item.Id)
+	}
+
+	localizacaoRepository := repositories.NewLocalizacaoRepository(ctx)
+	localizacoes, err := localizacaoRepository.ListByDeposito(depositoID)
+	if err != nil {
+		return nil, err
+	}
+	codigos := make(map[uint]string, len(localizacoes))
+	for _, l := range localizacoes {
+		codigos[l.Id] = l.Codigo
+	}
+	percurso := PercursoPosicoes(localizacoes)
+
+	var saldos []models.EstoqueLocalizacao
+	if len(itemIDs) > 0 {
+		if saldos, err = localizacaoRepository.SaldosByDeposito(depositoID, itemIDs...); err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(saldos, func(i, j int) bool {
+		return percurso[saldos[i].LocalizacaoId] < percurso[saldos[j].LocalizacaoId]
+	})
+	porItem := make(map[uint][]*models.EstoqueLocalizacao)
+	for i := range saldos {
+		porItem[saldos[i].ItemId] = append(porItem[saldos[i].ItemId], &saldos[i])
+	}
+
+	for _, pedido := range pedidos {
+		item, ok := itens[pedido.Codigo]
+		if !ok {
+			lista.Faltantes = append(lista.Faltantes, pedido)
+			continue
+		}
+		restante := pedido.Quantidade
+		for _, saldo := range porItem[item.Id] {
+			if restante == 0 {
+				break
+			}
+			retirar := min(saldo.Quantidade, restante)
+			if retirar == 0 {
+				continue
+			}
+			// O saldo é consumido para que pedidos repetidos do mesmo item
+			// não contem a mesma unidade duas vezes
+			saldo.Quantidade -= retirar
+			restante -= retirar
+			lista.Linhas = append(lista.Linhas, LinhaSeparacao{
+				Sequencia:     percurso[saldo.LocalizacaoId],
+				LocalizacaoId: saldo.LocalizacaoId,
+				Localizacao:   codigos[saldo.LocalizacaoId],
+				ItemId:        item.Id,
+				Codigo:        item.Codigo,
+				Quantidade:    retirar,
+			})
+		}
+		if restante > 0 {
+			lista.Faltantes = append(lista.Faltantes, ItemSeparacao{Codigo: pedido.Codigo, Quantidade: restante})
+		}
+	}
+
+	sort.SliceStable(lista.Linhas, func(i, j int) bool {
+		return lista.Linhas[i].Sequencia < lista.Linhas[j].Sequencia
+	})
+	return lista, nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"myapi/internal/decimal"
+	"myapi/internal/models"
+	"myapi/internal/money"
+	"myapi/internal/repositories"
+)
+
+// Converter - Converte o valor para a moeda de destino pelas cotações
+// vigentes na data
+func Converter(ctx context.Context, valor money.Money, destino string, em time.Time) (money.Money, error) {
+	origem := valor.Moeda()
+	if origem == destino {
+		return valor, nil
+	}
+	repository := repositories.NewCotacaoRepository(ctx)
+	taxaOrigem, err := repository.Taxa(origem, em)
+	if err != nil {
+		return money.Money{}, err
+	}
+	taxaDestino, err := repository.Taxa(destino, em)
+	if err != nil {
+		return money.Money{}, err
+	}
+	return valor.Converter(taxaOrigem, taxaDestino, destino), nil
+}
+
+// ConverterItens - Troca o preço dos itens pelo convertido para a moeda de destino
+func ConverterItens(ctx context.Context, itens []models.Iten, destino string, em time.Time) error {
+	if !money.CodigoValido(destino) {
+		return repositories.ErrMoedaInvalida
+	}
+	for i := range itens {
+		preco, err := Converter(ctx, itens[i].Preco.EmMoeda(itens[i].Moeda), destino, em)
+		if err != nil {
+			return err
+		}
+		itens[i].Preco = preco
+		itens[i].Moeda = destino
+	}
+	return nil
+}
+
+// ImportarCotacoes - Lê um CSV com as colunas moeda, data (AAAA-MM-DD) e
+// taxa, separadas por vírgula ou ponto e vírgula, com cabeçalho opcional,
+// e grava todas as linhas ou nenhuma
+func ImportarCotacoes(ctx context.Context, arquivo io.Reader) (int, error) {
+	conteudo, err := io.ReadAll(arquivo)
+	if err != nil {
+		return 0, err
+	}
+	leitor := csv.NewReader(strings.NewReader(string(conteudo)))
+	if primeira, _, _ := strings.Cut(string(conteudo), "\n"); strings.Contains(primeira, ";") {
+		leitor.Comma = ';'
+	}
+	leitor.FieldsPerRecord = 3
+	leitor.TrimLeadingSpace = true
+
+	var cotacoes []models.Cotacao
+	for linha := 1; ; linha++ {
+		campos, err := leitor.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", repositories.ErrCotacaoInvalida, err)
+		}
+		if linha == 1 && strings.EqualFold(strings.TrimSpace(campos[0]), "moeda") {
+			continue
+		}
+		data, err := time.Parse(time.DateOnly, strings.TrimSpace(campos[1]))
+		if err != nil {
+			return 0, fmt.Errorf("%w: linha %d: data %q, use AAAA-MM-DD", repositories.ErrCotacaoInvalida, linha, campos[1])
+		}
+		taxa, err := decimal.Parse(strings.Replace(strings.TrimSpace(campos[2]), ",", ".", 1))
+		if err != nil {
+			return 0, fmt.Errorf("%w: linha %d: taxa %q", repositories.ErrCotacaoInvalida, linha, campos[2])
+		}
+		cotacoes = append(cotacoes, models.Cotacao{
+			Moeda: strings.ToUpper(strings.TrimSpace(campos[0])), Data: data, Taxa: taxa,
+		})
+	}
+	if err := repositories.NewCotacaoRepository(ctx).Salvar(cotacoes); err != nil {
+		return 0, err
+	}
+	return len(cotacoes), nil
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"myapi/internal/config"
+	"myapi/internal/money"
+	"myapi/internal/repositories"
+)
+
+// TotalValorizacao - Quantidade e valor de uma categoria ou de um depósito.
+// Id nulo em por_categoria agrupa os itens sem categoria.
+type TotalValorizacao struct {
+	Id         *uint       `json:"id"`
+	Quantidade int         `json:"quantidade"`
+	Valor      money.Money `json:"valor"`
+}
+
+// Valorizacao - Valor do estoque em uma data
+type Valorizacao struct {
+	Data         string                          `json:"data"`
+	Metodo       string                          `json:"metodo"`
+	Total        money.Money                     `json:"total"`
+	PorCategoria []TotalValorizacao              `json:"por_categoria"`
+	PorDeposito  []TotalValorizacao              `json:"por_deposito"`
+	Linhas       []repositories.LinhaValorizacao `json:"linhas"`
+}
+
+// ValorizarEstoque - Valor do estoque no fim do dia informado, com os
+// totais por categoria e por depósito
+func ValorizarEstoque(ctx context.Context, data time.Time) (*Valorizacao, error) {
+	linhas, err := repositories.NewCustoRepository(ctx).Valorizacao(data)
+	if err != nil {
+		return nil, err
+	}
+	v := &Valorizacao{
+		Data:         data.Format(time.DateOnly),
+		Metodo:       config.LoadEstoqueConfig().CustoMetodo,
+		PorCategoria: []TotalValorizacao{},
+		PorDeposito:  []TotalValorizacao{},
+		Linhas:       linhas,
+	}
+	// Categoria 0 agrupa os itens sem categoria (os IDs começam em 1)
+	categorias := make(map[uint]int)
+	depositos := make(map[uint]int)
+	for _, l := range linhas {
+		v.Total = v.Total.Add(l.Valor)
+
+		var categoriaID uint
+		if l.CategoriaId != nil {
+			categoriaID = *l.CategoriaId
+		}
+		i, ok := categorias[categoriaID]
+		if !ok {
+			i = len(v.PorCategoria)
+			categorias[categoriaID] = i
+			v.PorCategoria = append(v.PorCategoria, TotalValorizacao{Id: l.CategoriaId})
+		}
+		v.PorCategoria[i].Quantidade += l.Quantidade
+		v.PorCategoria[i].Valor = v.PorCategoria[i].Valor.Add(l.Valor)
+
+		j, ok := depositos[l.DepositoId]
+		if !ok {
+			j = len(v.PorDeposito)
+			depositoID := l.DepositoId
+			depositos[depositoID] = j
+			v.PorDeposito = append(v.PorDeposito, TotalValorizacao{Id: &depositoID})
+		}
+		v.PorDeposito[j].Quantidade += l.Quantidade
+		v.PorDeposito[j].Valor = v.PorDeposito[j].Valor.Add(l.Valor)
+	}
+	return v, nil
+}
+
+// CustoVendas - Custo das mercadorias vendidas no período
+type CustoVendas struct {
+	De     string                  `json:"de"`
+	Ate    string                  `json:"ate"`
+	Metodo string                  `json:"metodo"`
+	Total  money.Money             `json:"total"`
+	Itens  []repositories.LinhaCMV `json:"itens"`
+}
+
+// CalcularCMV - Custo das saídas entre as datas, por item
+func CalcularCMV(ctx context.Context, de, ate time.Time) (*CustoVendas, error) {
+	itens, err := repositories.NewCustoRepository(ctx).CMV(de, ate)
+	if err != nil {
+		return nil, err
+	}
+	cmv := &CustoVendas{
+		De:     de.Format(time.DateOnly),
+		Ate:    ate.Format(time.DateOnly),
+		Metodo: config.LoadEstoqueConfig().CustoMetodo,
+		Itens:  itens,
+	}
+	for _, item := range itens {
+		cmv.Total = cmv.Total.Add(item.Valor)
+	}
+	return cmv, nil
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"myapi/internal/config"
+	"myapi/internal/decimal"
+	"myapi/internal/fiscal"
+	"myapi/internal/models"
+	"myapi/internal/money"
+	"myapi/internal/repositories"
+)
+
+// LinhaFiscal - Linha de venda a tributar. PrecoUnitario nulo usa o preço do
+// item; ConsumidorFinal indica venda a não contribuinte do ICMS.
+type LinhaFiscal struct {
+	ItemId          uint         `json:"item_id"`
+	Quantidade      int          `json:"quantidade"`
+	PrecoUnitario   *money.Money `json:"preco_unitario"`
+	UfOrigem        string       `json:"uf_origem"`
+	UfDestino       string       `json:"uf_destino"`
+	ConsumidorFinal bool         `json:"consumidor_final"`
+}
+
+// Imposto - Base, alíquota (percentual) e valor de um tributo
+type Imposto struct {
+	Base     money.Money     `json:"base"`
+	Aliquota decimal.Decimal `json:"aliquota"`
+	Valor    money.Money     `json:"valor"`
+}
+
+// CalculoImpostos - Tributos de uma linha. Difal é o diferencial de
+// alíquota devido à UF de destino nas vendas interestaduais a consumidor final.
+type CalculoImpostos struct {
+	ItemId        uint        `json:"item_id"`
+	Ncm           string      `json:"ncm"`
+	Cfop          string      `json:"cfop"`
+	Origem        int         `json:"origem"`
+	Quantidade    int         `json:"quantidade"`
+	ValorProdutos money.Money `json:"valor_produtos"`
+	Icms          Imposto     `json:"icms"`
+	Difal         *Imposto    `json:"difal,omitempty"`
+	Ipi           Imposto     `json:"ipi"`
+	Pis           Imposto     `json:"pis"`
+	Cofins        Imposto     `json:"cofins"`
+	TotalImpostos money.Money `json:"total_impostos"`
+	ValorTotal    money.Money `json:"valor_total"`
+}
+
+// CalcularImpostos - Tributos da linha pelas alíquotas do item e das tabelas:
+//   - IPI sobre o valor dos produtos;
+//   - ICMS sobre o valor dos produtos (mais o IPI na venda a consumidor
+//     final), com a alíquota interna da UF ou a interestadual;
+//   - DIFAL, na venda interestadual a consumidor final, pela diferença entre
+//     a alíquota interna do destino e a interestadual;
+//   - PIS e COFINS sobre o valor dos produtos sem o ICMS.
+//
+// Cada valor é arredondado com a regra de tributos (2 casas, meio para par).
+func CalcularImpostos(ctx context.Context, linha LinhaFiscal) (*CalculoImpostos, error) {
+	linha.UfOrigem = strings.ToUpper(linha.UfOrigem)
+	linha.UfDestino = strings.ToUpper(linha.UfDestino)
+	if !fiscal.UFValida(linha.UfOrigem) || !fiscal.UFValida(linha.UfDestino) {
+		return nil, repositories.ErrUFInvalida
+	}
+	if linha.Quantidade <= 0 {
+		return nil, repositories.ErrQuantidadeInvalida
+	}
+	item, err := repositories.NewItemRepository(ctx).GetByID(int(linha.ItemId))
+	if err != nil {
+		return nil, err
+	}
+	dados := item.Fiscal
+	interestadual := linha.UfOrigem != linha.UfDestino
+
+	preco := item.Preco
+	if linha.PrecoUnitario != nil {
+		preco = *linha.PrecoUnitario
+	}
+	calculo := &CalculoImpostos{
+		ItemId:        item.Id,
+		Ncm:           dados.Ncm,
+		Cfop:          cfop(dados, interestadual, linha.ConsumidorFinal),
+		Origem:        dados.Origem,
+		Quantidade:    linha.Quantidade,
+		ValorProdutos: preco.MulInt(int64(linha.Quantidade)).Arredondar(money.RegraPreco),
+	}
+
+	calculo.Ipi = tributar(calculo.ValorProdutos, derefAliquota(dados.AliquotaIpi))
+
+	baseIcms := calculo.ValorProdutos
+	if linha.ConsumidorFinal {
+		baseIcms = baseIcms.Add(calculo.Ipi.Valor)
+	}
+	aliquotas := repositories.NewAliquotaIcmsRepository(ctx)
+	aliquotaIcms, err := aliquotaIcms(aliquotas, dados, linha.UfOrigem, linha.UfDestino)
+	if err != nil {
+		return nil, err
+	}
+	calculo.Icms = tributar(baseIcms, aliquotaIcms)
+
+	if interestadual && linha.ConsumidorFinal {
+		interna, err := aliquotaInterna(aliquotas, linha.UfDestino)
+		if err != nil {
+			return nil, err
+		}
+		if diferenca := interna.Sub(aliquotaIcms); diferenca.Sign() > 0 {
+			difal := tributar(baseIcms, diferenca)
+			calculo.Difal = &difal
+		}
+	}
+
+	cfg := config.LoadFiscalConfig()
+	basePisCofins := calculo.ValorProdutos.Sub(calculo.Icms.Valor)
+	calculo.Pis = tributar(basePisCofins, aliquotaOuPadrao(dados.AliquotaPis, cfg.AliquotaPis))
+	calculo.Cofins = tributar(basePisCofins, aliquotaOuPadrao(dados.AliquotaCofins, cfg.AliquotaCofins))
+
+	calculo.TotalImpostos = calculo.Icms.Valor.Add(calculo.Ipi.Valor).Add(calculo.Pis.Valor).Add(calculo.Cofins.Valor)
+	if calculo.Difal != nil {
+		calculo.TotalImpostos = calculo.TotalImpostos.Add(calculo.Difal.Valor)
+	}
+	// O IPI é cobrado por fora; os demais já estão no preço
+	calculo.ValorTotal = calculo.ValorProdutos.Add(calculo.Ipi.Valor)
+	return calculo, nil
+}
+
+// tributar - Valor do tributo sobre a base pela alíquota percentual
+func tributar(base money.Money, aliquota decimal.Decimal) Imposto {
+	return Imposto{Base: base, Aliquota: aliquota, Valor: base.Mul(aliquota).DivInt(100, money.RegraImposto)}
+}
+
+// aliquotaIcms - Dentro da UF: a do item ou a interna da UF; entre UFs: a da
+// tabela cadastrada ou a interestadual padrão
+func aliquotaIcms(aliquotas *repositories.AliquotaIcmsRepository, dados models.DadosFiscais, origem, destino string) (decimal.Decimal, error) {
+	if origem == destino {
+		if dados.AliquotaIcms != nil {
+			return *dados.AliquotaIcms, nil
+		}
+		return aliquotaInterna(aliquotas, origem)
+	}
+	cadastrada, err := aliquotas.Buscar(origem, destino)
+	if err != nil || cadastrada != nil {
+		return derefAliquota(cadastrada), err
+	}
+	return fiscal.AliquotaInterestadual(origem, destino, dados.Origem), nil
+}
+
+// aliquotaInterna - Alíquota interna da UF, cadastrada ou padrão
+func aliquotaInterna(aliquotas *repositories.AliquotaIcmsRepository, uf string) (decimal.Decimal, error) {
+	cadastrada, err := aliquotas.Buscar(uf, uf)
+	if err != nil || cadastrada != nil {
+		return derefAliquota(cadastrada), err
+	}
+	return fiscal.AliquotaInterna(uf), nil
+}
+
+// cfop - CFOP do item ou o padrão de venda de mercadoria adquirida de terceiros
+func cfop(dados models.DadosFiscais, interestadual, consumidorFinal bool) string {
+	switch {
+	case !interestadual && dados.CfopEstadual != "":
+		return dados.CfopEstadual
+	case !interestadual:
+		return "5102"
+	case dados.CfopInterestadual != "":
+		return dados.CfopInterestadual
+	case consumidorFinal:
+		return "6108"
+	}
+	return "6102"
+}
+
+// aliquotaOuPadrao - Alíquota do item ou a padrão da configuração
+func aliquotaOuPadrao(aliquota *decimal.Decimal, padrao decimal.Decimal) decimal.Decimal {
+	if aliquota == nil {
+		return padrao
+	}
+	return *aliquota
+}
+
+// derefAliquota - Alíquota informada ou zero
+func derefAliquota(aliquota *decimal.Decimal) decimal.Decimal {
+	if aliquota == nil {
+		return decimal.Decimal{}
+	}
+	return *aliquota
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+
+	"myapi/internal/models"
+	"myapi/internal/repositories"
+)
+
+var ErrNaoEhKit = errors.New("item não é um kit")
+
+// DisponivelDeposito - Quantidade de kits que podem ser montados em um depósito
+type DisponivelDeposito struct {
+	DepositoId uint `json:"deposito_id"`
+	Disponivel int  `json:"disponivel"`
+}
+
+// DisponibilidadeKit - Composição do kit e quantos kits o estoque dos
+// componentes permite vender
+type DisponibilidadeKit struct {
+	KitId       uint                   `json:"kit_id"`
+	Componentes []models.ComponenteKit `json:"componentes"`
+	Disponivel  int                    `json:"disponivel"`
+	Depositos   []DisponivelDeposito   `json:"depositos"`
+}
+
+// CalcularKit - Disponibilidade do kit em cada depósito: o menor número de
+// kits que o disponível (físico - reservado) de cada componente final permite
+// montar, com as quantidades somadas por todos os kits aninhados. Como uma
+// venda sai de um único depósito, o total é a soma dos depósitos.
+func CalcularKit(ctx context.Context, kitID uint) (*DisponibilidadeKit, error) {
+	kitRepository := repositories.NewKitRepository(ctx)
+	componentes, err := kitRepository.Componentes(int(kitID))
+	if err != nil {
+		return nil, err
+	}
+	if len(componentes) == 0 {
+		return nil, ErrNaoEhKit
+	}
+	arvore, err := kitRepository.Arvore(kitID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Quantidade de cada componente final em um kit, somada em toda a árvore:
+	// a venda reserva e baixa esses componentes, e um componente presente em
+	// dois subkits precisa de estoque para os dois
+	necessidade := make(map[uint]int)
+	var somar func(itemID uint, quantidade int)
+	somar = func(itemID uint, quantidade int) {
+		filhos, ehKit := arvore[itemID]
+		if !ehKit {
+			necessidade[itemID] += quantidade
+			return
+		}
+		for _, c := range filhos {
+			somar(c.ComponenteId, quantidade*c.Quantidade)
+		}
+	}
+	somar(kitID, 1)
+
+	folhas := make([]uint, 0, len(necessidade))
+	for itemID := range necessidade {
+		folhas = append(folhas, itemID)
+	}
+	saldos, err := repositories.NewEstoqueRepository(ctx).ListByItens(folhas)
+	if err != nil {
+		return nil, err
+	}
+	type chave struct{ itemID, depositoID uint }
+	disponivel := make(map[chave]int, len(saldos))
+	depositos := make(map[uint]bool)
+	for _, s := range saldos {
+		disponivel[chave{s.ItemId, s.DepositoId}] = max(s.Disponivel(), 0)
+		depositos[s.DepositoId] = true
+	}
+
+	calcular := func(depositoID uint) int {
+		kits := math.MaxInt
+		for itemID, quantidade := range necessidade {
+			kits = min(kits, disponivel[chave{itemID, depositoID}]/quantidade)
+		}
+		return kits
+	}
+
+	resultado := &DisponibilidadeKit{KitId: kitID, Componentes: componentes, Depositos: []DisponivelDeposito{}}
+	for depositoID := range depositos {
+		n := calcular(depositoID)
+		resultado.Disponivel += n
+		resultado.Depositos = append(resultado.Depositos, DisponivelDeposito{DepositoId: depositoID, Disponivel: n})
+	}
+	sort.Slice(resultado.Depositos, func(i, j int) bool {
+		return resultado.Depositos[i].DepositoId < resultado.Depositos[j].DepositoId
+	})
+	return resultado, nil
+}
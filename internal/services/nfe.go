@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"myapi/internal/config"
+	"myapi/internal/fiscal"
+	"myapi/internal/models"
+	"myapi/internal/money"
+	"myapi/internal/nfe"
+	"myapi/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+// ImportarNfe - Lê o XML da NF-e, identifica o fornecedor pelo CNPJ do
+// emitente, procura o item de cada linha e grava a importação pendente,
+// com a proposta de cadastro das linhas sem item
+func ImportarNfe(ctx context.Context, arquivo io.Reader) (*models.ImportacaoNfe, error) {
+	nota, err := nfe.Ler(arquivo)
+	if err != nil {
+		return nil, err
+	}
+	importacao := &models.ImportacaoNfe{
+		Chave:        nota.Chave,
+		Numero:       nota.Numero,
+		Serie:        nota.Serie,
+		EmitidaEm:    nota.EmitidaEm,
+		EmitenteCnpj: nota.EmitenteCnpj,
+		EmitenteNome: nota.EmitenteNome,
+		ValorTotal:   money.FromDecimal(nota.ValorTotal),
+		Autor:        config.Autor(ctx),
+	}
+	for _, item := range nota.Itens {
+		var lotes []models.ImportacaoNfeLote
+		for _, lote := range item.Lotes {
+			lotes = append(lotes, models.ImportacaoNfeLote{
+				Numero:     lote.Numero,
+				Quantidade: lote.Quantidade,
+				Fabricacao: lote.Fabricacao,
+				Validade:   lote.Validade,
+			})
+		}
+		importacao.Linhas = append(importacao.Linhas, models.ImportacaoNfeLinha{
+			Numero:        item.Numero,
+			Codigo:        item.Codigo,
+			Ean:           item.Ean,
+			Descricao:     item.Descricao,
+			Ncm:           fiscal.Normalizar(item.Ncm),
+			Cest:          fiscal.Normalizar(item.Cest),
+			Cfop:          item.Cfop,
+			Origem:        item.Origem,
+			Unidade:       item.Unidade,
+			Quantidade:    item.Quantidade,
+			CustoUnitario: money.FromDecimal(item.ValorUnitario),
+			ValorTotal:    money.FromDecimal(item.ValorTotal),
+			Lotes:         lotes,
+		})
+	}
+
+	fornecedor, err := repositories.NewFornecedorRepository(ctx).GetByCnpj(nota.EmitenteCnpj)
+	switch {
+	case err == nil:
+		importacao.FornecedorId = &fornecedor.Id
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	repository := repositories.NewNfeRepository(ctx)
+	if err := repository.Corresponder(importacao.FornecedorId, importacao.Linhas); err != nil {
+		return nil, err
+	}
+	if err := repository.Registrar(importacao); err != nil {
+		return nil, err
+	}
+	return importacao, nil
+}
@@ -0,0 +1,198 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"myapi/internal/decimal"
+	"myapi/internal/models"
+	"myapi/internal/money"
+	"myapi/internal/repositories"
+
+	"gorm.io/gorm"
+)
+
+// ConsultaPreco - Parâmetros da precificação. Tabela (código) tem prioridade
+// sobre a tabela do cliente.
+type ConsultaPreco struct {
+	ItemId     uint
+	Quantidade int
+	Tabela     string
+	ClienteId  uint
+	Em         time.Time
+}
+
+// EtapaPreco - Um passo da precificação, para explicar o preço final
+type EtapaPreco struct {
+	Tipo      string      `json:"tipo"`
+	RegraId   *uint       `json:"regra_id,omitempty"`
+	Descricao string      `json:"descricao"`
+	Preco     money.Money `json:"preco"`
+	Aplicada  bool        `json:"aplicada"`
+}
+
+// Tipos de etapa da precificação
+const (
+	EtapaPrecoBase = "preco_base"
+	EtapaTabela    = "tabela"
+	EtapaRegra     = "regra"
+)
+
+// PrecoCalculado - Preço final de um item e as etapas que levaram a ele
+type PrecoCalculado struct {
+	ItemId        uint         `json:"item_id"`
+	Quantidade    int          `json:"quantidade"`
+	Moeda         string       `json:"moeda"`
+	Tabela        string       `json:"tabela,omitempty"`
+	Em            time.Time    `json:"em"`
+	PrecoBase     money.Money  `json:"preco_base"`
+	PrecoUnitario money.Money  `json:"preco_unitario"`
+	Total         money.Money  `json:"total"`
+	Etapas        []EtapaPreco `json:"etapas"`
+}
+
+// Precificar - Resolve o preço de um item:
+//  1. preço do item vigente na data (histórico de preços);
+//  2. preço próprio na tabela do cliente, se houver;
+//  3. regras que valem para o item, a categoria ou as categorias acima dela,
+//     a tabela, a quantidade e a data. As regras não se acumulam: vale a que
+//     der o menor preço, e entre preços iguais a mais específica (a categoria
+//     mais próxima vem antes das mais distantes).
+func Precificar(ctx context.Context, consulta ConsultaPreco) (*PrecoCalculado, error) {
+	item, err := repositories.NewItemRepository(ctx).GetByID(int(consulta.ItemId))
+	if err != nil {
+		return nil, err
+	}
+	resultado := &PrecoCalculado{ItemId: item.Id, Quantidade: consulta.Quantidade, Moeda: item.Moeda, Em: consulta.Em}
+
+	base := item.Preco
+	descricao := "Preço atual do item (sem histórico na data)"
+	vigente, err := repositories.NewPrecoRepository(ctx).VigenteEm(int(item.Id), consulta.Em)
+	switch {
+	case err == nil:
+		base = vigente.Preco
+		descricao = fmt.Sprintf("Preço do item vigente desde %s", vigente.VigenteDe.Format(time.RFC3339))
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+	resultado.PrecoBase = base
+	resultado.Etapas = append(resultado.Etapas, EtapaPreco{Tipo: EtapaPrecoBase, Descricao: descricao, Preco: base, Aplicada: true})
+
+	tabela, err := tabelaDaConsulta(ctx, consulta)
+	if err != nil {
+		return nil, err
+	}
+	preco := base
+	var tabelaID uint
+	if tabela != nil {
+		tabelaID = tabela.Id
+		resultado.Tabela = tabela.Codigo
+		precoTabela, err := repositories.NewTabelaPrecoRepository(ctx).PrecoItem(tabela.Id, item.Id)
+		switch {
+		case err == nil:
+			descricao := fmt.Sprintf("Preço da tabela %s", tabela.Codigo)
+			if precoTabela.Moeda != "" && precoTabela.Moeda != item.Moeda {
+				descricao += fmt.Sprintf(" (%s %s convertido)", precoTabela.Moeda, precoTabela.Preco.Decimal())
+			}
+			if preco, err = Converter(ctx, precoTabela.Preco.EmMoeda(precoTabela.Moeda), item.Moeda, consulta.Em); err != nil {
+				return nil, err
+			}
+			resultado.Etapas = append(resultado.Etapas, EtapaPreco{
+				Tipo: EtapaTabela, Descricao: descricao, Preco: preco, Aplicada: true,
+			})
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return nil, err
+		}
+	}
+
+	var categoriaID uint
+	if item.CategoriaId != nil {
+		categoriaID = *item.CategoriaId
+	}
+	regras, err := repositories.NewRegraPrecoRepository(ctx).
+		Aplicaveis(item.Id, categoriaID, tabelaID, consulta.Quantidade, consulta.Em)
+	if err != nil {
+		return nil, err
+	}
+
+	// As regras chegam das mais específicas para as mais gerais, então a
+	// comparação estrita mantém a mais específica em caso de empate
+	melhor := -1
+	final := preco
+	etapas := make([]EtapaPreco, len(regras))
+	for i, regra := range regras {
+		candidato, err := aplicarRegra(ctx, regra, preco, item.Moeda, consulta.Em)
+		if err != nil {
+			return nil, err
+		}
+		id := regra.Id
+		etapas[i] = EtapaPreco{Tipo: EtapaRegra, RegraId: &id, Descricao: descreverRegra(regra), Preco: candidato}
+		if candidato.Cmp(final) < 0 {
+			melhor, final = i, candidato
+		}
+	}
+	if melhor >= 0 {
+		etapas[melhor].Aplicada = true
+	}
+	resultado.Etapas = append(resultado.Etapas, etapas...)
+	resultado.PrecoUnitario = final
+	resultado.Total = final.MulInt(int64(consulta.Quantidade))
+	return resultado, nil
+}
+
+// tabelaDaConsulta - Tabela informada pelo código ou, sem ela, a do cliente
+func tabelaDaConsulta(ctx context.Context, consulta ConsultaPreco) (*models.TabelaPreco, error) {
+	repository := repositories.NewTabelaPrecoRepository(ctx)
+	if consulta.Tabela != "" {
+		return repository.GetByCodigo(consulta.Tabela)
+	}
+	if consulta.ClienteId == 0 {
+		return nil, nil
+	}
+	cliente, err := repositories.NewClienteRepository(ctx).GetByID(int(consulta.ClienteId))
+	if err != nil {
+		return nil, err
+	}
+	if cliente.TabelaPrecoId == nil {
+		return nil, nil
+	}
+	return repository.GetByID(int(*cliente.TabelaPrecoId))
+}
+
+// aplicarRegra - Preço resultante da regra sobre o preço de partida, na
+// moeda do item: o preço fixo é convertido pelas cotações da data e o
+// desconto é arredondado pela regra da moeda
+func aplicarRegra(ctx context.Context, regra models.RegraPreco, preco money.Money, moeda string, em time.Time) (money.Money, error) {
+	if regra.Preco != nil {
+		return Converter(ctx, regra.Preco.EmMoeda(regra.Moeda), moeda, em)
+	}
+	fator := decimal.NewFromInt(100).Sub(*regra.Percentual)
+	return preco.Mul(fator).DivInt(100, money.RegraDaMoeda(moeda)), nil
+}
+
+// descreverRegra - Texto da etapa: ajuste, alcance, quantidade e vigência
+func descreverRegra(regra models.RegraPreco) string {
+	var s string
+	if regra.Preco != nil {
+		s = fmt.Sprintf("%s: preço fixo %s %s", regra.Nome, regra.Moeda, regra.Preco.Decimal())
+	} else {
+		s = fmt.Sprintf("%s: %s%% de desconto", regra.Nome, regra.Percentual)
+	}
+	switch {
+	case regra.ItemId != nil:
+		s += " no item"
+	case regra.CategoriaCodigo != "":
+		s += " na categoria " + regra.CategoriaCodigo
+	default:
+		s += " em todos os itens"
+	}
+	if regra.QuantidadeMinima > 1 {
+		s += fmt.Sprintf(" a partir de %d unidades", regra.QuantidadeMinima)
+	}
+	if regra.VigenteAte != nil {
+		s += " até " + regra.VigenteAte.Format(time.RFC3339)
+	}
+	return s
+}
@@ -0,0 +1,150 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"myapi/internal/models"
+	"myapi/internal/repositories"
+)
+
+// ItemSugestao - Situação de um item e a quantidade sugerida para compra
+type ItemSugestao struct {
+	ItemId        uint    `json:"item_id"`
+	Codigo        string  `json:"codigo"`
+	Nome          string  `json:"nome"`
+	Disponivel    int     `json:"disponivel"`
+	EmPedido      int     `json:"em_pedido"`
+	ConsumoDiario float64 `json:"consumo_diario"`
+	PontoPedido   int     `json:"ponto_pedido"`
+	Maximo        int     `json:"maximo"`
+	Sugerido      int     `json:"sugerido"`
+}
+
+// SugestaoFornecedor - Itens a comprar de um fornecedor
+type SugestaoFornecedor struct {
+	FornecedorId uint           `json:"fornecedor_id"`
+	Itens        []ItemSugestao `json:"itens"`
+}
+
+// avaliar - Calcula o ponto de pedido e a sugestão de compra de um item.
+// A posição considera o disponível (físico - reservado) mais o que já está
+// em pedidos de compra abertos; abaixo do ponto de pedido, sugere completar
+// até o máximo (ou, sem máximo, até o ponto de pedido mais a demanda do lead time).
+func avaliar(p repositories.PosicaoEstoque, dias int) ItemSugestao {
+	consumoDiario := float64(p.Consumo) / float64(dias)
+	demandaLeadTime := int(math.Ceil(consumoDiario * float64(p.LeadTimeDias)))
+
+	pontoPedido := p.PontoPedido
+	if pontoPedido == 0 {
+		pontoPedido = p.Minimo + demandaLeadTime
+	}
+	maximo := p.Maximo
+	if maximo == 0 {
+		maximo = pontoPedido + demandaLeadTime
+	}
+
+	disponivel := p.Quantidade - p.Reservado
+	posicao := disponivel + p.EmPedido
+	sugerido := 0
+	if posicao <= pontoPedido {
+		sugerido = max(maximo-posicao, 0)
+	}
+	return ItemSugestao{
+		ItemId:        p.ItemId,
+		Codigo:        p.Codigo,
+		Nome:          p.Nome,
+		Disponivel:    disponivel,
+		EmPedido:      p.EmPedido,
+		ConsumoDiario: math.Round(consumoDiario*100) / 100,
+		PontoPedido:   pontoPedido,
+		Maximo:        maximo,
+		Sugerido:      sugerido,
+	}
+}
+
+// SugerirCompras - Sugestões de compra por fornecedor, com o consumo médio
+// calculado sobre os últimos dias
+func SugerirCompras(ctx context.Context, dias int) ([]SugestaoFornecedor, error) {
+	posicoes, err := repositories.NewReposicaoRepository(ctx).ListPosicoes(time.Now().AddDate(0, 0, -dias))
+	if err != nil {
+		return nil, err
+	}
+
+	sugestoes := []SugestaoFornecedor{}
+	indice := make(map[uint]int)
+	for _, p := range posicoes {
+		item := avaliar(p, dias)
+		if item.Sugerido == 0 {
+			continue
+		}
+		i, ok := indice[p.FornecedorId]
+		if !ok {
+			i = len(sugestoes)
+			indice[p.FornecedorId] = i
+			sugestoes = append(sugestoes, SugestaoFornecedor{FornecedorId: p.FornecedorId})
+		}
+		sugestoes[i].Itens = append(sugestoes[i].Itens, item)
+	}
+	return sugestoes, nil
+}
+
+// VerificarEstoqueBaixo - Gera uma notificação para cada item que passou a
+// ficar abaixo do ponto de pedido desde a última verificação e, se
+// configurado, envia o evento para o webhook
+func VerificarEstoqueBaixo(ctx context.Context, dias int, webhookURL string) error {
+	repository := repositories.NewReposicaoRepository(ctx)
+	posicoes, err := repository.ListPosicoes(time.Now().AddDate(0, 0, -dias))
+	if err != nil {
+		return err
+	}
+	for _, p := range posicoes {
+		item := avaliar(p, dias)
+		abaixo := item.Disponivel+item.EmPedido <= item.PontoPedido
+		if abaixo == p.Abaixo {
+			continue
+		}
+		if err := repository.SetAbaixo(p.ItemId, abaixo); err != nil {
+			return err
+		}
+		if !abaixo {
+			continue
+		}
+
+		itemID := p.ItemId
+		notificacao := models.Notificacao{
+			Tipo:   models.NotificacaoEstoqueBaixo,
+			ItemId: &itemID,
+			Mensagem: fmt.Sprintf("%s (%s) abaixo do ponto de pedido: disponível %d, em pedido %d, ponto de pedido %d, sugerido %d",
+				item.Nome, item.Codigo, item.Disponivel, item.EmPedido, item.PontoPedido, item.Sugerido),
+		}
+		if err := repository.CreateNotificacao(&notificacao); err != nil {
+			return err
+		}
+		log.Println(notificacao.Mensagem)
+		if webhookURL != "" {
+			go enviarWebhook(webhookURL, notificacao, item)
+		}
+	}
+	return nil
+}
+
+func enviarWebhook(url string, notificacao models.Notificacao, item ItemSugestao) {
+	body, _ := json.Marshal(map[string]any{"notificacao": notificacao, "item": item})
+	client := http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Printf("Erro ao enviar webhook de estoque baixo: %v", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		log.Printf("Webhook de estoque baixo respondeu %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"sort"
+
+	"myapi/internal/models"
+	"myapi/internal/repositories"
+)
+
+// EstoqueProduto - Saldos das variantes de um produto somados em um depósito
+type EstoqueProduto struct {
+	DepositoId uint `json:"deposito_id"`
+	Quantidade int  `json:"quantidade"`
+	Reservado  int  `json:"reservado"`
+	Disponivel int  `json:"disponivel"`
+}
+
+// ProdutoVariantes - Produto com as suas variantes e o estoque delas
+type ProdutoVariantes struct {
+	Produto   *models.Iten     `json:"produto"`
+	Variantes []models.Iten    `json:"variantes"`
+	Depositos []EstoqueProduto `json:"depositos"`
+}
+
+// ConsultarProduto - Produto, variantes e os saldos delas somados por
+// depósito; a quantidade do produto já é a soma das variantes
+func ConsultarProduto(ctx context.Context, produtoID uint) (*ProdutoVariantes, error) {
+	produto, err := repositories.NewItemRepository(ctx).GetByID(int(produtoID))
+	if err != nil {
+		return nil, err
+	}
+	if len(produto.Dimensoes) == 0 {
+		return nil, repositories.ErrNaoEhProduto
+	}
+	variantes, err := repositories.NewVariacaoRepository(ctx).Variantes(produtoID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(variantes))
+	for _, variante := range variantes {
+		ids = append(ids, variante.Id)
+	}
+	saldos, err := repositories.NewEstoqueRepository(ctx).ListByItens(ids)
+	if err != nil {
+		return nil, err
+	}
+	porDeposito := make(map[uint]*EstoqueProduto)
+	for _, s := range saldos {
+		estoque, ok := porDeposito[s.DepositoId]
+		if !ok {
+			estoque = &EstoqueProduto{DepositoId: s.DepositoId}
+			porDeposito[s.DepositoId] = estoque
+		}
+		estoque.Quantidade += s.Quantidade
+		estoque.Reservado += s.Reservado
+		estoque.Disponivel += max(s.Disponivel(), 0)
+	}
+	depositos := make([]EstoqueProduto, 0, len(porDeposito))
+	for _, estoque := range porDeposito {
+		depositos = append(depositos, *estoque)
+	}
+	sort.Slice(depositos, func(i, j int) bool { return depositos[i].DepositoId < depositos[j].DepositoId })
+
+	return &ProdutoVariantes{Produto: produto, Variantes: variantes, Depositos: depositos}, nil
+}
@@ -0,0 +1,260 @@
+// Package sugestao mantém em memória o índice de prefixos usado para sugerir
+// itens enquanto o usuário digita. O índice não acessa o banco: quem o usa
+// carrega as entradas e aplica as alterações (ver jobs.Sugestoes).
+package sugestao
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+
+	"myapi/internal/money"
+)
+
+// Entrada - Item sugerido, com o que é usado na ordenação
+type Entrada struct {
+	Id     uint        `json:"id"`
+	Codigo string      `json:"codigo"`
+	Nome   string      `json:"nome"`
+	Preco  money.Money `json:"preco"`
+	Moeda  string      `json:"moeda"`
+	// Saldo somado dos depósitos
+	Quantidade int `json:"quantidade"`
+	// Unidades vendidas no período configurado
+	Popularidade int `json:"popularidade"`
+}
+
+// termo - Texto normalizado em que a busca procura o prefixo
+type termo struct {
+	texto  string
+	codigo bool
+	e      *Entrada
+}
+
+// Indice - Termos ordenados para busca binária pelo prefixo. Cada item
+// contribui com o código e com o nome a partir de cada palavra, de modo que
+// "ouv" e "fone de o" encontram "Fone de Ouvido". As alterações montam um
+// novo slice de termos e o trocam atomicamente: as buscas não esperam por
+// elas nem por outras buscas.
+type Indice struct {
+	escrita  sync.Mutex
+	entradas map[uint]*Entrada
+	termos   atomic.Pointer[[]termo]
+}
+
+// Itens - Índice compartilhado pela API
+var Itens = NewIndice()
+
+// NewIndice - Cria um índice vazio
+func NewIndice() *Indice {
+	i := &Indice{entradas: map[uint]*Entrada{}}
+	i.termos.Store(&[]termo{})
+	return i
+}
+
+// Carregar - Substitui todo o conteúdo do índice
+func (i *Indice) Carregar(entradas []Entrada) {
+	novas := make(map[uint]*Entrada, len(entradas))
+	var termos []termo
+	for k := range entradas {
+		e := &entradas[k]
+		novas[e.Id] = e
+		termos = append(termos, termosDe(e)...)
+	}
+	sort.Slice(termos, func(a, b int) bool { return menor(termos[a], termos[b]) })
+
+	i.escrita.Lock()
+	defer i.escrita.Unlock()
+	i.entradas = novas
+	i.termos.Store(&termos)
+}
+
+// Atualizar - Inclui a entrada ou substitui a existente com o mesmo Id
+func (i *Indice) Atualizar(e Entrada) {
+	i.escrita.Lock()
+	defer i.escrita.Unlock()
+	antiga := i.entradas[e.Id]
+	i.entradas[e.Id] = &e
+	i.trocar(antiga, termosDe(&e))
+}
+
+// Remover - Retira o item do índice
+func (i *Indice) Remover(id uint) {
+	i.escrita.Lock()
+	defer i.escrita.Unlock()
+	if antiga, ok := i.entradas[id]; ok {
+		delete(i.entradas, id)
+		i.trocar(antiga, nil)
+	}
+}
+
+// trocar - Novo slice sem os termos da entrada antiga e com os novos, numa
+// única passada sobre os atuais
+func (i *Indice) trocar(antiga *Entrada, novos []termo) {
+	sort.Slice(novos, func(a, b int) bool { return menor(novos[a], novos[b]) })
+	atuais := *i.termos.Load()
+	termos := make([]termo, 0, len(atuais)+len(novos))
+	for _, t := range atuais {
+		if t.e == antiga {
+			continue
+		}
+		for len(novos) > 0 && menor(novos[0], t) {
+			termos = append(termos, novos[0])
+			novos = novos[1:]
+		}
+		termos = append(termos, t)
+	}
+	termos = append(termos, novos...)
+	i.termos.Store(&termos)
+}
+
+// Tamanho - Quantidade de itens no índice
+func (i *Indice) Tamanho() int {
+	i.escrita.Lock()
+	defer i.escrita.Unlock()
+	return len(i.entradas)
+}
+
+// candidato - Entrada encontrada; exato indica o código igual ao prefixo
+type candidato struct {
+	e     *Entrada
+	exato bool
+}
+
+// antes - Ordem das sugestões: código idêntico ao prefixo, mais vendidos,
+// mais estoque e, no empate, o nome
+func antes(a, b candidato) bool {
+	if a.exato != b.exato {
+		return a.exato
+	}
+	if a.e.Popularidade != b.e.Popularidade {
+		return a.e.Popularidade > b.e.Popularidade
+	}
+	if a.e.Quantidade != b.e.Quantidade {
+		return a.e.Quantidade > b.e.Quantidade
+	}
+	if a.e.Nome != b.e.Nome {
+		return a.e.Nome < b.e.Nome
+	}
+	return a.e.Id < b.e.Id
+}
+
+// Buscar - Até limite itens com código ou palavra do nome começando pelo
+// prefixo, sem diferenciar maiúsculas nem acentos. Percorre os termos com o
+// prefixo mantendo apenas os limite melhores, sem ordenar todos.
+func (i *Indice) Buscar(prefixo string, limite int) []Entrada {
+	p := Normalizar(prefixo)
+	if p == "" || limite <= 0 {
+		return []Entrada{}
+	}
+
+	termos := *i.termos.Load()
+	melhores := make([]candidato, 0, limite)
+	for k := sort.Search(len(termos), func(k int) bool { return termos[k].texto >= p }); k < len(termos); k++ {
+		t := termos[k]
+		if !strings.HasPrefix(t.texto, p) {
+			break
+		}
+		c := candidato{e: t.e, exato: t.codigo && t.texto == p}
+		if len(melhores) == limite && !antes(c, melhores[limite-1]) {
+			continue
+		}
+		// A entrada aparece uma vez por termo; fica a melhor ocorrência
+		pos := -1
+		for m := range melhores {
+			if melhores[m].e == c.e {
+				pos = m
+				break
+			}
+		}
+		switch {
+		case pos >= 0 && !antes(c, melhores[pos]):
+			continue
+		case pos >= 0:
+			melhores = append(melhores[:pos], melhores[pos+1:]...)
+		case len(melhores) == limite:
+			melhores = melhores[:limite-1]
+		}
+		pos = sort.Search(len(melhores), func(m int) bool { return antes(c, melhores[m]) })
+		melhores = append(melhores, candidato{})
+		copy(melhores[pos+1:], melhores[pos:])
+		melhores[pos] = c
+	}
+
+	encontradas := make([]Entrada, len(melhores))
+	for m, c := range melhores {
+		encontradas[m] = *c.e
+	}
+	return encontradas
+}
+
+// termosDe - Código e nome a partir de cada palavra. Os sufixos do nome
+// compartilham a memória da string normalizada.
+func termosDe(e *Entrada) []termo {
+	var termos []termo
+	if codigo := Normalizar(e.Codigo); codigo != "" {
+		termos = append(termos, termo{texto: codigo, codigo: true, e: e})
+	}
+	nome := Normalizar(e.Nome)
+	inicioPalavra := true
+	for pos, c := range nome {
+		if c == ' ' {
+			inicioPalavra = true
+			continue
+		}
+		if inicioPalavra {
+			termos = append(termos, termo{texto: nome[pos:], e: e})
+			inicioPalavra = false
+		}
+	}
+	return termos
+}
+
+func menor(a, b termo) bool {
+	if a.texto != b.texto {
+		return a.texto < b.texto
+	}
+	return a.e.Id < b.e.Id
+}
+
+// Normalizar - Minúsculas sem acentos, com pontuação e espaços repetidos
+// reduzidos a um espaço
+func Normalizar(s string) string {
+	var b strings.Builder
+	espaco := false
+	for _, c := range strings.ToLower(s) {
+		c = semAcento(c)
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			espaco = b.Len() > 0
+			continue
+		}
+		if espaco {
+			b.WriteByte(' ')
+			espaco = false
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+func semAcento(c rune) rune {
+	switch c {
+	case 'á', 'à', 'â', 'ã', 'ä':
+		return 'a'
+	case 'é', 'è', 'ê', 'ë':
+		return 'e'
+	case 'í', 'ì', 'î', 'ï':
+		return 'i'
+	case 'ó', 'ò', 'ô', 'õ', 'ö':
+		return 'o'
+	case 'ú', 'ù', 'û', 'ü':
+		return 'u'
+	case 'ç':
+		return 'c'
+	case 'ñ':
+		return 'n'
+	}
+	return c
+}
@@ -3,18 +3,58 @@ package main
 import (
 	"log"
 	"net/http"
+	"os"
 
+	"myapi/internal/cache"
 	"myapi/internal/config"
+	"myapi/internal/fiscal"
+	"myapi/internal/jobs"
+	"myapi/internal/middleware"
+	"myapi/internal/money"
 	"myapi/internal/routes"
 
 	_ "myapi/docs"
 )
 
 func main() {
+	dinheiroCfg := config.LoadDinheiroConfig()
+	money.Configurar(dinheiroCfg.MoedaPadrao, dinheiroCfg.JSON == "texto")
+	regras, err := money.ParseRegras(dinheiroCfg.Arredondamento)
+	if err != nil {
+		log.Fatalf("MOEDA_ARREDONDAMENTO inválido: %v", err)
+	}
+	money.ConfigurarArredondamento(regras)
+	if arquivo := config.LoadFiscalConfig().NcmArquivo; arquivo != "" {
+		carregarNcm(arquivo)
+	}
+
 	config.ConnectDatabase()
+	config.ConnectReplicas()
+
+	cacheCfg := config.LoadCacheConfig()
+	cache.Init(cacheCfg.Size, cacheCfg.TTL)
+	jobs.Sugestoes(config.LoadBuscaConfig())
+	cache.Listen(config.LoadDatabaseConfig().DSN())
+
+	middleware.PurgeIdempotencyKeys(config.LoadIdempotencyConfig().PurgeInterval)
+	jobs.ExpirarReservas(config.LoadVendaConfig().ExpiracaoInterval)
+	jobs.VerificarEstoqueBaixo(config.LoadReposicaoConfig())
+	jobs.AplicarPrecosAgendados(config.LoadPrecoConfig().AgendamentoInterval)
 
 	r := routes.SetupRoutes()
 
 	log.Println("Servidor rodando na porta 8080")
 	log.Fatal(http.ListenAndServe(":8080", r))
 }
+
+// carregarNcm - Troca a tabela NCM embutida pela do arquivo
+func carregarNcm(arquivo string) {
+	f, err := os.Open(arquivo)
+	if err != nil {
+		log.Fatalf("Erro ao abrir a tabela NCM: %v", err)
+	}
+	defer f.Close()
+	if err := fiscal.CarregarNcm(f); err != nil {
+		log.Fatalf("Erro ao carregar a tabela NCM: %v", err)
+	}
+}